@@ -3,6 +3,7 @@ package ast
 import (
 	"bolt/token"
 	"bytes"
+	"strings"
 )
 
 // Node is the interface that all nodes in the AST implement.
@@ -28,8 +29,10 @@ type Expression interface {
 
 // Program is the root node of every AST that the parser produces.
 //   - Statements: a slice of statements in the program
+//   - Comments: the token.COMMENT tokens found in the source, in order
 type Program struct {
 	Statements []Statement
+	Comments   []token.Token
 }
 
 // TokenLiteral returns the literal value of the first statement in the program.
@@ -41,26 +44,44 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
-// Create a buffer and write the return value of each statement's String method to it.
+// Create a buffer and write the return value of each statement's String method to it,
+// separating statements with newlines so that the result can be parsed back into the same program.
 // Return the buffer as a string.
 func (p *Program) String() string {
 	var out bytes.Buffer
 
-	for _, s := range p.Statements {
-		out.WriteString(s.String())
-	}
+	writeStatements(&out, p.Statements, "\n")
 
 	return out.String()
 }
 
+// Write statements to a buffer, each followed by sep but the last
+//   - An expression statement followed by another statement is terminated by a semicolon,
+//     so that a following statement starting with a parenthesis is not parsed as a call
+func writeStatements(out *bytes.Buffer, statements []Statement, sep string) {
+	for i, s := range statements {
+		if i > 0 {
+			out.WriteString(sep)
+		}
+		out.WriteString(s.String())
+		if _, ok := s.(*ExpressionStatement); ok && i+1 < len(statements) {
+			out.WriteString(";")
+		}
+	}
+}
+
 // LetStatement represents a let statement in the AST.
+//   - Export: the token.EXPORT token exporting the name from its module, or the zero token if it is not exported
 //   - Token: the token.LET token
 //   - Name: the identifier of the let statement
+//   - Type: the optional type annotation of the name, or nil
 //   - Value: the expression that the let statement is bound to
 type LetStatement struct {
-	Token token.Token
-	Name  *Identifier
-	Value Expression
+	Export token.Token
+	Token  token.Token
+	Name   *Identifier
+	Type   *TypeName
+	Value  Expression
 }
 
 func (ls *LetStatement) statementNode()       {}
@@ -68,8 +89,16 @@ func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
+	if ls.Exported() {
+		out.WriteString(ls.Export.Literal + " ")
+	}
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	if ls.Name != nil {
+		out.WriteString(ls.Name.String())
+	}
+	if ls.Type != nil {
+		out.WriteString(": " + ls.Type.String())
+	}
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -81,6 +110,40 @@ func (ls *LetStatement) String() string {
 	return out.String()
 }
 
+// Determine whether the let statement exports its name from its module
+func (ls *LetStatement) Exported() bool {
+	return ls.Export.Type != ""
+}
+
+// ImportStatement represents an import such as import "path/to/mod" as name; in the AST.
+//   - Token: the token.IMPORT token
+//   - Path: the path of the module imported
+//   - As: the token.AS token introducing the name, or the zero token if the name is derived from the path
+//   - Name: the identifier the module is bound to; if it is derived from the path, its token is that of the path
+type ImportStatement struct {
+	Token token.Token
+	Path  *StringLiteral
+	As    token.Token
+	Name  *Identifier
+}
+
+func (is *ImportStatement) statementNode()       {}
+func (is *ImportStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *ImportStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(is.TokenLiteral() + " ")
+	if is.Path != nil {
+		out.WriteString(is.Path.String())
+	}
+	if is.As.Type != "" && is.Name != nil {
+		out.WriteString(" " + is.As.Literal + " " + is.Name.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
 // Identifier represents an identifier in the AST.
 //   - Token: the token.IDENT token
 //   - Value: the value of the identifier
@@ -147,6 +210,18 @@ func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+// StringLiteral represents a string expression in the AST.
+//   - Token: the token.STRING token, whose literal is the source of the string including its quotes
+//   - Value: the value of the string, with its escapes decoded
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (sl *StringLiteral) expressionNode()      {}
+func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) String() string       { return sl.Token.Literal }
+
 // PrefixExpression represents a prefix expression in the AST.
 //   - Token: the prefix token
 //   - Operator: the operator of the prefix expression, e.g. ! or -
@@ -164,7 +239,9 @@ func (pe *PrefixExpression) String() string {
 
 	out.WriteString("(")
 	out.WriteString(pe.Operator)
-	out.WriteString(pe.Right.String())
+	if pe.Right != nil {
+		out.WriteString(pe.Right.String())
+	}
 	out.WriteString(")")
 
 	return out.String()
@@ -187,9 +264,13 @@ func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
-	out.WriteString(ie.Left.String())
+	if ie.Left != nil {
+		out.WriteString(ie.Left.String())
+	}
 	out.WriteString(" " + ie.Operator + " ")
-	out.WriteString(ie.Right.String())
+	if ie.Right != nil {
+		out.WriteString(ie.Right.String())
+	}
 	out.WriteString(")")
 
 	return out.String()
@@ -203,3 +284,230 @@ type Boolean struct {
 func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
 func (b *Boolean) String() string       { return b.Token.Literal }
+
+// BlockStatement represents a sequence of statements enclosed in braces in the AST.
+//   - Token: the token.LBRACE token
+//   - Statements: the statements of the block
+//   - Rbrace: the token.RBRACE token closing the block, or the zero token if the block is not closed
+type BlockStatement struct {
+	Token      token.Token
+	Statements []Statement
+	Rbrace     token.Token
+}
+
+func (bs *BlockStatement) statementNode()       {}
+func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("{")
+	if len(bs.Statements) > 0 {
+		out.WriteString(" ")
+		writeStatements(&out, bs.Statements, " ")
+	}
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// IfExpression represents a conditional expression such as if (<condition>) { ... } else { ... } in the AST.
+//   - Token: the token.IF token
+//   - Condition: the expression deciding which block is evaluated
+//   - Consequence: the block evaluated when the condition is truthy
+//   - Alternative: the optional block evaluated otherwise, or nil
+type IfExpression struct {
+	Token       token.Token
+	Condition   Expression
+	Consequence *BlockStatement
+	Alternative *BlockStatement
+}
+
+func (ie *IfExpression) expressionNode()      {}
+func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("if ")
+	if ie.Condition != nil {
+		out.WriteString(ie.Condition.String())
+	}
+	if ie.Consequence != nil {
+		out.WriteString(" " + ie.Consequence.String())
+	}
+	if ie.Alternative != nil {
+		out.WriteString(" else " + ie.Alternative.String())
+	}
+
+	return out.String()
+}
+
+// Parameter represents a parameter of a function literal in the AST.
+//   - Name: the identifier the argument is bound to
+//   - Type: the optional type annotation of the parameter, or nil
+type Parameter struct {
+	Name *Identifier
+	Type *TypeName
+}
+
+func (p *Parameter) TokenLiteral() string { return p.Name.TokenLiteral() }
+func (p *Parameter) String() string {
+	if p.Type != nil {
+		return p.Name.String() + ": " + p.Type.String()
+	}
+	return p.Name.String()
+}
+
+// FunctionLiteral represents a function such as fn(a: int, b) -> int { ... } in the AST.
+//   - Token: the token.FUNCTION token
+//   - Parameters: the parameters of the function, in order
+//   - ReturnType: the optional type annotation of the result, or nil
+//   - Body: the block evaluated when the function is called
+type FunctionLiteral struct {
+	Token      token.Token
+	Parameters []*Parameter
+	ReturnType *TypeName
+	Body       *BlockStatement
+}
+
+func (fl *FunctionLiteral) expressionNode()      {}
+func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) String() string {
+	var out bytes.Buffer
+
+	params := make([]string, len(fl.Parameters))
+	for i, p := range fl.Parameters {
+		params[i] = p.String()
+	}
+
+	out.WriteString(fl.TokenLiteral())
+	out.WriteString("(" + strings.Join(params, ", ") + ")")
+	if fl.ReturnType != nil {
+		out.WriteString(" -> " + fl.ReturnType.String())
+	}
+	if fl.Body != nil {
+		out.WriteString(" " + fl.Body.String())
+	}
+
+	return out.String()
+}
+
+// CallExpression represents a call such as <expression>(<arguments>) in the AST.
+//   - Token: the token.LPAREN token
+//   - Function: the expression evaluating to the function called
+//   - Arguments: the arguments of the call, in order
+type CallExpression struct {
+	Token     token.Token
+	Function  Expression
+	Arguments []Expression
+}
+
+func (ce *CallExpression) expressionNode()      {}
+func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) String() string {
+	var out bytes.Buffer
+
+	args := make([]string, len(ce.Arguments))
+	for i, a := range ce.Arguments {
+		args[i] = a.String()
+	}
+
+	if ce.Function != nil {
+		out.WriteString(ce.Function.String())
+	}
+	out.WriteString("(" + strings.Join(args, ", ") + ")")
+
+	return out.String()
+}
+
+// MemberExpression represents the selection of a member of a module such as <expression>.<name> in the AST.
+//   - Token: the token.DOT token
+//   - Object: the expression evaluating to the module
+//   - Member: the name of the member, which is not resolved in any scope
+type MemberExpression struct {
+	Token  token.Token
+	Object Expression
+	Member *Identifier
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) String() string {
+	var out bytes.Buffer
+
+	if me.Object != nil {
+		out.WriteString(me.Object.String())
+	}
+	out.WriteString(".")
+	if me.Member != nil {
+		out.WriteString(me.Member.String())
+	}
+
+	return out.String()
+}
+
+// TypeName represents a type annotation in the AST, naming a type such as int.
+//   - Token: the token.IDENT token
+//   - Value: the name of the type
+type TypeName struct {
+	Token token.Token
+	Value string
+}
+
+func (tn *TypeName) TokenLiteral() string { return tn.Token.Literal }
+func (tn *TypeName) String() string       { return tn.Value }
+
+// Return the first token of a node in the source, which gives its position
+//   - The token of an infix expression is its operator, that of a call its left parenthesis and
+//     that of a member expression its dot, so the first token is that of their left operand
+//   - The first token of an exported let statement is its export token
+func FirstToken(node Node) token.Token {
+	switch n := node.(type) {
+	case *LetStatement:
+		if n.Exported() {
+			return n.Export
+		}
+		return n.Token
+	case *ImportStatement:
+		return n.Token
+	case *StringLiteral:
+		return n.Token
+	case *ReturnStatement:
+		return n.Token
+	case *ExpressionStatement:
+		return n.Token
+	case *Identifier:
+		return n.Token
+	case *IntegerLiteral:
+		return n.Token
+	case *Boolean:
+		return n.Token
+	case *TypeName:
+		return n.Token
+	case *PrefixExpression:
+		return n.Token
+	case *InfixExpression:
+		if n.Left != nil {
+			return FirstToken(n.Left)
+		}
+		return n.Token
+	case *BlockStatement:
+		return n.Token
+	case *IfExpression:
+		return n.Token
+	case *Parameter:
+		return n.Name.Token
+	case *FunctionLiteral:
+		return n.Token
+	case *CallExpression:
+		if n.Function != nil {
+			return FirstToken(n.Function)
+		}
+		return n.Token
+	case *MemberExpression:
+		if n.Object != nil {
+			return FirstToken(n.Object)
+		}
+		return n.Token
+	}
+	return token.Token{}
+}
@@ -0,0 +1,618 @@
+package ast
+
+import (
+	"bolt/token"
+	"encoding/json"
+	"fmt"
+)
+
+// jsonToken is the JSON representation of a token, including its position in the source
+type jsonToken struct {
+	Type    token.TokenType `json:"type"`
+	Literal string          `json:"literal"`
+	Line    int             `json:"line"`
+	Column  int             `json:"column"`
+}
+
+// jsonNode is the JSON representation of any node in the AST
+//   - Kind: the discriminator naming the node type, e.g. "InfixExpression"
+//   - Token: the token the node was parsed from
+//   - Value: the literal value of identifiers, integers, strings, booleans and type names, and never a child node
+//   - Expression: the expression of an expression statement, or the value bound by a let statement
+//   - Export, As: the export token of an exported let statement, and the as token of an import naming its module
+//   - Statements: the statements of a program or block statement
+//   - The remaining fields hold the children of the node, and are omitted when unused
+type jsonNode struct {
+	Kind        string          `json:"kind"`
+	Token       *jsonToken      `json:"token,omitempty"`
+	Rbrace      *jsonToken      `json:"rbrace,omitempty"`
+	Export      *jsonToken      `json:"export,omitempty"`
+	As          *jsonToken      `json:"as,omitempty"`
+	Statements  []*jsonNode     `json:"statements,omitempty"`
+	Comments    []*jsonToken    `json:"comments,omitempty"`
+	Name        *jsonNode       `json:"name,omitempty"`
+	Type        *jsonNode       `json:"type,omitempty"`
+	Value       json.RawMessage `json:"value,omitempty"`
+	ReturnValue *jsonNode       `json:"returnValue,omitempty"`
+	Expression  *jsonNode       `json:"expression,omitempty"`
+	Operator    string          `json:"operator,omitempty"`
+	Left        *jsonNode       `json:"left,omitempty"`
+	Right       *jsonNode       `json:"right,omitempty"`
+	Condition   *jsonNode       `json:"condition,omitempty"`
+	Consequence *jsonNode       `json:"consequence,omitempty"`
+	Alternative *jsonNode       `json:"alternative,omitempty"`
+	Parameters  []*jsonNode     `json:"parameters,omitempty"`
+	ReturnType  *jsonNode       `json:"returnType,omitempty"`
+	Body        *jsonNode       `json:"body,omitempty"`
+	Function    *jsonNode       `json:"function,omitempty"`
+	Arguments   []*jsonNode     `json:"arguments,omitempty"`
+	Path        *jsonNode       `json:"path,omitempty"`
+	Object      *jsonNode       `json:"object,omitempty"`
+	Member      *jsonNode       `json:"member,omitempty"`
+}
+
+// MarshalJSON encodes a node and all of its children as JSON
+//   - Every node carries a "kind" discriminator and its token (type, literal and position)
+//   - Children are keyed by their role, e.g. "expression" for the value of a let statement, while "value"
+//     only ever holds the literal value of a leaf node such as an identifier or integer
+//   - Nil children are omitted
+func MarshalJSON(node Node) ([]byte, error) {
+	n, err := encodeNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(n)
+}
+
+// UnmarshalJSON decodes a node previously encoded with MarshalJSON
+func UnmarshalJSON(data []byte) (Node, error) {
+	var n jsonNode
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, err
+	}
+	return decodeNode(&n)
+}
+
+// MarshalJSON implements json.Marshaler for the program
+func (p *Program) MarshalJSON() ([]byte, error) {
+	return MarshalJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for the program
+func (p *Program) UnmarshalJSON(data []byte) error {
+	node, err := UnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+	program, ok := node.(*Program)
+	if !ok {
+		return fmt.Errorf("expected Program, got %T", node)
+	}
+	*p = *program
+	return nil
+}
+
+// Convert a node to its JSON representation
+func encodeNode(node Node) (*jsonNode, error) {
+	var err error
+
+	switch n := node.(type) {
+	case *Program:
+		out := &jsonNode{Kind: "Program", Statements: []*jsonNode{}}
+		for _, s := range n.Statements {
+			stmt, err := encodeNode(s)
+			if err != nil {
+				return nil, err
+			}
+			out.Statements = append(out.Statements, stmt)
+		}
+		for _, c := range n.Comments {
+			out.Comments = append(out.Comments, encodeToken(c))
+		}
+		return out, nil
+
+	case *LetStatement:
+		out := &jsonNode{Kind: "LetStatement", Token: encodeToken(n.Token)}
+		if n.Exported() {
+			out.Export = encodeToken(n.Export)
+		}
+		if n.Name != nil {
+			if out.Name, err = encodeNode(n.Name); err != nil {
+				return nil, err
+			}
+		}
+		if n.Type != nil {
+			if out.Type, err = encodeNode(n.Type); err != nil {
+				return nil, err
+			}
+		}
+		if n.Value != nil {
+			if out.Expression, err = encodeNode(n.Value); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case *ImportStatement:
+		out := &jsonNode{Kind: "ImportStatement", Token: encodeToken(n.Token)}
+		if n.Path != nil {
+			if out.Path, err = encodeNode(n.Path); err != nil {
+				return nil, err
+			}
+		}
+		if n.As.Type != "" {
+			out.As = encodeToken(n.As)
+		}
+		if n.Name != nil {
+			if out.Name, err = encodeNode(n.Name); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case *ReturnStatement:
+		out := &jsonNode{Kind: "ReturnStatement", Token: encodeToken(n.Token)}
+		if n.ReturnValue != nil {
+			if out.ReturnValue, err = encodeNode(n.ReturnValue); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case *ExpressionStatement:
+		out := &jsonNode{Kind: "ExpressionStatement", Token: encodeToken(n.Token)}
+		if n.Expression != nil {
+			if out.Expression, err = encodeNode(n.Expression); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case *PrefixExpression:
+		out := &jsonNode{Kind: "PrefixExpression", Token: encodeToken(n.Token), Operator: n.Operator}
+		if n.Right != nil {
+			if out.Right, err = encodeNode(n.Right); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case *InfixExpression:
+		out := &jsonNode{Kind: "InfixExpression", Token: encodeToken(n.Token), Operator: n.Operator}
+		if n.Left != nil {
+			if out.Left, err = encodeNode(n.Left); err != nil {
+				return nil, err
+			}
+		}
+		if n.Right != nil {
+			if out.Right, err = encodeNode(n.Right); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case *BlockStatement:
+		out := &jsonNode{Kind: "BlockStatement", Token: encodeToken(n.Token), Rbrace: encodeToken(n.Rbrace), Statements: []*jsonNode{}}
+		for _, s := range n.Statements {
+			stmt, err := encodeNode(s)
+			if err != nil {
+				return nil, err
+			}
+			out.Statements = append(out.Statements, stmt)
+		}
+		return out, nil
+
+	case *IfExpression:
+		out := &jsonNode{Kind: "IfExpression", Token: encodeToken(n.Token)}
+		if n.Condition != nil {
+			if out.Condition, err = encodeNode(n.Condition); err != nil {
+				return nil, err
+			}
+		}
+		if n.Consequence != nil {
+			if out.Consequence, err = encodeNode(n.Consequence); err != nil {
+				return nil, err
+			}
+		}
+		if n.Alternative != nil {
+			if out.Alternative, err = encodeNode(n.Alternative); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case *Parameter:
+		out := &jsonNode{Kind: "Parameter"}
+		if n.Name != nil {
+			if out.Name, err = encodeNode(n.Name); err != nil {
+				return nil, err
+			}
+		}
+		if n.Type != nil {
+			if out.Type, err = encodeNode(n.Type); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case *FunctionLiteral:
+		out := &jsonNode{Kind: "FunctionLiteral", Token: encodeToken(n.Token), Parameters: []*jsonNode{}}
+		for _, p := range n.Parameters {
+			param, err := encodeNode(p)
+			if err != nil {
+				return nil, err
+			}
+			out.Parameters = append(out.Parameters, param)
+		}
+		if n.ReturnType != nil {
+			if out.ReturnType, err = encodeNode(n.ReturnType); err != nil {
+				return nil, err
+			}
+		}
+		if n.Body != nil {
+			if out.Body, err = encodeNode(n.Body); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case *CallExpression:
+		out := &jsonNode{Kind: "CallExpression", Token: encodeToken(n.Token), Arguments: []*jsonNode{}}
+		if n.Function != nil {
+			if out.Function, err = encodeNode(n.Function); err != nil {
+				return nil, err
+			}
+		}
+		for _, a := range n.Arguments {
+			arg, err := encodeNode(a)
+			if err != nil {
+				return nil, err
+			}
+			out.Arguments = append(out.Arguments, arg)
+		}
+		return out, nil
+
+	case *MemberExpression:
+		out := &jsonNode{Kind: "MemberExpression", Token: encodeToken(n.Token)}
+		if n.Object != nil {
+			if out.Object, err = encodeNode(n.Object); err != nil {
+				return nil, err
+			}
+		}
+		if n.Member != nil {
+			if out.Member, err = encodeNode(n.Member); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case *Identifier:
+		return encodeLeaf("Identifier", n.Token, n.Value)
+
+	case *StringLiteral:
+		return encodeLeaf("StringLiteral", n.Token, n.Value)
+
+	case *IntegerLiteral:
+		return encodeLeaf("IntegerLiteral", n.Token, n.Value)
+
+	case *Boolean:
+		return encodeLeaf("Boolean", n.Token, n.Value)
+
+	case *TypeName:
+		return encodeLeaf("TypeName", n.Token, n.Value)
+
+	default:
+		return nil, fmt.Errorf("ast.MarshalJSON: unexpected node type %T", n)
+	}
+}
+
+// Convert a leaf node holding a plain value to its JSON representation
+func encodeLeaf(kind string, tok token.Token, value interface{}) (*jsonNode, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonNode{Kind: kind, Token: encodeToken(tok), Value: raw}, nil
+}
+
+func encodeToken(tok token.Token) *jsonToken {
+	return &jsonToken{Type: tok.Type, Literal: tok.Literal, Line: tok.Line, Column: tok.Column}
+}
+
+// Convert a JSON representation back into a node
+func decodeNode(n *jsonNode) (Node, error) {
+	var err error
+	tok := decodeToken(n.Token)
+
+	switch n.Kind {
+	case "Program":
+		out := &Program{Statements: []Statement{}}
+		for _, s := range n.Statements {
+			stmt, err := decodeStatement(s)
+			if err != nil {
+				return nil, err
+			}
+			out.Statements = append(out.Statements, stmt)
+		}
+		for _, c := range n.Comments {
+			out.Comments = append(out.Comments, decodeToken(c))
+		}
+		return out, nil
+
+	case "LetStatement":
+		out := &LetStatement{Export: decodeToken(n.Export), Token: tok}
+		if n.Name != nil {
+			if out.Name, err = decodeIdentifier("LetStatement name", n.Name); err != nil {
+				return nil, err
+			}
+		}
+		if n.Type != nil {
+			if out.Type, err = decodeTypeName(n.Type); err != nil {
+				return nil, err
+			}
+		}
+		if n.Expression != nil {
+			if out.Value, err = decodeExpression(n.Expression); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case "ImportStatement":
+		out := &ImportStatement{Token: tok, As: decodeToken(n.As)}
+		if n.Path != nil {
+			path, err := decodeNode(n.Path)
+			if err != nil {
+				return nil, err
+			}
+			literal, ok := path.(*StringLiteral)
+			if !ok {
+				return nil, fmt.Errorf("ImportStatement path must be a StringLiteral, got %s", n.Path.Kind)
+			}
+			out.Path = literal
+		}
+		if n.Name != nil {
+			if out.Name, err = decodeIdentifier("ImportStatement name", n.Name); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case "ReturnStatement":
+		out := &ReturnStatement{Token: tok}
+		if out.ReturnValue, err = decodeExpression(n.ReturnValue); err != nil {
+			return nil, err
+		}
+		return out, nil
+
+	case "ExpressionStatement":
+		out := &ExpressionStatement{Token: tok}
+		if out.Expression, err = decodeExpression(n.Expression); err != nil {
+			return nil, err
+		}
+		return out, nil
+
+	case "PrefixExpression":
+		out := &PrefixExpression{Token: tok, Operator: n.Operator}
+		if out.Right, err = decodeExpression(n.Right); err != nil {
+			return nil, err
+		}
+		return out, nil
+
+	case "InfixExpression":
+		out := &InfixExpression{Token: tok, Operator: n.Operator}
+		if out.Left, err = decodeExpression(n.Left); err != nil {
+			return nil, err
+		}
+		if out.Right, err = decodeExpression(n.Right); err != nil {
+			return nil, err
+		}
+		return out, nil
+
+	case "BlockStatement":
+		return decodeBlock(n)
+
+	case "IfExpression":
+		out := &IfExpression{Token: tok}
+		if out.Condition, err = decodeExpression(n.Condition); err != nil {
+			return nil, err
+		}
+		if n.Consequence != nil {
+			if out.Consequence, err = decodeBlock(n.Consequence); err != nil {
+				return nil, err
+			}
+		}
+		if n.Alternative != nil {
+			if out.Alternative, err = decodeBlock(n.Alternative); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case "Parameter":
+		return decodeParameter(n)
+
+	case "FunctionLiteral":
+		out := &FunctionLiteral{Token: tok, Parameters: []*Parameter{}}
+		for _, p := range n.Parameters {
+			param, err := decodeParameter(p)
+			if err != nil {
+				return nil, err
+			}
+			out.Parameters = append(out.Parameters, param)
+		}
+		if n.ReturnType != nil {
+			if out.ReturnType, err = decodeTypeName(n.ReturnType); err != nil {
+				return nil, err
+			}
+		}
+		if n.Body != nil {
+			if out.Body, err = decodeBlock(n.Body); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case "CallExpression":
+		out := &CallExpression{Token: tok, Arguments: []Expression{}}
+		if out.Function, err = decodeExpression(n.Function); err != nil {
+			return nil, err
+		}
+		for _, a := range n.Arguments {
+			if a == nil {
+				return nil, fmt.Errorf("argument must not be null")
+			}
+			arg, err := decodeExpression(a)
+			if err != nil {
+				return nil, err
+			}
+			out.Arguments = append(out.Arguments, arg)
+		}
+		return out, nil
+
+	case "MemberExpression":
+		out := &MemberExpression{Token: tok}
+		if out.Object, err = decodeExpression(n.Object); err != nil {
+			return nil, err
+		}
+		if n.Member != nil {
+			if out.Member, err = decodeIdentifier("MemberExpression member", n.Member); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case "Identifier":
+		out := &Identifier{Token: tok}
+		return out, decodeValue(n, &out.Value)
+
+	case "StringLiteral":
+		out := &StringLiteral{Token: tok}
+		return out, decodeValue(n, &out.Value)
+
+	case "IntegerLiteral":
+		out := &IntegerLiteral{Token: tok}
+		return out, decodeValue(n, &out.Value)
+
+	case "Boolean":
+		out := &Boolean{Token: tok}
+		return out, decodeValue(n, &out.Value)
+
+	case "TypeName":
+		out := &TypeName{Token: tok}
+		return out, decodeValue(n, &out.Value)
+
+	default:
+		return nil, fmt.Errorf("ast.UnmarshalJSON: unknown node kind %q", n.Kind)
+	}
+}
+
+// Decode a child that must be a statement
+func decodeStatement(n *jsonNode) (Statement, error) {
+	if n == nil {
+		return nil, fmt.Errorf("statement must not be null")
+	}
+	node, err := decodeNode(n)
+	if err != nil {
+		return nil, err
+	}
+	stmt, ok := node.(Statement)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a statement", n.Kind)
+	}
+	return stmt, nil
+}
+
+// Decode an optional child that must be an expression, returning nil if the child is absent
+func decodeExpression(n *jsonNode) (Expression, error) {
+	if n == nil {
+		return nil, nil
+	}
+	node, err := decodeNode(n)
+	if err != nil {
+		return nil, err
+	}
+	exp, ok := node.(Expression)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an expression", n.Kind)
+	}
+	return exp, nil
+}
+
+// Decode a child that must be a block statement
+func decodeBlock(n *jsonNode) (*BlockStatement, error) {
+	if n.Kind != "BlockStatement" {
+		return nil, fmt.Errorf("expected BlockStatement, got %s", n.Kind)
+	}
+	out := &BlockStatement{Token: decodeToken(n.Token), Rbrace: decodeToken(n.Rbrace), Statements: []Statement{}}
+	for _, s := range n.Statements {
+		stmt, err := decodeStatement(s)
+		if err != nil {
+			return nil, err
+		}
+		out.Statements = append(out.Statements, stmt)
+	}
+	return out, nil
+}
+
+// Decode a child that must be an identifier, described by what in errors
+func decodeIdentifier(what string, n *jsonNode) (*Identifier, error) {
+	node, err := decodeNode(n)
+	if err != nil {
+		return nil, err
+	}
+	ident, ok := node.(*Identifier)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an Identifier, got %s", what, n.Kind)
+	}
+	return ident, nil
+}
+
+// Decode a child that must be a function parameter
+func decodeParameter(n *jsonNode) (*Parameter, error) {
+	if n == nil || n.Kind != "Parameter" {
+		return nil, fmt.Errorf("function parameters must be Parameters")
+	}
+	if n.Name == nil {
+		return nil, fmt.Errorf("Parameter is missing its name")
+	}
+	ident, err := decodeIdentifier("Parameter name", n.Name)
+	if err != nil {
+		return nil, err
+	}
+	out := &Parameter{Name: ident}
+	if n.Type != nil {
+		if out.Type, err = decodeTypeName(n.Type); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Decode a child that must be a type name
+func decodeTypeName(n *jsonNode) (*TypeName, error) {
+	node, err := decodeNode(n)
+	if err != nil {
+		return nil, err
+	}
+	typeName, ok := node.(*TypeName)
+	if !ok {
+		return nil, fmt.Errorf("type annotation must be a TypeName, got %s", n.Kind)
+	}
+	return typeName, nil
+}
+
+// Decode the plain value of a leaf node into target
+func decodeValue(n *jsonNode, target interface{}) error {
+	if len(n.Value) == 0 {
+		return fmt.Errorf("%s is missing its value", n.Kind)
+	}
+	return json.Unmarshal(n.Value, target)
+}
+
+func decodeToken(t *jsonToken) token.Token {
+	if t == nil {
+		return token.Token{}
+	}
+	return token.Token{Type: t.Type, Literal: t.Literal, Line: t.Line, Column: t.Column}
+}
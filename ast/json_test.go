@@ -0,0 +1,112 @@
+package ast_test
+
+import (
+	"bolt/ast"
+	"bolt/lexer"
+	"bolt/parser"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []string{
+		"",
+		"let x = 5;",
+		"return;",
+		"foobar;",
+		"-a * b",
+		"!-a",
+		"a + b * c + d / e - f",
+		"3 + 4; -5 * 5",
+		"3 + 4 * 5 == 3 * 1 + 4 * 5",
+		"!(true == false)",
+		"(5 + 5) * 2 * (5 + 5)",
+		"// comment\nlet y = x; // trailing",
+		"let z: int = 1;",
+		"let f = fn(x: int, y) { if (x < y) { x } else { f(y, x) } };",
+		"let g = fn() -> bool { if (true) { return false; } }; g()",
+		`import "lib/math"; import "./util.bolt" as u;`,
+		`export let s: string = "a\tb"; m.f(s).g`,
+	}
+
+	for _, input := range tests {
+		p := parser.New(lexer.New(input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors for %q: %v", input, p.Errors())
+		}
+
+		data, err := ast.MarshalJSON(program)
+		if err != nil {
+			t.Fatalf("MarshalJSON(%q) returned error: %s", input, err)
+		}
+
+		decoded, err := ast.UnmarshalJSON(data)
+		if err != nil {
+			t.Fatalf("UnmarshalJSON(%q) returned error: %s", input, err)
+		}
+
+		if decoded.String() != program.String() {
+			t.Errorf("round trip changed program. expected=%q, got=%q", program.String(), decoded.String())
+		}
+		if !reflect.DeepEqual(decoded, program) {
+			t.Errorf("round trip changed tree for %q.\nexpected=%#v\ngot=%#v", input, program, decoded)
+		}
+	}
+}
+
+func TestJSONEncoding(t *testing.T) {
+	p := parser.New(lexer.New("let x = -a;"))
+	program := p.ParseProgram()
+
+	data, err := json.Marshal(program)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %s", err)
+	}
+
+	expected := `{"kind":"Program","statements":[` +
+		`{"kind":"LetStatement","token":{"type":"LET","literal":"let","line":1,"column":1},` +
+		`"name":{"kind":"Identifier","token":{"type":"IDENT","literal":"x","line":1,"column":5},"value":"x"},` +
+		`"expression":{"kind":"PrefixExpression","token":{"type":"-","literal":"-","line":1,"column":9},"operator":"-",` +
+		`"right":{"kind":"Identifier","token":{"type":"IDENT","literal":"a","line":1,"column":10},"value":"a"}}}]}`
+
+	if string(data) != expected {
+		t.Errorf("wrong JSON.\nexpected=%s\ngot=%s", expected, data)
+	}
+
+	var decoded ast.Program
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %s", err)
+	}
+	if decoded.String() != "let x = (-a);" {
+		t.Errorf("decoded.String() wrong. got=%q", decoded.String())
+	}
+}
+
+func TestJSONDecodeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"kind":"Unknown"}`, `unknown node kind "Unknown"`},
+		{`{"kind":"Program","statements":[{"kind":"Identifier","value":"a"}]}`, "Identifier is not a statement"},
+		{`{"kind":"ExpressionStatement","expression":{"kind":"Program"}}`, "Program is not an expression"},
+		{`{"kind":"IntegerLiteral"}`, "IntegerLiteral is missing its value"},
+		{`{"kind":"LetStatement","name":{"kind":"Boolean","value":true}}`, "LetStatement name must be an Identifier"},
+		{`{"kind":"ImportStatement","path":{"kind":"IntegerLiteral","value":1}}`, "ImportStatement path must be a StringLiteral"},
+		{`{"kind":"MemberExpression","member":{"kind":"IntegerLiteral","value":1}}`, "MemberExpression member must be an Identifier"},
+	}
+
+	for _, tt := range tests {
+		_, err := ast.UnmarshalJSON([]byte(tt.input))
+		if err == nil {
+			t.Errorf("expected error for %s", tt.input)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("wrong error for %s. expected=%q, got=%q", tt.input, tt.expected, err)
+		}
+	}
+}
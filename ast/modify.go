@@ -0,0 +1,122 @@
+package ast
+
+import "fmt"
+
+// ModifierFunc is applied to each node by Modify, returning the node to put in its place
+type ModifierFunc func(Node) Node
+
+// Modify rebuilds an AST bottom-up, replacing nodes with the result of the modifier
+//   - Modify each of the non-nil children of node first, storing the results back on node
+//   - Apply the modifier to node itself and return the result
+//
+// Children whose replacement is not of the kind the parent expects (e.g. a Statement where an
+// Expression belongs) are left untouched. Modify panics if it encounters a node type it does not
+// know how to rebuild.
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch n := node.(type) {
+	case *Program:
+		for i, s := range n.Statements {
+			if modified, ok := Modify(s, modifier).(Statement); ok {
+				n.Statements[i] = modified
+			}
+		}
+
+	case *LetStatement:
+		if n.Value != nil {
+			if modified, ok := Modify(n.Value, modifier).(Expression); ok {
+				n.Value = modified
+			}
+		}
+
+	case *ReturnStatement:
+		if n.ReturnValue != nil {
+			if modified, ok := Modify(n.ReturnValue, modifier).(Expression); ok {
+				n.ReturnValue = modified
+			}
+		}
+
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			if modified, ok := Modify(n.Expression, modifier).(Expression); ok {
+				n.Expression = modified
+			}
+		}
+
+	case *PrefixExpression:
+		if n.Right != nil {
+			if modified, ok := Modify(n.Right, modifier).(Expression); ok {
+				n.Right = modified
+			}
+		}
+
+	case *InfixExpression:
+		if n.Left != nil {
+			if modified, ok := Modify(n.Left, modifier).(Expression); ok {
+				n.Left = modified
+			}
+		}
+		if n.Right != nil {
+			if modified, ok := Modify(n.Right, modifier).(Expression); ok {
+				n.Right = modified
+			}
+		}
+
+	case *BlockStatement:
+		for i, s := range n.Statements {
+			if modified, ok := Modify(s, modifier).(Statement); ok {
+				n.Statements[i] = modified
+			}
+		}
+
+	case *IfExpression:
+		if n.Condition != nil {
+			if modified, ok := Modify(n.Condition, modifier).(Expression); ok {
+				n.Condition = modified
+			}
+		}
+		if n.Consequence != nil {
+			if modified, ok := Modify(n.Consequence, modifier).(*BlockStatement); ok {
+				n.Consequence = modified
+			}
+		}
+		if n.Alternative != nil {
+			if modified, ok := Modify(n.Alternative, modifier).(*BlockStatement); ok {
+				n.Alternative = modified
+			}
+		}
+
+	case *FunctionLiteral:
+		if n.Body != nil {
+			if modified, ok := Modify(n.Body, modifier).(*BlockStatement); ok {
+				n.Body = modified
+			}
+		}
+
+	case *CallExpression:
+		if n.Function != nil {
+			if modified, ok := Modify(n.Function, modifier).(Expression); ok {
+				n.Function = modified
+			}
+		}
+		for i, a := range n.Arguments {
+			if modified, ok := Modify(a, modifier).(Expression); ok {
+				n.Arguments[i] = modified
+			}
+		}
+
+	case *MemberExpression:
+		if n.Object != nil {
+			if modified, ok := Modify(n.Object, modifier).(Expression); ok {
+				n.Object = modified
+			}
+		}
+
+	case *ImportStatement, *Parameter, *Identifier, *IntegerLiteral, *StringLiteral, *Boolean, *TypeName:
+		// no statement or expression children to rebuild
+
+	default:
+		panic(fmt.Sprintf("ast.Modify: unexpected node type %T", n))
+	}
+
+	return modifier(node)
+}
@@ -0,0 +1,153 @@
+package ast
+
+import (
+	"bolt/token"
+	"reflect"
+	"testing"
+)
+
+func TestModify(t *testing.T) {
+	one := func() Expression { return &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "1"}, Value: 1} }
+	two := func() Expression { return &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "2"}, Value: 2} }
+
+	turnOneIntoTwo := func(node Node) Node {
+		integer, ok := node.(*IntegerLiteral)
+		if !ok {
+			return node
+		}
+		if integer.Value != 1 {
+			return node
+		}
+		return two()
+	}
+
+	tests := []struct {
+		input    Node
+		expected Node
+	}{
+		{
+			one(),
+			two(),
+		},
+		{
+			&Program{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			&Program{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+		},
+		{
+			&InfixExpression{Left: one(), Operator: "+", Right: two()},
+			&InfixExpression{Left: two(), Operator: "+", Right: two()},
+		},
+		{
+			&InfixExpression{Left: two(), Operator: "+", Right: one()},
+			&InfixExpression{Left: two(), Operator: "+", Right: two()},
+		},
+		{
+			&PrefixExpression{Operator: "-", Right: one()},
+			&PrefixExpression{Operator: "-", Right: two()},
+		},
+		{
+			&ReturnStatement{ReturnValue: one()},
+			&ReturnStatement{ReturnValue: two()},
+		},
+		{
+			&LetStatement{Name: &Identifier{Value: "x"}, Value: one()},
+			&LetStatement{Name: &Identifier{Value: "x"}, Value: two()},
+		},
+		{
+			&LetStatement{Name: &Identifier{Value: "x"}},
+			&LetStatement{Name: &Identifier{Value: "x"}},
+		},
+		{
+			&BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			&BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+		},
+		{
+			&IfExpression{
+				Condition:   one(),
+				Consequence: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+				Alternative: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			},
+			&IfExpression{
+				Condition:   two(),
+				Consequence: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+				Alternative: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+			},
+		},
+		{
+			&FunctionLiteral{Body: &BlockStatement{Statements: []Statement{&ReturnStatement{ReturnValue: one()}}}},
+			&FunctionLiteral{Body: &BlockStatement{Statements: []Statement{&ReturnStatement{ReturnValue: two()}}}},
+		},
+		{
+			&CallExpression{Function: one(), Arguments: []Expression{one(), two(), one()}},
+			&CallExpression{Function: two(), Arguments: []Expression{two(), two(), two()}},
+		},
+		{
+			&MemberExpression{Object: one(), Member: &Identifier{Value: "f"}},
+			&MemberExpression{Object: two(), Member: &Identifier{Value: "f"}},
+		},
+	}
+
+	for _, tt := range tests {
+		modified := Modify(tt.input, turnOneIntoTwo)
+
+		if !reflect.DeepEqual(modified, tt.expected) {
+			t.Errorf("not equal. got=%#v, want=%#v", modified, tt.expected)
+		}
+	}
+}
+
+func TestModifyIsBottomUp(t *testing.T) {
+	// Fold constant additions: children must already be folded when the parent is visited
+	fold := func(node Node) Node {
+		infix, ok := node.(*InfixExpression)
+		if !ok || infix.Operator != "+" {
+			return node
+		}
+		left, lok := infix.Left.(*IntegerLiteral)
+		right, rok := infix.Right.(*IntegerLiteral)
+		if !lok || !rok {
+			return node
+		}
+		return &IntegerLiteral{Value: left.Value + right.Value}
+	}
+
+	integer := func(v int64) Expression { return &IntegerLiteral{Value: v} }
+	input := &ExpressionStatement{
+		Expression: &InfixExpression{
+			Left:     &InfixExpression{Left: integer(1), Operator: "+", Right: integer(2)},
+			Operator: "+",
+			Right:    &InfixExpression{Left: integer(3), Operator: "+", Right: integer(4)},
+		},
+	}
+
+	modified := Modify(input, fold).(*ExpressionStatement)
+
+	result, ok := modified.Expression.(*IntegerLiteral)
+	if !ok {
+		t.Fatalf("expression not folded to *IntegerLiteral. got=%T", modified.Expression)
+	}
+	if result.Value != 10 {
+		t.Errorf("result.Value not %d. got=%d", 10, result.Value)
+	}
+}
+
+// Ensure that every type implementing Node in this package is known to Modify.
+// Adding a new node type without teaching Modify (and sampleNodes) about it fails this test.
+func TestModifyCoversAllNodeTypes(t *testing.T) {
+	samples := sampleNodes()
+	for _, typeName := range nodeTypeNames(t) {
+		node, ok := samples[typeName]
+		if !ok {
+			t.Errorf("node type %s has no sample; add it to sampleNodes and ast.Modify", typeName)
+			continue
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("Modify panicked on %s: %v", typeName, r)
+				}
+			}()
+			Modify(node, func(n Node) Node { return n })
+		}()
+	}
+}
@@ -0,0 +1,148 @@
+package ast
+
+import "fmt"
+
+// Visitor is the interface used by Walk to visit each node in the AST.
+//   - Visit is called for every node encountered by Walk
+//   - If the returned visitor w is not nil, Walk visits each of the children of node with w,
+//     followed by a call of w.Visit(nil)
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order
+//   - Call v.Visit(node), stopping if the returned visitor is nil
+//   - Walk each of the non-nil children of node with the returned visitor
+//   - Call w.Visit(nil) once all children have been walked
+//
+// Walk panics if it encounters a node type it does not know how to traverse.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, s := range n.Statements {
+			Walk(v, s)
+		}
+
+	case *LetStatement:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+
+	case *ImportStatement:
+		if n.Path != nil {
+			Walk(v, n.Path)
+		}
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+
+	case *ReturnStatement:
+		if n.ReturnValue != nil {
+			Walk(v, n.ReturnValue)
+		}
+
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			Walk(v, n.Expression)
+		}
+
+	case *PrefixExpression:
+		if n.Right != nil {
+			Walk(v, n.Right)
+		}
+
+	case *InfixExpression:
+		if n.Left != nil {
+			Walk(v, n.Left)
+		}
+		if n.Right != nil {
+			Walk(v, n.Right)
+		}
+
+	case *BlockStatement:
+		for _, s := range n.Statements {
+			Walk(v, s)
+		}
+
+	case *IfExpression:
+		if n.Condition != nil {
+			Walk(v, n.Condition)
+		}
+		if n.Consequence != nil {
+			Walk(v, n.Consequence)
+		}
+		if n.Alternative != nil {
+			Walk(v, n.Alternative)
+		}
+
+	case *Parameter:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+
+	case *FunctionLiteral:
+		for _, p := range n.Parameters {
+			Walk(v, p)
+		}
+		if n.ReturnType != nil {
+			Walk(v, n.ReturnType)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
+	case *CallExpression:
+		if n.Function != nil {
+			Walk(v, n.Function)
+		}
+		for _, a := range n.Arguments {
+			Walk(v, a)
+		}
+
+	case *MemberExpression:
+		if n.Object != nil {
+			Walk(v, n.Object)
+		}
+		if n.Member != nil {
+			Walk(v, n.Member)
+		}
+
+	case *Identifier, *IntegerLiteral, *StringLiteral, *Boolean, *TypeName:
+		// leaf nodes, nothing to walk
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+// inspector adapts a plain function to the Visitor interface
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order
+//   - Call f(node), and if it returns true, inspect each of the non-nil children of node
+//   - Call f(nil) once all children of a node have been inspected
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,204 @@
+package ast
+
+import (
+	"bolt/token"
+	"fmt"
+	goast "go/ast"
+	goparser "go/parser"
+	gotoken "go/token"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// One sample of every node type in the package, keyed by type name
+func sampleNodes() map[string]Node {
+	ident := func(name string) *Identifier {
+		return &Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name}
+	}
+	integer := &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "5"}, Value: 5}
+	boolean := &Boolean{Token: token.Token{Type: token.TRUE, Literal: "true"}, Value: true}
+	prefix := &PrefixExpression{Token: token.Token{Type: token.MINUS, Literal: "-"}, Operator: "-", Right: integer}
+	typeName := &TypeName{Token: token.Token{Type: token.IDENT, Literal: "int"}, Value: "int"}
+	infix := &InfixExpression{Token: token.Token{Type: token.PLUS, Literal: "+"}, Left: ident("a"), Operator: "+", Right: integer}
+	block := &BlockStatement{Token: token.Token{Type: token.LBRACE, Literal: "{"}, Statements: []Statement{&ExpressionStatement{Expression: ident("a")}}}
+	param := &Parameter{Name: ident("a"), Type: typeName}
+	str := &StringLiteral{Token: token.Token{Type: token.STRING, Literal: `"lib"`}, Value: "lib"}
+
+	return map[string]Node{
+		"Program":             &Program{Statements: []Statement{&ExpressionStatement{Expression: ident("a")}}},
+		"LetStatement":        &LetStatement{Token: token.Token{Type: token.LET, Literal: "let"}, Name: ident("x"), Type: typeName, Value: integer},
+		"ReturnStatement":     &ReturnStatement{Token: token.Token{Type: token.RETURN, Literal: "return"}, ReturnValue: boolean},
+		"ExpressionStatement": &ExpressionStatement{Expression: infix},
+		"Identifier":          ident("a"),
+		"IntegerLiteral":      integer,
+		"Boolean":             boolean,
+		"PrefixExpression":    prefix,
+		"InfixExpression":     infix,
+		"TypeName":            typeName,
+		"BlockStatement":      block,
+		"IfExpression":        &IfExpression{Token: token.Token{Type: token.IF, Literal: "if"}, Condition: boolean, Consequence: block, Alternative: block},
+		"Parameter":           param,
+		"FunctionLiteral":     &FunctionLiteral{Token: token.Token{Type: token.FUNCTION, Literal: "fn"}, Parameters: []*Parameter{param}, ReturnType: typeName, Body: block},
+		"CallExpression":      &CallExpression{Token: token.Token{Type: token.LPAREN, Literal: "("}, Function: ident("f"), Arguments: []Expression{integer, ident("b")}},
+		"StringLiteral":       str,
+		"ImportStatement":     &ImportStatement{Token: token.Token{Type: token.IMPORT, Literal: "import"}, Path: str, Name: ident("lib")},
+		"MemberExpression":    &MemberExpression{Token: token.Token{Type: token.DOT, Literal: "."}, Object: ident("lib"), Member: ident("f")},
+	}
+}
+
+// The names of every type implementing Node in this package, found by parsing its source
+func nodeTypeNames(t *testing.T) []string {
+	t.Helper()
+	fset := gotoken.NewFileSet()
+	pkgs, err := goparser.ParseDir(fset, ".", nil, 0)
+	if err != nil {
+		t.Fatalf("could not parse package source: %s", err)
+	}
+
+	var names []string
+	for _, pkg := range pkgs {
+		for name, file := range pkg.Files {
+			if strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*goast.FuncDecl)
+				if !ok || fn.Recv == nil || fn.Name.Name != "TokenLiteral" {
+					continue
+				}
+				recv := fn.Recv.List[0].Type
+				if star, ok := recv.(*goast.StarExpr); ok {
+					recv = star.X
+				}
+				names = append(names, recv.(*goast.Ident).Name)
+			}
+		}
+	}
+	return names
+}
+
+// Ensure that every type implementing Node in this package is known to Walk.
+// Adding a new node type without teaching Walk (and sampleNodes) about it fails this test.
+func TestWalkCoversAllNodeTypes(t *testing.T) {
+	samples := sampleNodes()
+	for _, typeName := range nodeTypeNames(t) {
+		node, ok := samples[typeName]
+		if !ok {
+			t.Errorf("node type %s has no sample; add it to sampleNodes and ast.Walk", typeName)
+			continue
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("Walk panicked on %s: %v", typeName, r)
+				}
+			}()
+			Inspect(node, func(Node) bool { return true })
+		}()
+	}
+}
+
+func TestInspect(t *testing.T) {
+	node := sampleNodes()["LetStatement"]
+	let := node.(*LetStatement)
+	let.Value = &InfixExpression{
+		Token:    token.Token{Type: token.PLUS, Literal: "+"},
+		Left:     &Identifier{Token: token.Token{Type: token.IDENT, Literal: "a"}, Value: "a"},
+		Operator: "+",
+		Right: &PrefixExpression{
+			Token:    token.Token{Type: token.BANG, Literal: "!"},
+			Operator: "!",
+			Right:    &Boolean{Token: token.Token{Type: token.FALSE, Literal: "false"}, Value: false},
+		},
+	}
+	program := &Program{Statements: []Statement{let}}
+
+	var visited []string
+	Inspect(program, func(n Node) bool {
+		if n == nil {
+			visited = append(visited, "end")
+			return true
+		}
+		visited = append(visited, fmt.Sprintf("%T", n))
+		return true
+	})
+
+	expected := []string{
+		"*ast.Program",
+		"*ast.LetStatement",
+		"*ast.Identifier", "end",
+		"*ast.TypeName", "end",
+		"*ast.InfixExpression",
+		"*ast.Identifier", "end",
+		"*ast.PrefixExpression",
+		"*ast.Boolean", "end",
+		"end",
+		"end",
+		"end",
+		"end",
+	}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("wrong visit order.\nexpected=%v\ngot=%v", expected, visited)
+	}
+}
+
+func TestInspectPrunes(t *testing.T) {
+	program := &Program{Statements: []Statement{sampleNodes()["ExpressionStatement"].(Statement)}}
+
+	count := 0
+	Inspect(program, func(n Node) bool {
+		if n == nil {
+			return false
+		}
+		count++
+		_, isInfix := n.(*InfixExpression)
+		return !isInfix
+	})
+
+	if count != 3 {
+		t.Errorf("expected 3 nodes to be inspected, got=%d", count)
+	}
+}
+
+// Visitor counting identifiers, used to exercise Walk with a custom Visitor
+type identCounter struct {
+	count int
+}
+
+func (c *identCounter) Visit(node Node) Visitor {
+	if _, ok := node.(*Identifier); ok {
+		c.count++
+	}
+	return c
+}
+
+func TestWalk(t *testing.T) {
+	c := &identCounter{}
+	Walk(c, sampleNodes()["LetStatement"])
+
+	if c.count != 1 {
+		t.Errorf("expected 1 identifier, got=%d", c.count)
+	}
+
+	c = &identCounter{}
+	Walk(c, sampleNodes()["ExpressionStatement"])
+
+	if c.count != 1 {
+		t.Errorf("expected 1 identifier, got=%d", c.count)
+	}
+}
+
+func TestWalkUnknownNodePanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected Walk to panic on an unknown node type")
+		}
+	}()
+	Inspect(&unknownNode{}, func(Node) bool { return true })
+}
+
+type unknownNode struct{}
+
+func (u *unknownNode) TokenLiteral() string { return "" }
+func (u *unknownNode) String() string       { return "" }
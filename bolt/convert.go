@@ -0,0 +1,132 @@
+package bolt
+
+import (
+	"bolt/evaluator"
+	"bolt/object"
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// Func is a function that can be passed between Go and Bolt
+//   - Set binds a Func as a builtin, whose arguments are converted to Go as by Get; a non-nil error fails the call
+//   - Get converts Bolt functions to a Func, which returns a *RuntimeError if the call fails
+//   - A builtin can take a Func parameter, to call back into Bolt
+type Func func(args ...interface{}) (interface{}, error)
+
+var funcType = reflect.TypeOf(Func(nil))
+
+// Convert a Go value to a Bolt object
+//   - Integers of any size convert to integers; unsigned integers must fit in an int64
+//   - Booleans convert to booleans, strings to strings, and nil to null
+//   - Slices and arrays convert to arrays, and maps with string keys to hashes, converting their elements
+//   - Pointers convert the value they point to, or to null if they are nil
+//   - Functions convert to builtins, see Runtime.RegisterFunc
+//   - Any other value returns an error
+func toObject(v interface{}) (object.Object, error) {
+	if v == nil {
+		return evaluator.NULL, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: rv.Int()}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows a Bolt integer", rv.Uint())
+		}
+		return &object.Integer{Value: int64(rv.Uint())}, nil
+
+	case reflect.Bool:
+		if rv.Bool() {
+			return evaluator.TRUE, nil
+		}
+		return evaluator.FALSE, nil
+
+	case reflect.String:
+		return &object.String{Value: rv.String()}, nil
+
+	case reflect.Slice, reflect.Array:
+		elements := make([]object.Object, rv.Len())
+		for i := range elements {
+			el, err := toObject(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = el
+		}
+		return &object.Array{Elements: elements}, nil
+
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("cannot convert %T to a Bolt hash: keys must be strings", v)
+		}
+		pairs := make(map[string]object.Object, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			value, err := toObject(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			pairs[iter.Key().String()] = value
+		}
+		return &object.Hash{Pairs: pairs}, nil
+
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return toObject(rv.Elem().Interface())
+
+	case reflect.Func:
+		if rv.IsNil() {
+			return evaluator.NULL, nil
+		}
+		b, err := builtin(rv)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %T to a Bolt value: %w", v, err)
+		}
+		return b, nil
+	}
+
+	return nil, fmt.Errorf("cannot convert %T to a Bolt value", v)
+}
+
+// Convert a Bolt object to a Go value, see Runtime.Get
+func fromObject(obj object.Object) interface{} {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return obj.Value
+
+	case *object.Boolean:
+		return obj.Value
+
+	case *object.String:
+		return obj.Value
+
+	case *object.Array:
+		elements := make([]interface{}, len(obj.Elements))
+		for i, el := range obj.Elements {
+			elements[i] = fromObject(el)
+		}
+		return elements
+
+	case *object.Hash:
+		pairs := make(map[string]interface{}, len(obj.Pairs))
+		for key, value := range obj.Pairs {
+			pairs[key] = fromObject(value)
+		}
+		return pairs
+
+	case *object.Function, *object.Builtin:
+		return Func(func(args ...interface{}) (interface{}, error) {
+			return call(context.Background(), obj, args)
+		})
+
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,226 @@
+package bolt
+
+import (
+	"bolt/evaluator"
+	"bolt/object"
+	"fmt"
+	"reflect"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Bind a Go function to a name in the top-level environment of the runtime, as a builtin that
+// Bolt code can call
+//   - fn may take any number of parameters of the types accepted by a builtin, see toValue,
+//     and be variadic
+//   - fn may return nothing, a value, an error, or a value and an error; a non-nil error fails the call
+//     with its message, and a value is converted as by Set
+//   - A call with the wrong number of arguments, or an argument that cannot be converted to its
+//     parameter, fails without calling fn
+//   - A panic in fn is recovered, and fails the call instead of crashing the host
+//   - Return an error if fn is not a function, or its signature is not supported
+func (rt *Runtime) RegisterFunc(name string, fn interface{}) error {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func || rv.IsNil() {
+		return fmt.Errorf("cannot register %s: %T is not a function", name, fn)
+	}
+	b, err := builtin(rv)
+	if err != nil {
+		return fmt.Errorf("cannot register %s: %w", name, err)
+	}
+	b.Name = name
+	rt.env.Set(name, b)
+	return nil
+}
+
+// Wrap a Go function as a builtin, converting its arguments from Bolt and its result back to Bolt
+//   - Return an error if its signature is not supported, see Runtime.RegisterFunc
+func builtin(fn reflect.Value) (*object.Builtin, error) {
+	typ := fn.Type()
+	for i := 0; i < typ.NumIn(); i++ {
+		param := typ.In(i)
+		if typ.IsVariadic() && i == typ.NumIn()-1 {
+			param = param.Elem()
+		}
+		if !supported(param) {
+			return nil, fmt.Errorf("unsupported type %s of parameter %d", typ.In(i), i+1)
+		}
+	}
+
+	switch {
+	case typ.NumOut() > 2:
+		return nil, fmt.Errorf("too many results: %s", typ)
+	case typ.NumOut() == 2 && typ.Out(1) != errorType:
+		return nil, fmt.Errorf("the second result must be an error: %s", typ)
+	}
+
+	b := &object.Builtin{Name: "func"}
+	b.Fn = func(args ...object.Object) (result object.Object) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = &object.Error{Message: fmt.Sprintf("panic in %s: %v", b.Name, r)}
+			}
+		}()
+
+		in, err := arguments(b.Name, typ, args)
+		if err != nil {
+			return &object.Error{Message: err.Error()}
+		}
+		return results(fn.Call(in))
+	}
+	return b, nil
+}
+
+// Convert the arguments of a call to a builtin to the parameters of its function type
+func arguments(name string, typ reflect.Type, args []object.Object) ([]reflect.Value, error) {
+	params := typ.NumIn()
+	switch {
+	case typ.IsVariadic() && len(args) < params-1:
+		return nil, fmt.Errorf("wrong number of arguments to %s: want=%d or more, got=%d", name, params-1, len(args))
+	case !typ.IsVariadic() && len(args) != params:
+		return nil, fmt.Errorf("wrong number of arguments to %s: want=%d, got=%d", name, params, len(args))
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var param reflect.Type
+		if typ.IsVariadic() && i >= params-1 {
+			param = typ.In(params - 1).Elem()
+		} else {
+			param = typ.In(i)
+		}
+
+		value, err := toValue(arg, param)
+		if err != nil {
+			return nil, fmt.Errorf("%s in argument %d of %s", err, i+1, name)
+		}
+		in[i] = value
+	}
+	return in, nil
+}
+
+// Convert the results of a function to the result of a call
+//   - A non-nil error fails the call with its message
+//   - A function without a value to return returns null
+func results(out []reflect.Value) object.Object {
+	if len(out) != 0 {
+		if last := out[len(out)-1]; last.Type() == errorType {
+			if !last.IsNil() {
+				err := last.Interface().(error)
+				return &object.Error{Message: err.Error(), Err: err}
+			}
+			out = out[:len(out)-1]
+		}
+	}
+	if len(out) == 0 {
+		return evaluator.NULL
+	}
+
+	obj, err := toObject(out[0].Interface())
+	if err != nil {
+		return &object.Error{Message: err.Error()}
+	}
+	return obj
+}
+
+// Determine whether a builtin can take a parameter of a type, see toValue
+func supported(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Bool, reflect.String:
+		return true
+	case reflect.Interface:
+		return typ.NumMethod() == 0
+	case reflect.Slice:
+		return supported(typ.Elem())
+	case reflect.Map:
+		return typ.Key().Kind() == reflect.String && supported(typ.Elem())
+	case reflect.Func:
+		return typ == funcType
+	default:
+		return false
+	}
+}
+
+// Convert a Bolt object to a Go value of a type
+//   - Integers convert to any integer type they fit in, booleans to bool types and strings to string types
+//   - Arrays convert to slices, and hashes to maps with string keys, converting their elements
+//   - Functions convert to a Func
+//   - Any object converts to an empty interface as by Runtime.Get
+//   - Return an error if the object is not of a kind the type accepts, or does not fit in it
+func toValue(obj object.Object, typ reflect.Type) (reflect.Value, error) {
+	value := reflect.New(typ).Elem()
+
+	switch typ.Kind() {
+	case reflect.Interface:
+		if v := fromObject(obj); v != nil {
+			value.Set(reflect.ValueOf(v))
+		}
+		return value, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := obj.(*object.Integer); ok {
+			if value.OverflowInt(i.Value) {
+				return value, fmt.Errorf("%d overflows %s", i.Value, typ)
+			}
+			value.SetInt(i.Value)
+			return value, nil
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := obj.(*object.Integer); ok {
+			if i.Value < 0 || value.OverflowUint(uint64(i.Value)) {
+				return value, fmt.Errorf("%d overflows %s", i.Value, typ)
+			}
+			value.SetUint(uint64(i.Value))
+			return value, nil
+		}
+
+	case reflect.Bool:
+		if b, ok := obj.(*object.Boolean); ok {
+			value.SetBool(b.Value)
+			return value, nil
+		}
+
+	case reflect.String:
+		if s, ok := obj.(*object.String); ok {
+			value.SetString(s.Value)
+			return value, nil
+		}
+
+	case reflect.Slice:
+		if a, ok := obj.(*object.Array); ok {
+			value.Set(reflect.MakeSlice(typ, len(a.Elements), len(a.Elements)))
+			for i, el := range a.Elements {
+				v, err := toValue(el, typ.Elem())
+				if err != nil {
+					return value, err
+				}
+				value.Index(i).Set(v)
+			}
+			return value, nil
+		}
+
+	case reflect.Map:
+		if h, ok := obj.(*object.Hash); ok {
+			value.Set(reflect.MakeMapWithSize(typ, len(h.Pairs)))
+			for key, el := range h.Pairs {
+				v, err := toValue(el, typ.Elem())
+				if err != nil {
+					return value, err
+				}
+				value.SetMapIndex(reflect.ValueOf(key).Convert(typ.Key()), v)
+			}
+			return value, nil
+		}
+
+	case reflect.Func:
+		if f, ok := fromObject(obj).(Func); ok {
+			value.Set(reflect.ValueOf(f))
+			return value, nil
+		}
+	}
+
+	return value, fmt.Errorf("cannot use %s as %s", obj.Type(), typ)
+}
@@ -0,0 +1,168 @@
+package bolt
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegisterFunc(t *testing.T) {
+	rt := NewRuntime()
+	funcs := map[string]interface{}{
+		"add":   func(a, b int) int { return a + b },
+		"small": func(a int8, b uint) string { return fmt.Sprint(a, b) },
+		"not":   func(b bool) bool { return !b },
+		"upper": strings.ToUpper,
+		"sum": func(base int64, rest ...int64) int64 {
+			for _, n := range rest {
+				base += n
+			}
+			return base
+		},
+		"describe": func(v interface{}) string { return fmt.Sprintf("%T", v) },
+		"length":   func(items []string) int { return len(items) },
+		"keys": func(h map[string]bool) []string {
+			keys := []string{}
+			for key := range h {
+				keys = append(keys, key)
+			}
+			return keys
+		},
+		"apply": func(f Func, arg int) (interface{}, error) { return f(arg) },
+		"noop":  func() {},
+		"check": func(n int) error {
+			if n < 0 {
+				return errors.New("negative")
+			}
+			return nil
+		},
+		"div": func(a, b int) (int, error) {
+			if b == 0 {
+				return 0, errors.New("cannot divide by zero")
+			}
+			return a / b, nil
+		},
+		"boom":  func() int { panic("boom") },
+		"index": func(items []int, i int) int { return items[i] },
+	}
+	for name, fn := range funcs {
+		if err := rt.RegisterFunc(name, fn); err != nil {
+			t.Fatalf("unexpected error registering %s: %s", name, err)
+		}
+	}
+	for name, value := range map[string]interface{}{"s": "bolt", "list": []string{"a", "b"}, "set": map[string]bool{"k": true}} {
+		if err := rt.Set(name, value); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"add(1, 2)", int64(3)},
+		{"small(-128, 255)", "-128 255"},
+		{"not(true)", false},
+		{"upper(s)", "BOLT"},
+		{"sum(1)", int64(1)},
+		{"sum(1, 2, 3)", int64(6)},
+		{"describe(1)", "int64"},
+		{"describe(list)", "[]interface {}"},
+		{"length(list)", int64(2)},
+		{"keys(set)", []interface{}{"k"}},
+		{"apply(fn(x) { x * 10 }, 4)", int64(40)},
+		{"noop()", nil},
+		{"check(1)", nil},
+		{"div(7, 2)", int64(3)},
+	}
+
+	for _, tt := range tests {
+		result, err := rt.Eval(tt.input)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("wrong result for %q. expected=%#v, got=%#v", tt.input, tt.expected, result)
+		}
+	}
+}
+
+func TestRegisteredFuncErrors(t *testing.T) {
+	rt := NewRuntime()
+	rt.RegisterFunc("add", func(a, b int) int { return a + b })
+	rt.RegisterFunc("small", func(a int8, b uint) int8 { return a })
+	rt.RegisterFunc("sum", func(base int, rest ...int) int { return base })
+	rt.RegisterFunc("length", func(items []int) int { return len(items) })
+	rt.RegisterFunc("check", func(n int) error { return errors.New("negative") })
+	rt.RegisterFunc("div", func(a, b int) (int, error) { return 0, errors.New("cannot divide by zero") })
+	rt.RegisterFunc("boom", func() int { panic("boom") })
+	rt.RegisterFunc("index", func(items []int, i int) int { return items[i] })
+	rt.RegisterFunc("apply", func(f Func, arg int) (interface{}, error) { return f(arg) })
+	rt.Set("list", []interface{}{1, true})
+	rt.Set("ints", []int{1})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"add(1)", "wrong number of arguments to add: want=2, got=1"},
+		{"add(1, 2, 3)", "wrong number of arguments to add: want=2, got=3"},
+		{"sum()", "wrong number of arguments to sum: want=1 or more, got=0"},
+		{"add(1, true)", "cannot use BOOLEAN as int in argument 2 of add"},
+		{"sum(1, 2, false)", "cannot use BOOLEAN as int in argument 3 of sum"},
+		{"small(128, 0)", "128 overflows int8 in argument 1 of small"},
+		{"small(1, -1)", "-1 overflows uint in argument 2 of small"},
+		{"length(list)", "cannot use BOOLEAN as int in argument 1 of length"},
+		{"length(1)", "cannot use INTEGER as []int in argument 1 of length"},
+		{"check(1)", "negative"},
+		{"div(1, 0)", "cannot divide by zero"},
+		{"boom()", "panic in boom: boom"},
+		{"apply(add, 4)", "wrong number of arguments to add: want=2, got=1"},
+		{"apply(1, 4)", "cannot use INTEGER as bolt.Func in argument 1 of apply"},
+		{"index(ints, 5)", "panic in index: runtime error: index out of range [5] with length 1"},
+	}
+
+	for _, tt := range tests {
+		_, err := rt.Eval(tt.input)
+		var runtimeErr *RuntimeError
+		if !errors.As(err, &runtimeErr) || err.Error() != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+
+	// the runtime is still usable after a panic
+	if result, err := rt.Eval("add(1, 2)"); err != nil || result != int64(3) {
+		t.Errorf("wrong result after a panic. got=%#v (%v)", result, err)
+	}
+}
+
+func TestRegisterFuncSignatures(t *testing.T) {
+	tests := []struct {
+		fn       interface{}
+		expected string
+	}{
+		{42, "cannot register f: int is not a function"},
+		{nil, "cannot register f: <nil> is not a function"},
+		{(func())(nil), "cannot register f: func() is not a function"},
+		{func(float64) {}, "cannot register f: unsupported type float64 of parameter 1"},
+		{func(int, ...chan int) {}, "cannot register f: unsupported type []chan int of parameter 2"},
+		{func(map[int]string) {}, "cannot register f: unsupported type map[int]string of parameter 1"},
+		{func(fmt.Stringer) {}, "cannot register f: unsupported type fmt.Stringer of parameter 1"},
+		{func() (int, int) { return 0, 0 }, "cannot register f: the second result must be an error: func() (int, int)"},
+		{func() (int, int, error) { return 0, 0, nil }, "cannot register f: too many results: func() (int, int, error)"},
+	}
+
+	for _, tt := range tests {
+		rt := NewRuntime()
+		err := rt.RegisterFunc("f", tt.fn)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error for %T. expected=%q, got=%v", tt.fn, tt.expected, err)
+		}
+		if _, ok := rt.Get("f"); ok {
+			t.Errorf("expected %T not to be registered", tt.fn)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package bolt
+
+import (
+	"bolt/evaluator"
+	"bolt/object"
+	"context"
+	"io/fs"
+	"time"
+)
+
+// The call depth a runtime allows by default, well within the Go stack
+const DEFAULT_MAX_DEPTH = evaluator.DEFAULT_MAX_DEPTH
+
+// Options limit the resources that evaluation in a runtime may use, and what it may do outside the runtime,
+// so that hosts can run untrusted code
+//   - MaxSteps: the maximum number of steps, roughly the nodes of the program evaluated, of each run, or 0 for no limit
+//   - MaxDepth: the maximum depth of nested function calls, or 0 for no limit
+//   - Timeout: the maximum duration of each run, or 0 for no limit
+//   - MaxAllocated: the maximum number of bytes each run may allocate in total, or 0 for no limit; allocations
+//     are counted approximately for strings, arrays, hashes, closures and the environments of calls and blocks,
+//     and are not given back when the values become unreachable. This bounds the memory a run holds from above,
+//     but a long run that keeps allocating short-lived values reaches it even though its live memory stays small
+//   - Capabilities: the capabilities granted to the builtins of the runtime, see Capability; none by default
+//   - FS: the filesystem that modules, the files of EvalFile and the files of the builtins are read from,
+//     such as an embed.FS or an fstest.MapFS; if nil, they are read from the operating system. The builtins
+//     can only write to it if it is a WriteFS
+//   - SearchPath: the directories searched for the modules imported by the code of the runtime, see
+//     module.Loader; code given to Eval imports relative paths as if it were a file in the first of them.
+//     Without an FS or a search path, code cannot import modules
+//
+// A run is a call to Eval, Call, or a Func converted from a Bolt function, along with any calls back into
+// Bolt made by builtins during it. Time spent in builtins counts towards the timeout, but is not interrupted.
+type Options struct {
+	MaxSteps     int64
+	MaxDepth     int
+	Timeout      time.Duration
+	MaxAllocated int64
+
+	Capabilities Capability
+	FS           fs.FS
+	SearchPath   []string
+}
+
+// DefaultOptions are the options used by NewRuntime
+var DefaultOptions = Options{MaxDepth: DEFAULT_MAX_DEPTH}
+
+// The errors that stop a run exceeding its options, returned wrapped in a *RuntimeError; use errors.As to detect them.
+// A run stopped by its context returns the error of the context, also wrapped.
+type (
+	StepLimitError       = evaluator.StepLimitError
+	CallDepthError       = evaluator.CallDepthError
+	TimeoutError         = evaluator.TimeoutError
+	AllocationLimitError = evaluator.AllocationLimitError
+)
+
+// Stats describe the resources used by the runs of a runtime, see Options
+//   - Steps: the steps taken by the last run
+//   - Allocated: the bytes allocated in total by the last run, not its live memory
+//   - MostAllocated: the most bytes allocated in total by any one run
+//   - TotalAllocated: the bytes allocated by all runs
+type Stats struct {
+	Steps          int64
+	Allocated      int64
+	MostAllocated  int64
+	TotalAllocated int64
+}
+
+// Return the resources used by the runs of the runtime so far
+func (rt *Runtime) Stats() Stats {
+	limits := rt.env.Limits()
+	return Stats{
+		Steps:          limits.Steps,
+		Allocated:      limits.Allocated,
+		MostAllocated:  limits.MostAllocated,
+		TotalAllocated: limits.TotalAllocated,
+	}
+}
+
+// Evaluate within limits, starting a run with a context unless one is already in progress
+//   - A run in progress, such as one calling a builtin that calls back into Bolt, continues with its own context,
+//     and shares its steps, deadline and allocations with the evaluation
+//   - The allocations of a run are added to the totals of the limits once it finishes
+func run(ctx context.Context, limits *object.Limits, eval func() object.Object) object.Object {
+	if limits == nil || limits.Context != nil {
+		return eval()
+	}
+
+	limits.Context, limits.Steps, limits.Allocated = ctx, 0, 0
+	if limits.Timeout > 0 {
+		limits.Deadline = time.Now().Add(limits.Timeout)
+	}
+	defer func() {
+		limits.Context, limits.Deadline = nil, time.Time{}
+		limits.TotalAllocated += limits.Allocated
+		if limits.Allocated > limits.MostAllocated {
+			limits.MostAllocated = limits.Allocated
+		}
+	}()
+	return eval()
+}
@@ -0,0 +1,194 @@
+package bolt
+
+import (
+	"bolt/evaluator"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const FIB = "let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } };"
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		options  Options
+		input    string
+		expected error
+	}{
+		{Options{MaxSteps: 100}, "1 + 2", nil},
+		{Options{MaxSteps: 100}, FIB + "fib(10)", &StepLimitError{Limit: 100}},
+		{Options{MaxDepth: 10}, FIB + "fib(10)", nil},
+		{Options{MaxDepth: 10}, FIB + "fib(11)", &CallDepthError{Limit: 10}},
+		{DefaultOptions, "let f = fn() { f() }; f()", &CallDepthError{Limit: DEFAULT_MAX_DEPTH}},
+		{Options{Timeout: time.Millisecond}, FIB + "fib(40)", &TimeoutError{Timeout: time.Millisecond}},
+	}
+
+	for _, tt := range tests {
+		_, err := NewRuntimeWithOptions(tt.options).Eval(tt.input)
+		if tt.expected == nil {
+			if err != nil {
+				t.Errorf("unexpected error for %q with %+v: %s", tt.input, tt.options, err)
+			}
+			continue
+		}
+
+		var runtimeErr *RuntimeError
+		if !errors.As(err, &runtimeErr) || err.Error() != tt.expected.Error() {
+			t.Errorf("wrong error for %q with %+v. expected=%q, got=%v", tt.input, tt.options, tt.expected, err)
+			continue
+		}
+		var stepErr *StepLimitError
+		var depthErr *CallDepthError
+		var timeoutErr *TimeoutError
+		switch tt.expected.(type) {
+		case *StepLimitError:
+			if !errors.As(err, &stepErr) || errors.As(err, &depthErr) || errors.As(err, &timeoutErr) {
+				t.Errorf("expected a *StepLimitError for %q. got=%#v", tt.input, runtimeErr.Err)
+			}
+		case *CallDepthError:
+			if !errors.As(err, &depthErr) || errors.As(err, &stepErr) || errors.As(err, &timeoutErr) {
+				t.Errorf("expected a *CallDepthError for %q. got=%#v", tt.input, runtimeErr.Err)
+			}
+		case *TimeoutError:
+			if !errors.As(err, &timeoutErr) || errors.As(err, &stepErr) || errors.As(err, &depthErr) {
+				t.Errorf("expected a *TimeoutError for %q. got=%#v", tt.input, runtimeErr.Err)
+			}
+		}
+	}
+}
+
+func TestLimitsPerRun(t *testing.T) {
+	rt := NewRuntimeWithOptions(Options{MaxSteps: 1000})
+	if _, err := rt.Eval(FIB); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// each run has a budget of its own
+	for i := 0; i < 3; i++ {
+		if result, err := rt.Call("fib", 8); err != nil || result != int64(21) {
+			t.Fatalf("wrong result of run %d. got=%#v (%v)", i, result, err)
+		}
+	}
+
+	// a Func read from the runtime is limited like Call
+	fib, _ := rt.Get("fib")
+	if _, err := fib.(Func)(20); !errors.As(err, new(*StepLimitError)) {
+		t.Errorf("expected the Func to exceed the step limit. got=%v", err)
+	}
+
+	// a builtin calling back into Bolt shares the budget of the run calling it
+	rt.RegisterFunc("twice", func(f Func, n int) (interface{}, error) {
+		if _, err := f(n); err != nil {
+			return nil, err
+		}
+		return f(n)
+	})
+	if _, err := rt.Eval("twice(fib, 6)"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if _, err := rt.Eval("twice(fib, 7)"); !errors.As(err, new(*StepLimitError)) {
+		t.Errorf("expected the callbacks to exceed the step limit together. got=%v", err)
+	}
+}
+
+func TestContext(t *testing.T) {
+	rt := NewRuntime()
+	ctx, cancel := context.WithCancel(context.Background())
+	rt.RegisterFunc("cancel", func() { cancel() })
+	rt.Eval("let one = fn() { 1 };")
+
+	_, err := rt.EvalContext(ctx, "let x = 1; cancel(); x + 1")
+	if !errors.Is(err, context.Canceled) || err.Error() != "context canceled" {
+		t.Errorf("expected the run to be canceled. got=%v", err)
+	}
+	if _, err := rt.CallContext(ctx, "one"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a call with a canceled context to fail. got=%v", err)
+	}
+
+	// a canceled run leaves the runtime usable
+	if result, err := rt.Eval("x + 1"); err != nil || result != int64(2) {
+		t.Errorf("wrong result after a canceled run. got=%#v (%v)", result, err)
+	}
+
+	deadline, stop := context.WithTimeout(context.Background(), time.Millisecond)
+	defer stop()
+	_, err = rt.EvalContext(deadline, FIB+"fib(40)")
+	if !errors.Is(err, context.DeadlineExceeded) || errors.As(err, new(*TimeoutError)) {
+		t.Errorf("expected the deadline of the context to stop the run. got=%v", err)
+	}
+}
+
+func TestAllocationLimit(t *testing.T) {
+	grow := "let grow = fn(s, n) { if (n == 0) { s } else { grow(s + s, n - 1) } };"
+
+	rt := NewRuntimeWithOptions(Options{MaxAllocated: 1 << 20})
+	rt.Set("s", "ab")
+	if result, err := rt.Eval(grow + "grow(s, 10)"); err != nil || len(result.(string)) != 2048 {
+		t.Fatalf("unexpected result: %v", err)
+	}
+
+	_, err := rt.Eval("grow(s, 30)")
+	var allocationErr *AllocationLimitError
+	if !errors.As(err, &allocationErr) || err.Error() != "allocation limit of 1048576 bytes exceeded" {
+		t.Errorf("expected the allocation limit to be exceeded. got=%v", err)
+	}
+
+	// strings returned by builtins count too
+	rt.RegisterFunc("repeat", strings.Repeat)
+	if _, err := rt.Eval("repeat(s, 1000000)"); !errors.As(err, &allocationErr) {
+		t.Errorf("expected the result of a builtin to exceed the allocation limit. got=%v", err)
+	}
+}
+
+func TestAllocationLimitCountsReleasedValues(t *testing.T) {
+	// each call allocates a string that is released when it returns, so live memory stays small
+	rt := NewRuntimeWithOptions(Options{MaxAllocated: 4096})
+	rt.Set("s", "ab")
+	if _, err := rt.Eval("let discard = fn() { let t = s + s; 0 };"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	loop := strings.Repeat("discard();", 20)
+	if _, err := rt.Eval(loop); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	once := rt.Stats().Allocated
+
+	// the allocations of a run add up, so enough calls reach the limit
+	_, err := rt.Eval(strings.Repeat(loop, int(4096/once)+1))
+	if !errors.As(err, new(*AllocationLimitError)) {
+		t.Errorf("expected the allocation limit to be exceeded by a long run. got=%v", err)
+	}
+	// while each run starts counting afresh
+	if _, err := rt.Eval(loop); err != nil {
+		t.Errorf("unexpected error in a new run: %s", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	rt := NewRuntime()
+	if stats := rt.Stats(); stats != (Stats{}) {
+		t.Errorf("expected no usage before the first run. got=%+v", stats)
+	}
+
+	rt.Set("s", "ab")
+	rt.Eval("let f = fn(x) { x + x };")
+	first := rt.Stats()
+	if first.Steps == 0 || first.Allocated != evaluator.FUNCTION_SIZE+evaluator.BINDING_SIZE {
+		t.Errorf("wrong stats of the first run. got=%+v", first)
+	}
+
+	rt.Eval("f(f(s))")
+	second := rt.Stats()
+	if second.Allocated <= first.Allocated || second.MostAllocated != second.Allocated ||
+		second.TotalAllocated != first.Allocated+second.Allocated {
+		t.Errorf("wrong stats of the second run. got=%+v", second)
+	}
+
+	rt.Eval("1")
+	third := rt.Stats()
+	if third.Allocated != 0 || third.MostAllocated != second.MostAllocated || third.TotalAllocated != second.TotalAllocated {
+		t.Errorf("wrong stats of the third run. got=%+v", third)
+	}
+}
@@ -0,0 +1,258 @@
+package bolt
+
+import (
+	"bolt/evaluator"
+	"bolt/lexer"
+	"bolt/module"
+	"bolt/object"
+	"bolt/parser"
+	"bolt/resolver"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Runtime evaluates Bolt code on behalf of a Go host application
+//   - env: the top-level environment, shared by every call to Eval, so that the names bound by one
+//     are visible to the next, and limited by the options of the runtime
+//   - fsys: the filesystem of the options, or nil for that of the operating system
+//   - loader: the loader of the modules imported by the code of the runtime, or nil if it cannot import
+//
+// A Runtime is not safe for concurrent use.
+type Runtime struct {
+	env    *object.Environment
+	fsys   fs.FS
+	loader *module.Loader
+}
+
+// Create, initialize and return a new Runtime limited by DefaultOptions, with only the builtins bound
+func NewRuntime() *Runtime {
+	return NewRuntimeWithOptions(DefaultOptions)
+}
+
+// Create, initialize and return a new Runtime limited by options, with only the builtins bound,
+// which may use the capabilities the options grant
+//   - Each module imported is evaluated once per runtime, with the builtins of the runtime and within its limits
+func NewRuntimeWithOptions(options Options) *Runtime {
+	env := object.NewEnvironment()
+	env.SetLimits(&object.Limits{
+		MaxSteps:     options.MaxSteps,
+		MaxDepth:     options.MaxDepth,
+		Timeout:      options.Timeout,
+		MaxAllocated: options.MaxAllocated,
+	})
+	rt := &Runtime{env: env, fsys: options.FS}
+	(&sandbox{granted: options.Capabilities, fsys: options.FS}).register(rt)
+
+	if options.FS != nil || len(options.SearchPath) != 0 {
+		rt.loader = module.NewFSLoader(options.FS, options.SearchPath...)
+		rt.loader.Environment = rt.moduleEnvironment
+		dir := "."
+		if len(options.SearchPath) != 0 {
+			dir = options.SearchPath[0]
+		}
+		env.SetImporter(rt.loader.Importer(dir))
+	}
+	return rt
+}
+
+// Create the top-level environment of an imported module, binding the builtins of the runtime
+// and limited by its options
+func (rt *Runtime) moduleEnvironment() *object.Environment {
+	env := object.NewEnvironment()
+	env.SetLimits(rt.env.Limits())
+	for _, name := range rt.env.Names() {
+		if obj, _ := rt.env.Get(name); obj.Type() == object.BUILTIN_OBJ {
+			env.Set(name, obj)
+		}
+	}
+	return env
+}
+
+// CompileError is returned when source does not parse, or uses names that are not bound
+//   - File: the name of the file of the source given to EvalFile, or empty for source given to Eval
+//   - Errors: the errors found, each formatted as "line:col: message", and prefixed with "file:" if there is one
+type CompileError struct {
+	File   string
+	Errors []error
+}
+
+func (e *CompileError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+		if e.File != "" {
+			msgs[i] = e.File + ":" + msgs[i]
+		}
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// RuntimeError is returned when evaluation fails, such as on a type mismatch or a failing host function
+//   - Err: the Go error that caused it, such as one returned by a host function or a limit being exceeded, or nil
+type RuntimeError struct {
+	Message string
+	Err     error
+}
+
+func (e *RuntimeError) Error() string {
+	return e.Message
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// Evaluate Bolt source in the top-level environment of the runtime, and return the value of its
+// last statement, or that of a top-level return, converted to Go
+//   - Names bound by let statements stay bound for later calls to Eval, Get and Call
+//   - Source that does not parse, or whose names do not resolve, is not evaluated and returns a *CompileError
+//   - Evaluation that fails, or exceeds the options of the runtime, returns a *RuntimeError
+//   - See Get for the Go types of the values returned
+func (rt *Runtime) Eval(src string) (interface{}, error) {
+	return rt.EvalContext(context.Background(), src)
+}
+
+// Evaluate Bolt source as by Eval, stopping with an error wrapping that of ctx once it is done
+func (rt *Runtime) EvalContext(ctx context.Context, src string) (interface{}, error) {
+	return rt.eval(ctx, "", src)
+}
+
+// Evaluate the Bolt source in a file as by Eval, such as a script bundled into the host with embed.FS
+//   - The file is read from the filesystem of the options, or from the operating system if there is none
+//   - Relative imports in the file are resolved against its directory
+//   - Return the error reading the file if it cannot be read
+func (rt *Runtime) EvalFile(name string) (interface{}, error) {
+	return rt.EvalFileContext(context.Background(), name)
+}
+
+// Evaluate the Bolt source in a file as by EvalFile, stopping with an error wrapping that of ctx once it is done
+func (rt *Runtime) EvalFileContext(ctx context.Context, name string) (interface{}, error) {
+	var src []byte
+	var err error
+	var dir string
+	if rt.fsys != nil {
+		src, err = fs.ReadFile(rt.fsys, name)
+		dir = path.Dir(name)
+	} else {
+		src, err = os.ReadFile(name)
+		dir = filepath.Dir(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// imports are only allowed at the top level, so only the file itself imports with this importer
+	if rt.loader != nil {
+		importer := rt.env.Importer()
+		rt.env.SetImporter(rt.loader.Importer(dir))
+		defer rt.env.SetImporter(importer)
+	}
+	return rt.eval(ctx, name, string(src))
+}
+
+// Evaluate Bolt source from a file, or from Eval if the name of the file is empty
+func (rt *Runtime) eval(ctx context.Context, file, src string) (interface{}, error) {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if errs := p.ParseErrors(); len(errs) != 0 {
+		compileErr := &CompileError{File: file}
+		for _, err := range errs {
+			compileErr.Errors = append(compileErr.Errors, err)
+		}
+		return nil, compileErr
+	}
+
+	info := resolver.Resolve(program, resolver.Predeclared(rt.env.Names()...))
+	if len(info.Errors) != 0 {
+		compileErr := &CompileError{File: file}
+		for _, err := range info.Errors {
+			compileErr.Errors = append(compileErr.Errors, err)
+		}
+		return nil, compileErr
+	}
+
+	return result(run(ctx, rt.env.Limits(), func() object.Object {
+		return evaluator.Eval(program, rt.env)
+	}))
+}
+
+// Bind a Go value to a name in the top-level environment of the runtime, replacing any previous binding
+//   - Return an error if the value cannot be converted, see toObject
+func (rt *Runtime) Set(name string, value interface{}) error {
+	obj, err := toObject(value)
+	if err != nil {
+		return fmt.Errorf("cannot set %s: %w", name, err)
+	}
+	if builtin, ok := obj.(*object.Builtin); ok {
+		builtin.Name = name
+	}
+	rt.env.Set(name, obj)
+	return nil
+}
+
+// Return the value bound to a name in the top-level environment of the runtime converted to Go,
+// and whether the name is bound at all
+//   - Integers convert to int64, booleans to bool, strings to string and null to nil
+//   - Arrays convert to []interface{} and hashes to map[string]interface{}, converting their elements
+//   - Functions convert to a Func calling them
+func (rt *Runtime) Get(name string) (interface{}, bool) {
+	obj, ok := rt.env.Get(name)
+	if !ok {
+		return nil, false
+	}
+	return fromObject(obj), true
+}
+
+// Call the function bound to a name in the top-level environment of the runtime with Go arguments,
+// and return its result converted to Go
+//   - Return an error if the name is not bound or an argument cannot be converted
+//   - A call that fails, including one of a value that is not a function, returns a *RuntimeError
+func (rt *Runtime) Call(name string, args ...interface{}) (interface{}, error) {
+	return rt.CallContext(context.Background(), name, args...)
+}
+
+// Call a Bolt function as by Call, stopping with an error wrapping that of ctx once it is done
+func (rt *Runtime) CallContext(ctx context.Context, name string, args ...interface{}) (interface{}, error) {
+	fn, ok := rt.env.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("cannot call %s: not defined", name)
+	}
+	return call(ctx, fn, args)
+}
+
+// Call a Bolt function with Go arguments, and return its result converted to Go
+//   - A function created in a limited environment is called within its limits, see run
+func call(ctx context.Context, fn object.Object, args []interface{}) (interface{}, error) {
+	objs := make([]object.Object, len(args))
+	for i, arg := range args {
+		obj, err := toObject(arg)
+		if err != nil {
+			return nil, fmt.Errorf("cannot use argument %d: %w", i+1, err)
+		}
+		objs[i] = obj
+	}
+	var limits *object.Limits
+	if function, ok := fn.(*object.Function); ok {
+		limits = function.Env.Limits()
+	}
+	return result(run(ctx, limits, func() object.Object {
+		return evaluator.Apply(fn, objs)
+	}))
+}
+
+// Convert the result of an evaluation to Go, or to a *RuntimeError if it is an error
+//   - A result without a value, such as that of a let statement, converts to nil
+func result(obj object.Object) (interface{}, error) {
+	if err, ok := obj.(*object.Error); ok {
+		return nil, &RuntimeError{Message: err.Message, Err: err.Err}
+	}
+	if obj == nil {
+		return nil, nil
+	}
+	return fromObject(obj), nil
+}
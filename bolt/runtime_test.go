@@ -0,0 +1,330 @@
+package bolt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1 + 2", int64(3)},
+		{"1 < 2", true},
+		{"let x = 1;", nil},
+		{"return 5; 6", int64(5)},
+		{"if (false) { 1 }", nil},
+		{"let f = fn(x) { x * 2 }; f(21)", int64(42)},
+	}
+
+	for _, tt := range tests {
+		result, err := NewRuntime().Eval(tt.input)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("wrong result for %q. expected=%#v, got=%#v", tt.input, tt.expected, result)
+		}
+	}
+}
+
+func TestEvalKeepsBindings(t *testing.T) {
+	rt := NewRuntime()
+	if err := rt.Set("greeting", "hello"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if result, err := rt.Eval("greeting + greeting"); err != nil || result != "hellohello" {
+		t.Errorf("wrong result. expected=hellohello, got=%#v (%v)", result, err)
+	}
+	if _, err := rt.Eval("let double = fn(x) { x * 2 };"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := rt.Eval("let y = double(4); y + 1")
+	if err != nil || result != int64(9) {
+		t.Errorf("wrong result. expected=9, got=%#v (%v)", result, err)
+	}
+	if y, ok := rt.Get("y"); !ok || y != int64(8) {
+		t.Errorf("wrong value of y. expected=8, got=%#v", y)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		compile  bool
+		expected string
+	}{
+		{"let = 1;", true, "1:5: expected next token to be IDENT, got = instead\n1:5: no prefix parse function for = found"},
+		{"y + 1;\nz;", true, "1:1: identifier not found: y\n2:1: identifier not found: z"},
+		{"1 + true", false, "type mismatch: INTEGER + BOOLEAN"},
+		{"let f = fn(x) { x }; f()", false, "wrong number of arguments: want=1, got=0"},
+	}
+
+	for _, tt := range tests {
+		_, err := NewRuntime().Eval(tt.input)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%v", tt.input, tt.expected, err)
+			continue
+		}
+
+		var compileErr *CompileError
+		var runtimeErr *RuntimeError
+		if errors.As(err, &compileErr) != tt.compile || errors.As(err, &runtimeErr) == tt.compile {
+			t.Errorf("wrong type of error for %q. got=%T", tt.input, err)
+		}
+	}
+}
+
+func TestSetAndGet(t *testing.T) {
+	type named string
+	n := 7
+
+	tests := []struct {
+		value    interface{}
+		expected interface{}
+	}{
+		{int64(1), int64(1)},
+		{42, int64(42)},
+		{uint8(255), int64(255)},
+		{true, true},
+		{"text", "text"},
+		{named("named"), "named"},
+		{nil, nil},
+		{&n, int64(7)},
+		{(*int)(nil), nil},
+		{[]int{1, 2}, []interface{}{int64(1), int64(2)}},
+		{[2]string{"a", "b"}, []interface{}{"a", "b"}},
+		{[]interface{}{1, "a", nil, []bool{true}}, []interface{}{int64(1), "a", nil, []interface{}{true}}},
+		{map[string]int{"a": 1}, map[string]interface{}{"a": int64(1)}},
+		{map[string]interface{}{"list": []string{"x"}}, map[string]interface{}{"list": []interface{}{"x"}}},
+	}
+
+	for _, tt := range tests {
+		rt := NewRuntime()
+		if err := rt.Set("v", tt.value); err != nil {
+			t.Errorf("unexpected error setting %#v: %s", tt.value, err)
+			continue
+		}
+		got, ok := rt.Get("v")
+		if !ok || !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("wrong value for %#v. expected=%#v, got=%#v", tt.value, tt.expected, got)
+		}
+	}
+
+	if _, ok := NewRuntime().Get("missing"); ok {
+		t.Errorf("expected an unbound name not to be found")
+	}
+}
+
+func TestSetErrors(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{uint64(1 << 63), "cannot set v: 9223372036854775808 overflows a Bolt integer"},
+		{3.5, "cannot set v: cannot convert float64 to a Bolt value"},
+		{map[int]string{}, "cannot set v: cannot convert map[int]string to a Bolt hash: keys must be strings"},
+		{[]interface{}{struct{}{}}, "cannot set v: cannot convert struct {} to a Bolt value"},
+		{func(chan int) {}, "cannot set v: cannot convert func(chan int) to a Bolt value: unsupported type chan int of parameter 1"},
+	}
+
+	for _, tt := range tests {
+		rt := NewRuntime()
+		err := rt.Set("v", tt.value)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error for %#v. expected=%q, got=%v", tt.value, tt.expected, err)
+		}
+		if _, ok := rt.Get("v"); ok {
+			t.Errorf("expected %#v not to be bound", tt.value)
+		}
+	}
+}
+
+func TestHostFunctions(t *testing.T) {
+	rt := NewRuntime()
+	join := func(args ...interface{}) (interface{}, error) {
+		parts := make([]string, len(args))
+		for i, arg := range args {
+			parts[i] = fmt.Sprint(arg)
+		}
+		return strings.Join(parts, ","), nil
+	}
+	fail := Func(func(args ...interface{}) (interface{}, error) {
+		return nil, errors.New("host failure")
+	})
+	if err := rt.Set("join", join); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := rt.Set("fail", fail); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := rt.Set("x", "x"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := rt.Eval("join(1, true, x)")
+	if err != nil || result != "1,true,x" {
+		t.Errorf("wrong result calling a host function. got=%#v (%v)", result, err)
+	}
+
+	_, err = rt.Eval("fail()")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || err.Error() != "host failure" {
+		t.Errorf("wrong error from a failing host function. got=%v", err)
+	}
+}
+
+func TestCall(t *testing.T) {
+	rt := NewRuntime()
+	if _, err := rt.Eval("let add = fn(a, b) { a + b }; let n = 1;"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if result, err := rt.Call("add", 2, int64(3)); err != nil || result != int64(5) {
+		t.Errorf("wrong result of add. got=%#v (%v)", result, err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []interface{}
+		expected string
+	}{
+		{"add", []interface{}{1, "a"}, "type mismatch: INTEGER + STRING"},
+		{"add", []interface{}{1}, "wrong number of arguments: want=2, got=1"},
+		{"add", []interface{}{1, 2.5}, "cannot use argument 2: cannot convert float64 to a Bolt value"},
+		{"n", nil, "not a function: INTEGER"},
+		{"missing", nil, "cannot call missing: not defined"},
+	}
+
+	for _, tt := range tests {
+		_, err := rt.Call(tt.name, tt.args...)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error calling %s%v. expected=%q, got=%v", tt.name, tt.args, tt.expected, err)
+		}
+	}
+}
+
+func TestFunctionValues(t *testing.T) {
+	rt := NewRuntime()
+	if _, err := rt.Eval("let adder = fn(x) { fn(y) { x + y } };"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	result, err := rt.Call("adder", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	add, ok := result.(Func)
+	if !ok {
+		t.Fatalf("expected a Func. got=%T", result)
+	}
+	if sum, err := add(5); err != nil || sum != int64(15) {
+		t.Errorf("wrong result of the returned function. got=%#v (%v)", sum, err)
+	}
+
+	// a Func read from one runtime can be bound in another
+	other := NewRuntime()
+	if err := other.Set("add", add); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if sum, err := other.Eval("add(1)"); err != nil || sum != int64(11) {
+		t.Errorf("wrong result of a Func bound in another runtime. got=%#v (%v)", sum, err)
+	}
+}
+
+func TestImports(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"math.bolt":  "export let square = fn(x) { x * x }; export let loaded = now();",
+		"env.bolt":   `export let home = getenv("HOME");`,
+		"loop.bolt":  "let f = fn(n) { f(n + 1) }; f(0);",
+		"local.bolt": `import "./math" as m; export let nine = m.square(3);`,
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rt := NewRuntimeWithOptions(Options{MaxDepth: 100, Capabilities: CAP_CLOCK, SearchPath: []string{dir}})
+	first, err := rt.Eval(`import "math"; math.square(4) + math.loaded - math.loaded`)
+	if err != nil || first != int64(16) {
+		t.Fatalf("wrong result importing a module. got=%#v (%v)", first, err)
+	}
+	loaded, _ := rt.Eval("math.loaded")
+	time.Sleep(2 * time.Millisecond)
+	if again, err := rt.Eval(`import "./local"; import "math" as other; other.loaded`); err != nil || again != loaded {
+		t.Errorf("expected the module to be evaluated once. got=%#v, want=%#v (%v)", again, loaded, err)
+	}
+	if nine, err := rt.Eval("local.nine"); err != nil || nine != int64(9) {
+		t.Errorf("wrong result of a relative import. got=%#v (%v)", nine, err)
+	}
+
+	_, err = rt.Eval(`import "env";`)
+	var permissionErr *PermissionError
+	if !errors.As(err, &permissionErr) || permissionErr.Builtin != "getenv" {
+		t.Errorf("expected a module to be sandboxed. got=%v", err)
+	}
+	_, err = rt.Eval(`import "loop";`)
+	var depthErr *CallDepthError
+	if !errors.As(err, &depthErr) {
+		t.Errorf("expected a module to be limited. got=%v", err)
+	}
+
+	_, err = NewRuntime().Eval(`import "math";`)
+	if err == nil || err.Error() != `cannot import "math": imports are not supported here` {
+		t.Errorf("wrong error importing without a search path. got=%v", err)
+	}
+}
+
+func TestEvalFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"scripts/main.bolt": {Data: []byte("import \"./util\";\nlet total = util.double(base);\ntotal + 1")},
+		"scripts/util.bolt": {Data: []byte("export let double = fn(x) { x * 2 };")},
+		"scripts/bad.bolt":  {Data: []byte("let x = 1;\nlet = 2;")},
+		"lib/util.bolt":     {Data: []byte("export let double = fn(x) { x };")},
+	}
+	rt := NewRuntimeWithOptions(Options{FS: fsys, SearchPath: []string{"lib"}})
+	if err := rt.Set("base", 20); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if result, err := rt.EvalFile("scripts/main.bolt"); err != nil || result != int64(41) {
+		t.Errorf("wrong result of a file. got=%#v (%v)", result, err)
+	}
+	if total, _ := rt.Get("total"); total != int64(40) {
+		t.Errorf("expected the bindings of a file to be kept. got=%#v", total)
+	}
+	// code given to Eval imports relative paths from the first directory of the search path
+	if result, err := rt.Eval(`import "./util" as u; u.double(3)`); err != nil || result != int64(3) {
+		t.Errorf("wrong result importing from Eval after EvalFile. got=%#v (%v)", result, err)
+	}
+
+	_, err := rt.EvalFile("scripts/bad.bolt")
+	var compileErr *CompileError
+	if !errors.As(err, &compileErr) || !strings.HasPrefix(err.Error(), "scripts/bad.bolt:2:5: expected next token to be IDENT") {
+		t.Errorf("wrong error for a file that does not parse. got=%v", err)
+	}
+	if _, err := rt.EvalFile("scripts/missing.bolt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("wrong error for a missing file. got=%v", err)
+	}
+
+	// without a filesystem, files are read from the operating system
+	file := filepath.Join(t.TempDir(), "answer.bolt")
+	if err := os.WriteFile(file, []byte("6 * 7"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := NewRuntime().EvalFile(file); err != nil || result != int64(42) {
+		t.Errorf("wrong result of a file of the operating system. got=%#v (%v)", result, err)
+	}
+}
@@ -0,0 +1,177 @@
+package bolt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+)
+
+// Capability is a set of kinds of authority over the world outside a runtime, which the host grants
+// in Options; the builtins that need one fail with a *PermissionError unless it is granted
+type Capability uint
+
+// The capabilities, and the builtins that need them
+//   - CAP_FS_READ: readFile(path), returning the contents of a file as a string
+//   - CAP_FS_WRITE: writeFile(path, contents), creating or replacing a file
+//
+// The files are those of Options.FS, or of the operating system if it is nil.
+//   - CAP_ENV: getenv(name), returning the value of an environment variable, or null if it is not set
+//   - CAP_CLOCK: now(), returning the current Unix time in milliseconds
+//   - CAP_RANDOM: random(n), returning a pseudo-random integer from 0 to n - 1
+//   - CAP_EXIT: exit(code), stopping the run with an *ExitError rather than exiting the host process
+const (
+	CAP_FS_READ Capability = 1 << iota
+	CAP_FS_WRITE
+	CAP_ENV
+	CAP_CLOCK
+	CAP_RANDOM
+	CAP_EXIT
+
+	CAP_NONE Capability = 0
+	CAP_ALL             = CAP_FS_READ | CAP_FS_WRITE | CAP_ENV | CAP_CLOCK | CAP_RANDOM | CAP_EXIT
+)
+
+// The names of the capabilities, in the order of their bits
+var capabilityNames = []string{"fs-read", "fs-write", "env", "clock", "random", "exit"}
+
+// Return the names of the capabilities in the set, separated by "|", e.g. "fs-read|env", or "none"
+func (c Capability) String() string {
+	names := []string{}
+	for i, name := range capabilityNames {
+		if c&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// PermissionError fails a call to a builtin that needs a capability the runtime was not granted
+type PermissionError struct {
+	Builtin    string
+	Capability Capability
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("permission denied: %s needs the %s capability", e.Builtin, e.Capability)
+}
+
+// WriteFS is a filesystem that writeFile can create and replace files in, when given as Options.FS
+//   - WriteFile: writes data to the named file, creating it with permissions perm if it does not exist
+type WriteFS interface {
+	fs.FS
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+}
+
+// ErrReadOnly fails writeFile, wrapped in an *fs.PathError, when Options.FS is not a WriteFS
+var ErrReadOnly = errors.New("read-only filesystem")
+
+// ExitError stops a run calling exit(code)
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// sandbox implements the builtins that touch the world outside a runtime, each checking its capability first
+//   - granted: the capabilities the host granted to the runtime
+//   - fsys: the filesystem of readFile and writeFile, or nil for that of the operating system
+type sandbox struct {
+	granted Capability
+	fsys    fs.FS
+}
+
+// Bind the builtins of the sandbox in the top-level environment of a runtime
+func (s *sandbox) register(rt *Runtime) {
+	builtins := map[string]interface{}{
+		"readFile":  s.readFile,
+		"writeFile": s.writeFile,
+		"getenv":    s.getenv,
+		"now":       s.now,
+		"random":    s.random,
+		"exit":      s.exit,
+	}
+	for name, fn := range builtins {
+		if err := rt.RegisterFunc(name, fn); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Return a *PermissionError if a capability needed by a builtin was not granted
+func (s *sandbox) check(builtin string, capability Capability) error {
+	if s.granted&capability != capability {
+		return &PermissionError{Builtin: builtin, Capability: capability}
+	}
+	return nil
+}
+
+func (s *sandbox) readFile(path string) (string, error) {
+	if err := s.check("readFile", CAP_FS_READ); err != nil {
+		return "", err
+	}
+	var data []byte
+	var err error
+	if s.fsys != nil {
+		data, err = fs.ReadFile(s.fsys, path)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	return string(data), err
+}
+
+func (s *sandbox) writeFile(path, contents string) error {
+	if err := s.check("writeFile", CAP_FS_WRITE); err != nil {
+		return err
+	}
+	if s.fsys == nil {
+		return os.WriteFile(path, []byte(contents), 0o644)
+	}
+	w, ok := s.fsys.(WriteFS)
+	if !ok {
+		return &fs.PathError{Op: "write", Path: path, Err: ErrReadOnly}
+	}
+	return w.WriteFile(path, []byte(contents), 0o644)
+}
+
+func (s *sandbox) getenv(name string) (interface{}, error) {
+	if err := s.check("getenv", CAP_ENV); err != nil {
+		return nil, err
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value, nil
+	}
+	return nil, nil
+}
+
+func (s *sandbox) now() (int64, error) {
+	if err := s.check("now", CAP_CLOCK); err != nil {
+		return 0, err
+	}
+	return time.Now().UnixMilli(), nil
+}
+
+func (s *sandbox) random(n int64) (int64, error) {
+	if err := s.check("random", CAP_RANDOM); err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("random: %d is not positive", n)
+	}
+	return rand.Int63n(n), nil
+}
+
+func (s *sandbox) exit(code int) error {
+	if err := s.check("exit", CAP_EXIT); err != nil {
+		return err
+	}
+	return &ExitError{Code: code}
+}
@@ -0,0 +1,148 @@
+package bolt
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCapabilityString(t *testing.T) {
+	tests := []struct {
+		capability Capability
+		expected   string
+	}{
+		{CAP_NONE, "none"},
+		{CAP_FS_READ, "fs-read"},
+		{CAP_ENV | CAP_FS_READ, "fs-read|env"},
+		{CAP_ALL, "fs-read|fs-write|env|clock|random|exit"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.capability.String(); got != tt.expected {
+			t.Errorf("wrong string. expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestSandboxDenies(t *testing.T) {
+	tests := []struct {
+		input      string
+		capability Capability
+		expected   string
+	}{
+		{"readFile(path)", CAP_FS_READ, "permission denied: readFile needs the fs-read capability"},
+		{"writeFile(path, path)", CAP_FS_WRITE, "permission denied: writeFile needs the fs-write capability"},
+		{"getenv(path)", CAP_ENV, "permission denied: getenv needs the env capability"},
+		{"now()", CAP_CLOCK, "permission denied: now needs the clock capability"},
+		{"random(10)", CAP_RANDOM, "permission denied: random needs the random capability"},
+		{"exit(1)", CAP_EXIT, "permission denied: exit needs the exit capability"},
+	}
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	for _, tt := range tests {
+		// denied both by default and when every other capability is granted
+		for _, options := range []Options{DefaultOptions, {Capabilities: CAP_ALL &^ tt.capability}} {
+			rt := NewRuntimeWithOptions(options)
+			rt.Set("path", path)
+
+			_, err := rt.Eval(tt.input)
+			var permissionErr *PermissionError
+			if !errors.As(err, &permissionErr) || err.Error() != tt.expected {
+				t.Errorf("wrong error for %q granted %s. expected=%q, got=%v", tt.input, options.Capabilities, tt.expected, err)
+			}
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected writeFile not to create %s. got=%v", path, err)
+	}
+}
+
+func TestSandboxGrants(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	t.Setenv("BOLT_SANDBOX_TEST", "set")
+
+	rt := NewRuntimeWithOptions(Options{Capabilities: CAP_ALL})
+	rt.Set("path", path)
+	rt.Set("name", "BOLT_SANDBOX_TEST")
+	rt.Set("unset", "BOLT_SANDBOX_TEST_UNSET")
+	rt.Set("contents", "hello")
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"writeFile(path, contents)", nil},
+		{"readFile(path)", "hello"},
+		{"getenv(name)", "set"},
+		{"getenv(unset)", nil},
+		{"random(1)", int64(0)},
+		{"now() > 0", true},
+	}
+
+	for _, tt := range tests {
+		result, err := rt.Eval(tt.input)
+		if err != nil || result != tt.expected {
+			t.Errorf("wrong result for %q. expected=%#v, got=%#v (%v)", tt.input, tt.expected, result, err)
+		}
+	}
+
+	_, err := rt.Eval("exit(3); 1")
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+		t.Errorf("expected exit to stop the run with code 3. got=%v", err)
+	}
+	if _, err := rt.Eval("random(0)"); err == nil || err.Error() != "random: 0 is not positive" {
+		t.Errorf("wrong error for random(0). got=%v", err)
+	}
+}
+
+// writeMapFS is an fstest.MapFS that writeFile can write to
+type writeMapFS fstest.MapFS
+
+func (m writeMapFS) Open(name string) (fs.File, error) {
+	return fstest.MapFS(m).Open(name)
+}
+
+func (m writeMapFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m[name] = &fstest.MapFile{Data: data, Mode: perm}
+	return nil
+}
+
+func TestSandboxFS(t *testing.T) {
+	readOnly := fstest.MapFS{"data/in.txt": {Data: []byte("from the map")}}
+	writable := writeMapFS{"data/in.txt": {Data: []byte("from the map")}}
+
+	tests := []struct {
+		fsys     fs.FS
+		input    string
+		expected interface{}
+		err      string
+	}{
+		{readOnly, `readFile("data/in.txt")`, "from the map", ""},
+		{readOnly, `readFile("data/missing.txt")`, nil, "open data/missing.txt: file does not exist"},
+		{readOnly, `writeFile("data/out.txt", "x")`, nil, "write data/out.txt: read-only filesystem"},
+		{writable, `writeFile("data/out.txt", "written"); readFile("data/out.txt")`, "written", ""},
+	}
+
+	for _, tt := range tests {
+		rt := NewRuntimeWithOptions(Options{Capabilities: CAP_FS_READ | CAP_FS_WRITE, FS: tt.fsys})
+		result, err := rt.Eval(tt.input)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("wrong error for %q. expected=%q, got=%v", tt.input, tt.err, err)
+			}
+			continue
+		}
+		if err != nil || result != tt.expected {
+			t.Errorf("wrong result for %q. expected=%#v, got=%#v (%v)", tt.input, tt.expected, result, err)
+		}
+	}
+
+	_, err := NewRuntimeWithOptions(Options{Capabilities: CAP_FS_WRITE, FS: readOnly}).Eval(`writeFile("x", "y")`)
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected the error to wrap ErrReadOnly. got=%v", err)
+	}
+}
@@ -0,0 +1,438 @@
+package evaluator
+
+import (
+	"bolt/ast"
+	"bolt/object"
+	"fmt"
+)
+
+// Shared instances of the values that only ever need one copy
+var (
+	NULL  = &object.Null{}
+	TRUE  = &object.Boolean{Value: true}
+	FALSE = &object.Boolean{Value: false}
+)
+
+// Evaluate an AST node in the given environment and return the resulting object
+//   - Statements are evaluated in order, stopping at the first return statement or error
+//   - Let statements bind their value in env and produce no object
+//   - Runtime errors are returned as *object.Error values rather than Go errors
+//   - Evaluation stops with an error once the limits of env, if any, are exceeded, counting the
+//     nodes evaluated, the depth of calls and the bytes allocated for strings, arrays, hashes,
+//     closures and environments
+func Eval(node ast.Node, env *object.Environment) object.Object {
+	if err := step(env); err != nil {
+		return err
+	}
+
+	switch node := node.(type) {
+
+	// Statements
+	case *ast.Program:
+		return evalProgram(node, env)
+
+	case *ast.ExpressionStatement:
+		return Eval(node.Expression, env)
+
+	case *ast.ReturnStatement:
+		if node.ReturnValue == nil {
+			return &object.ReturnValue{Value: NULL}
+		}
+		val := Eval(node.ReturnValue, env)
+		if isError(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+
+	case *ast.LetStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		if err := allocate(env, BINDING_SIZE); err != nil {
+			return err
+		}
+		env.Set(node.Name.Value, val)
+
+	case *ast.ImportStatement:
+		module := evalImportStatement(node, env)
+		if isError(module) {
+			return module
+		}
+		if err := allocate(env, BINDING_SIZE); err != nil {
+			return err
+		}
+		env.Set(node.Name.Value, module)
+
+	// Expressions
+	case *ast.IntegerLiteral:
+		return &object.Integer{Value: node.Value}
+
+	case *ast.StringLiteral:
+		str := &object.String{Value: node.Value}
+		if err := allocate(env, sizeOf(str)); err != nil {
+			return err
+		}
+		return str
+
+	case *ast.Boolean:
+		return nativeBoolToBooleanObject(node.Value)
+
+	case *ast.Identifier:
+		return evalIdentifier(node, env)
+
+	case *ast.PrefixExpression:
+		right := Eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		return evalPrefixExpression(node.Operator, right)
+
+	case *ast.InfixExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		right := Eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		result := evalInfixExpression(node.Operator, left, right)
+		if s, ok := result.(*object.String); ok {
+			if err := allocate(env, sizeOf(s)); err != nil {
+				return err
+			}
+		}
+		return result
+
+	case *ast.BlockStatement:
+		return evalBlockStatement(node, env)
+
+	case *ast.IfExpression:
+		return evalIfExpression(node, env)
+
+	case *ast.FunctionLiteral:
+		if err := allocate(env, FUNCTION_SIZE); err != nil {
+			return err
+		}
+		return &object.Function{Parameters: node.Parameters, Body: node.Body, Env: env}
+
+	case *ast.MemberExpression:
+		obj := Eval(node.Object, env)
+		if isError(obj) {
+			return obj
+		}
+		return evalMemberExpression(obj, node.Member.Value)
+
+	case *ast.CallExpression:
+		function := Eval(node.Function, env)
+		if isError(function) {
+			return function
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+		result := Apply(function, args)
+		if _, ok := function.(*object.Builtin); ok && !isError(result) {
+			if err := allocate(env, sizeOf(result)); err != nil {
+				return err
+			}
+		}
+		return result
+	}
+
+	return nil
+}
+
+// Evaluate each statement of a program in order
+//   - Unwrap and return the value of the first return statement
+//   - Stop at the first error
+//   - Otherwise return the value of the last statement
+func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, statement := range program.Statements {
+		result = Eval(statement, env)
+
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case *object.Error:
+			return result
+		}
+	}
+
+	return result
+}
+
+// Evaluate each statement of a block in order
+//   - Stop at the first return statement or error, leaving a return value wrapped so that it
+//     unwinds through the enclosing blocks to the function or program
+//   - Otherwise return the value of the last statement, or null if it has none, such as a let statement
+func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, statement := range block.Statements {
+		result = Eval(statement, env)
+
+		if result != nil {
+			if rt := result.Type(); rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+				return result
+			}
+		}
+	}
+
+	if result == nil {
+		return NULL
+	}
+	return result
+}
+
+// Evaluate the block of an if expression chosen by its condition, each in an environment of its own
+//   - Without an alternative, a falsy condition evaluates to null
+func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	condition := Eval(ie.Condition, env)
+	if isError(condition) {
+		return condition
+	}
+
+	block := ie.Consequence
+	if !isTruthy(condition) {
+		block = ie.Alternative
+	}
+	if block == nil {
+		return NULL
+	}
+	if err := allocate(env, ENVIRONMENT_SIZE); err != nil {
+		return err
+	}
+	return Eval(block, object.NewEnclosedEnvironment(env))
+}
+
+// Determine whether an object counts as true in a condition: only false and null are falsy
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case FALSE, NULL:
+		return false
+	default:
+		return true
+	}
+}
+
+// Evaluate expressions in order, returning only the error if one of them fails
+func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	var result []object.Object
+
+	for _, e := range exps {
+		evaluated := Eval(e, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+
+	return result
+}
+
+// Call a function or builtin with its arguments
+//   - The arguments are bound to the parameters in an environment enclosed by that of the function,
+//     in which the body is evaluated
+//   - A return statement in the body returns from this call only
+//   - A builtin is called with the arguments as they are, and a nil result counts as null
+//   - The depth of the call counts against the limits of the environment the function was created in
+func Apply(fn object.Object, args []object.Object) object.Object {
+	if builtin, ok := fn.(*object.Builtin); ok {
+		if result := builtin.Fn(args...); result != nil {
+			return result
+		}
+		return NULL
+	}
+
+	function, ok := fn.(*object.Function)
+	if !ok {
+		return newError("not a function: %s", fn.Type())
+	}
+	if len(args) != len(function.Parameters) {
+		return newError("wrong number of arguments: want=%d, got=%d", len(function.Parameters), len(args))
+	}
+
+	leave, err := enter(function.Env)
+	defer leave()
+	if err != nil {
+		return err
+	}
+	if err := allocate(function.Env, ENVIRONMENT_SIZE+BINDING_SIZE*int64(len(args))); err != nil {
+		return err
+	}
+
+	env := object.NewEnclosedEnvironment(function.Env)
+	for i, param := range function.Parameters {
+		env.Set(param.Name.Value, args[i])
+	}
+
+	result := Eval(function.Body, env)
+	if returnValue, ok := result.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+	return result
+}
+
+// Look up the value bound to an identifier, or return an error if it is not bound
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	val, ok := env.Get(node.Value)
+	if !ok {
+		return newError("identifier not found: " + node.Value)
+	}
+	return val
+}
+
+// Import the module at the path of an import statement with the importer of the environment
+//   - Return an error if the environment cannot import, or the module cannot be imported
+func evalImportStatement(node *ast.ImportStatement, env *object.Environment) object.Object {
+	importer := env.Importer()
+	if importer == nil {
+		return newError("cannot import %q: imports are not supported here", node.Path.Value)
+	}
+	module, err := importer.Import(node.Path.Value)
+	if err != nil {
+		return &object.Error{Message: err.Error(), Err: err}
+	}
+	return module
+}
+
+// Look up a member exported by a module, or return an error if the object is not a module or does not export it
+func evalMemberExpression(obj object.Object, name string) object.Object {
+	module, ok := obj.(*object.Module)
+	if !ok {
+		return newError("cannot select %s of %s: not a module", name, obj.Type())
+	}
+	val, ok := module.Exports[name]
+	if !ok {
+		return newError("%s is not exported by module %s", name, module.Name)
+	}
+	return val
+}
+
+// Evaluate a prefix expression based on its operator
+func evalPrefixExpression(operator string, right object.Object) object.Object {
+	switch operator {
+	case "!":
+		return evalBangOperatorExpression(right)
+	case "-":
+		return evalMinusPrefixOperatorExpression(right)
+	default:
+		return newError("unknown operator: %s%s", operator, right.Type())
+	}
+}
+
+// Negate the truthiness of an object: only false and null are falsy
+func evalBangOperatorExpression(right object.Object) object.Object {
+	switch right {
+	case TRUE:
+		return FALSE
+	case FALSE:
+		return TRUE
+	case NULL:
+		return TRUE
+	default:
+		return FALSE
+	}
+}
+
+// Negate an integer
+func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	if right.Type() != object.INTEGER_OBJ {
+		return newError("unknown operator: -%s", right.Type())
+	}
+
+	value := right.(*object.Integer).Value
+	return &object.Integer{Value: -value}
+}
+
+// Evaluate an infix expression based on the types of its operands
+//   - Integers support arithmetic and comparison
+//   - Strings support concatenation and comparison by value
+//   - Any other pair of objects of the same type supports equality by identity
+func evalInfixExpression(operator string, left, right object.Object) object.Object {
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return evalIntegerInfixExpression(operator, left, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, left, right)
+	case left.Type() != right.Type():
+		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	case operator == "==":
+		return nativeBoolToBooleanObject(left == right)
+	case operator == "!=":
+		return nativeBoolToBooleanObject(left != right)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// Evaluate an infix expression with two integer operands
+func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.Integer).Value
+	rightVal := right.(*object.Integer).Value
+
+	switch operator {
+	case "+":
+		return &object.Integer{Value: leftVal + rightVal}
+	case "-":
+		return &object.Integer{Value: leftVal - rightVal}
+	case "*":
+		return &object.Integer{Value: leftVal * rightVal}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		return &object.Integer{Value: leftVal / rightVal}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// Evaluate an infix expression with two string operands
+func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// Return the shared boolean object for a Go boolean
+func nativeBoolToBooleanObject(input bool) *object.Boolean {
+	if input {
+		return TRUE
+	}
+	return FALSE
+}
+
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
+
+func isError(obj object.Object) bool {
+	if obj != nil {
+		return obj.Type() == object.ERROR_OBJ
+	}
+	return false
+}
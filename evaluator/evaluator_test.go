@@ -0,0 +1,399 @@
+package evaluator
+
+import (
+	"bolt/lexer"
+	"bolt/object"
+	"bolt/parser"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEvalIntegerExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"5", 5},
+		{"10", 10},
+		{"-5", -5},
+		{"-10", -10},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"2 * 2 * 2 * 2 * 2", 32},
+		{"-50 + 100 + -50", 0},
+		{"5 * 2 + 10", 20},
+		{"5 + 2 * 10", 25},
+		{"20 + 2 * -10", 0},
+		{"50 / 2 * 2 + 10", 60},
+		{"2 * (5 + 10)", 30},
+		{"3 * 3 * 3 + 10", 37},
+		{"3 * (3 * 3) + 10", 37},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalBooleanExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 < 1", false},
+		{"1 == 1", true},
+		{"1 != 1", false},
+		{"1 == 2", false},
+		{"1 != 2", true},
+		{"true == true", true},
+		{"false == false", true},
+		{"true == false", false},
+		{"true != false", true},
+		{"(1 < 2) == true", true},
+		{"(1 > 2) == true", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestBangOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"!true", false},
+		{"!false", true},
+		{"!5", false},
+		{"!!true", true},
+		{"!!false", false},
+		{"!!5", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestReturnStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"return 10;", 10},
+		{"return 10; 9;", 10},
+		{"return 2 * 5; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{"return;", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if expected, ok := tt.expected.(int); ok {
+			testIntegerObject(t, evaluated, int64(expected))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestErrorHandling(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"5 + true;", "type mismatch: INTEGER + BOOLEAN"},
+		{"5 + true; 5;", "type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "unknown operator: -BOOLEAN"},
+		{"true + false;", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"5; true + false; 5", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"foobar", "identifier not found: foobar"},
+		{"let x = 1 / 0;", "division by zero"},
+		{"if (10 > 1) { if (10 > 1) { return true + false; } return 1; }", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"5(1)", "not a function: INTEGER"},
+		{"let f = fn(x) { x }; f(1, 2)", "wrong number of arguments: want=1, got=2"},
+		{"let f = fn(x) { x }; f(y)", "identifier not found: y"},
+		{"if (true) { let z = 1; }; z", "identifier not found: z"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestLetStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let a = 5; a;", 5},
+		{"let a = 5 * 5; a;", 25},
+		{"let a = 5; let b = a; b;", 5},
+		{"let a = 5; let b = a; let c = a + b + 5; c;", 15},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestStringOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected object.Object
+	}{
+		{"a + b", &object.String{Value: "foobar"}},
+		{"a + a + b", &object.String{Value: "foofoobar"}},
+		{"a == a", TRUE},
+		{"a == b", FALSE},
+		{"a != b", TRUE},
+		{"a - b", &object.Error{Message: "unknown operator: STRING - STRING"}},
+		{"a + 1", &object.Error{Message: "type mismatch: STRING + INTEGER"}},
+		{`"foo" + b`, &object.String{Value: "foobar"}},
+		{`a == "fo\x6f"`, TRUE},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Set("a", &object.String{Value: "foo"})
+		env.Set("b", &object.String{Value: "bar"})
+
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		if evaluated.Type() != tt.expected.Type() || evaluated.Inspect() != tt.expected.Inspect() {
+			t.Errorf("%q: wrong result. expected=%s, got=%s", tt.input, tt.expected.Inspect(), evaluated.Inspect())
+		}
+	}
+}
+
+func TestIfElseExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"if (true) { 10 }", 10},
+		{"if (false) { 10 }", nil},
+		{"if (1) { 10 }", 10},
+		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if 1 > 2 { 10 } else { 20 }", 20},
+		{"if (true) { let x = 1; }", nil},
+		{"let x = 1; if (true) { let x = 2; x } else { 0 }", 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if expected, ok := tt.expected.(int); ok {
+			testIntegerObject(t, evaluated, int64(expected))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestFunctionObject(t *testing.T) {
+	evaluated := testEval("fn(x: int) { x + 2; };")
+
+	fn, ok := evaluated.(*object.Function)
+	if !ok {
+		t.Fatalf("object is not Function. got=%T (%+v)", evaluated, evaluated)
+	}
+	if len(fn.Parameters) != 1 || fn.Parameters[0].Name.Value != "x" {
+		t.Errorf("function has wrong parameters. got=%v", fn.Parameters)
+	}
+	if fn.Inspect() != "fn(x: int) { (x + 2) }" {
+		t.Errorf("wrong inspection. got=%q", fn.Inspect())
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let identity = fn(x) { return x; }; identity(5);", 5},
+		{"let double = fn(x) { x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"fn(x) { x; }(5)", 5},
+		{"let f = fn() { if (true) { return 1; } 2 }; f() + 1", 2},
+		{"let newAdder = fn(x) { fn(y) { x + y } }; let addTwo = newAdder(2); addTwo(3);", 5},
+		{"let fact = fn(n) { if (n < 2) { return 1; } n * fact(n - 1) }; fact(5)", 120},
+		{"let f = fn() { 1 }; return f(); 2;", 1},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestBuiltinApplication(t *testing.T) {
+	sum := &object.Builtin{Name: "sum", Fn: func(args ...object.Object) object.Object {
+		var total int64
+		for _, arg := range args {
+			total += arg.(*object.Integer).Value
+		}
+		return &object.Integer{Value: total}
+	}}
+	none := &object.Builtin{Name: "none", Fn: func(args ...object.Object) object.Object { return nil }}
+
+	program := parser.New(lexer.New("sum(1, sum(2, 3))")).ParseProgram()
+	env := object.NewEnvironment()
+	env.Set("sum", sum)
+	testIntegerObject(t, Eval(program, env), 6)
+
+	testNullObject(t, Apply(none, nil))
+	if result := Apply(sum, nil); result.Inspect() != "0" {
+		t.Errorf("wrong result calling a builtin without arguments. got=%s", result.Inspect())
+	}
+}
+
+// mapImporter imports the modules in a map by path
+type mapImporter map[string]*object.Module
+
+func (m mapImporter) Import(path string) (*object.Module, error) {
+	if module, ok := m[path]; ok {
+		return module, nil
+	}
+	return nil, fmt.Errorf("cannot find module %q", path)
+}
+
+func TestImports(t *testing.T) {
+	math := &object.Module{Name: "math", Path: "lib/math.bolt", Exports: map[string]object.Object{
+		"two": &object.Integer{Value: 2},
+	}}
+	importer := mapImporter{"lib/math": math}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "lib/math"; math.two * 21`, "42"},
+		{`import "lib/math" as m; let f = fn() { m.two }; f()`, "2"},
+		{`import "lib/math"; math`, "module math"},
+		{`import "lib/math"; math.three`, "ERROR: three is not exported by module math"},
+		{`let x = 1; x.y`, "ERROR: cannot select y of INTEGER: not a module"},
+		{`import "lib/missing";`, `ERROR: cannot find module "lib/missing"`},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.SetImporter(importer)
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	expected := `ERROR: cannot import "lib/math": imports are not supported here`
+	if evaluated := testEval(`import "lib/math";`); evaluated.Inspect() != expected {
+		t.Errorf("wrong result importing without an importer. expected=%s, got=%s", expected, evaluated.Inspect())
+	}
+}
+
+func TestLimits(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		input    string
+		limits   object.Limits
+		expected string
+	}{
+		{"1 + 2", object.Limits{MaxSteps: 5}, ""},
+		{"1 + 2 + 3", object.Limits{MaxSteps: 5}, "step limit of 5 exceeded"},
+		{"let f = fn(n) { if (n > 0) { f(n - 1) } }; f(9)", object.Limits{MaxDepth: 10}, ""},
+		{"let f = fn(n) { if (n > 0) { f(n - 1) } }; f(10)", object.Limits{MaxDepth: 10}, "call depth limit of 10 exceeded"},
+		{"let f = fn() { f() }; f()", object.Limits{MaxDepth: 1000}, "call depth limit of 1000 exceeded"},
+		{"1", object.Limits{Timeout: time.Second, Deadline: time.Now().Add(-time.Second)}, "timed out after 1s"},
+		{"1", object.Limits{Context: canceled}, "context canceled"},
+		{"let f = fn() { 1 }; f()", object.Limits{MaxAllocated: 200}, ""},
+		{"let f = fn() { 1 }; f()", object.Limits{MaxAllocated: 100}, "allocation limit of 100 bytes exceeded"},
+		{"let f = fn(n) { if (n > 0) { f(n - 1) } }; f(100)", object.Limits{MaxAllocated: 1000}, "allocation limit of 1000 bytes exceeded"},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		env := object.NewEnvironment()
+		limits := tt.limits
+		env.SetLimits(&limits)
+
+		result := Eval(program, env)
+		errObj, ok := result.(*object.Error)
+		if tt.expected == "" {
+			if ok {
+				t.Errorf("unexpected error for %q: %s", tt.input, errObj.Message)
+			}
+		} else if !ok || errObj.Message != tt.expected || errObj.Err == nil {
+			t.Errorf("wrong result for %q. expected error %q, got=%s", tt.input, tt.expected, result.Inspect())
+		}
+		if limits.MaxAllocated > 0 && limits.Allocated == 0 {
+			t.Errorf("no bytes allocated by %q", tt.input)
+		}
+		if limits.Depth != 0 {
+			t.Errorf("call depth of %q not unwound. got=%d", tt.input, limits.Depth)
+		}
+	}
+}
+
+func testEval(input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+
+	return Eval(program, env)
+}
+
+func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		t.Errorf("object is not Integer. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%d, want=%d", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
+	result, ok := obj.(*object.Boolean)
+	if !ok {
+		t.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%t, want=%t", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func testNullObject(t *testing.T, obj object.Object) bool {
+	if obj != NULL {
+		t.Errorf("object is not NULL. got=%T (%+v)", obj, obj)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package evaluator
+
+import (
+	"bolt/object"
+	"fmt"
+	"time"
+)
+
+// The call depth allowed by default by the hosts of the evaluator, well within the Go stack
+const DEFAULT_MAX_DEPTH = 10000
+
+// StepLimitError stops a run that evaluates more nodes than its limits allow
+type StepLimitError struct {
+	Limit int64
+}
+
+func (e *StepLimitError) Error() string {
+	return fmt.Sprintf("step limit of %d exceeded", e.Limit)
+}
+
+// CallDepthError stops a run whose function calls nest deeper than its limits allow,
+// such as runaway recursion
+type CallDepthError struct {
+	Limit int
+}
+
+func (e *CallDepthError) Error() string {
+	return fmt.Sprintf("call depth limit of %d exceeded", e.Limit)
+}
+
+// TimeoutError stops a run that takes longer than its limits allow
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", e.Timeout)
+}
+
+// AllocationLimitError stops a run that allocates more bytes in total than its limits allow
+type AllocationLimitError struct {
+	Limit int64
+}
+
+func (e *AllocationLimitError) Error() string {
+	return fmt.Sprintf("allocation limit of %d bytes exceeded", e.Limit)
+}
+
+// The approximate sizes in bytes of what evaluation allocates, counted against the allocation limit
+//   - STRING_SIZE, ARRAY_SIZE, HASH_SIZE: the size of a value before its contents
+//   - ELEMENT_SIZE: the size of each element of an array or pair of a hash, besides its value and key
+//   - INTEGER_SIZE: the size of an integer
+//   - FUNCTION_SIZE: the size of a closure, sharing the environment it was created in
+//   - ENVIRONMENT_SIZE, BINDING_SIZE: the size of the environment of a call or block, and of each name bound in it
+const (
+	STRING_SIZE      = 16
+	ARRAY_SIZE       = 24
+	HASH_SIZE        = 48
+	ELEMENT_SIZE     = 16
+	INTEGER_SIZE     = 8
+	FUNCTION_SIZE    = 64
+	ENVIRONMENT_SIZE = 64
+	BINDING_SIZE     = 32
+)
+
+// Count a step of evaluation in an environment against its limits
+//   - Return an error if the step limit is exceeded, the deadline has passed or the context is done
+func step(env *object.Environment) *object.Error {
+	limits := env.Limits()
+	if limits == nil {
+		return nil
+	}
+
+	limits.Steps++
+	if limits.MaxSteps > 0 && limits.Steps > limits.MaxSteps {
+		return limitError(&StepLimitError{Limit: limits.MaxSteps})
+	}
+	if !limits.Deadline.IsZero() && time.Now().After(limits.Deadline) {
+		return limitError(&TimeoutError{Timeout: limits.Timeout})
+	}
+	if limits.Context != nil {
+		if err := limits.Context.Err(); err != nil {
+			return limitError(err)
+		}
+	}
+	return nil
+}
+
+// Enter a call to a function created in an environment, counting its depth against the limits of the environment
+//   - Return a function to call once the call returns, and an error if the depth limit is exceeded
+func enter(env *object.Environment) (func(), *object.Error) {
+	limits := env.Limits()
+	if limits == nil {
+		return func() {}, nil
+	}
+
+	limits.Depth++
+	leave := func() { limits.Depth-- }
+	if limits.MaxDepth > 0 && limits.Depth > limits.MaxDepth {
+		return leave, limitError(&CallDepthError{Limit: limits.MaxDepth})
+	}
+	return leave, nil
+}
+
+// Count bytes allocated by evaluation in an environment against its limits
+//   - Return an error if the allocation limit is exceeded
+func allocate(env *object.Environment, bytes int64) *object.Error {
+	limits := env.Limits()
+	if limits == nil {
+		return nil
+	}
+
+	limits.Allocated += bytes
+	if limits.MaxAllocated > 0 && limits.Allocated > limits.MaxAllocated {
+		return limitError(&AllocationLimitError{Limit: limits.MaxAllocated})
+	}
+	return nil
+}
+
+// Return the approximate size in bytes of an object and the objects it contains
+//   - Booleans and null are shared, and take no space
+func sizeOf(obj object.Object) int64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return INTEGER_SIZE
+	case *object.String:
+		return STRING_SIZE + int64(len(obj.Value))
+	case *object.Array:
+		size := int64(ARRAY_SIZE)
+		for _, el := range obj.Elements {
+			size += ELEMENT_SIZE + sizeOf(el)
+		}
+		return size
+	case *object.Hash:
+		size := int64(HASH_SIZE)
+		for key, value := range obj.Pairs {
+			size += ELEMENT_SIZE + int64(len(key)) + sizeOf(value)
+		}
+		return size
+	case *object.Function, *object.Builtin:
+		return FUNCTION_SIZE
+	default:
+		return 0
+	}
+}
+
+func limitError(err error) *object.Error {
+	return &object.Error{Message: err.Error(), Err: err}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bolt/format"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Format Bolt source files
+//   - With no paths, format standard input to standard output
+//   - Directories are walked recursively for .bolt files
+//   - -l: list the files whose formatting differs instead of printing them
+//   - -w: write the formatted source back to each file instead of printing it
+func runFmt(args []string, std *streams) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(std.err)
+	list := flags.Bool("l", false, "list files whose formatting differs")
+	write := flags.Bool("w", false, "write result to the source file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return EXIT_USAGE
+	}
+
+	if flags.NArg() == 0 {
+		return formatFile("-", *list, *write, std)
+	}
+
+	return walkSources(flags.Args(), std, func(file string) int {
+		return formatFile(file, *list, *write, std)
+	})
+}
+
+// Call fn for each source file named by paths, returning the highest exit code
+//   - Directories are walked recursively for .bolt files, while files named directly are used whatever their extension
+func walkSources(paths []string, std *streams, fn func(file string) int) int {
+	status := EXIT_SUCCESS
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (file != path && filepath.Ext(file) != ".bolt") {
+				return nil
+			}
+			if code := fn(file); code > status {
+				status = code
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(std.err, "bolt: %s\n", err)
+			if status < EXIT_FAILURE {
+				status = EXIT_FAILURE
+			}
+		}
+	}
+	return status
+}
+
+// Format a single file according to the -l and -w flags of `bolt fmt`, returning the exit code
+func formatFile(name string, list, write bool, std *streams) int {
+	src, err := readSource(name, std)
+	if err != nil {
+		fmt.Fprintf(std.err, "bolt: %s\n", err)
+		return EXIT_FAILURE
+	}
+	out, err := format.Source(src)
+	if err != nil {
+		fmt.Fprintf(std.err, "%s: %s\n", displayName(name), err)
+		return EXIT_PARSE_ERROR
+	}
+
+	changed := !bytes.Equal(src, out)
+	if list && changed {
+		fmt.Fprintln(std.out, displayName(name))
+	}
+	if write && changed && name != "-" {
+		info, err := os.Stat(name)
+		if err != nil {
+			fmt.Fprintf(std.err, "bolt: %s\n", err)
+			return EXIT_FAILURE
+		}
+		if err := os.WriteFile(name, out, info.Mode().Perm()); err != nil {
+			fmt.Fprintf(std.err, "bolt: %s\n", err)
+			return EXIT_FAILURE
+		}
+		return EXIT_SUCCESS
+	}
+	if !list && (!write || name == "-") {
+		std.out.Write(out)
+	}
+	return EXIT_SUCCESS
+}
@@ -0,0 +1,314 @@
+package format
+
+import (
+	"bolt/ast"
+	"bolt/lexer"
+	"bolt/parser"
+	"bolt/token"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Config controls the layout of formatted source
+//   - Width: the preferred maximum line width; longer statements are wrapped at operators
+//   - Indent: the string used to indent continuation lines
+type Config struct {
+	Width  int
+	Indent string
+}
+
+// DefaultConfig is the configuration used by Source and Program, and by `bolt fmt`
+var DefaultConfig = Config{Width: 80, Indent: "    "}
+
+// Source parses and formats Bolt source code with the default configuration
+//   - If the source cannot be parsed, return an error listing the parser errors
+func Source(src []byte) ([]byte, error) {
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, fmt.Errorf("parse errors:\n\t%s", strings.Join(p.Errors(), "\n\t"))
+	}
+
+	// the lexer skips the shebang line of a script, so keep it as it is
+	var out bytes.Buffer
+	if bytes.HasPrefix(src, []byte("#!")) {
+		shebang, _, _ := bytes.Cut(src, []byte("\n"))
+		out.Write(bytes.TrimRight(shebang, "\r"))
+		out.WriteString("\n")
+	}
+	if err := DefaultConfig.Fprint(&out, program); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Program formats a parsed program with the default configuration
+func Program(program *ast.Program) string {
+	var out bytes.Buffer
+	DefaultConfig.Fprint(&out, program)
+	return out.String()
+}
+
+// Fprint writes the canonical formatting of a program to w
+//   - One statement per line, each terminated by a semicolon
+//   - The statements of a block go on their own lines between its braces, indented once more than the block
+//   - Runs of blank lines between statements are collapsed to a single blank line
+//   - Comments are kept, either on their own line or trailing the statement whose last line they share;
+//     comments inside a statement spanning several lines are moved above it, unless they are inside a block
+func (c *Config) Fprint(w io.Writer, program *ast.Program) error {
+	pr := &printer{config: c, comments: program.Comments}
+
+	pr.statements(program.Statements)
+	pr.flushComments(0)
+
+	_, err := w.Write(pr.out.Bytes())
+	return err
+}
+
+// printer holds the state of a single Fprint call, or of one block within it
+//   - comments: the comments not yet printed, in source order
+//   - lastLine: the source line of the last printed item, or 0 if nothing has been printed
+//   - indent: the indentation of the lines printed
+type printer struct {
+	config   *Config
+	out      bytes.Buffer
+	comments []token.Token
+	lastLine int
+	indent   string
+}
+
+func (pr *printer) hasComment() bool {
+	return len(pr.comments) > 0
+}
+
+// Print statements one per line, each preceded by the comments before it
+func (pr *printer) statements(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		start, end := lineRange(stmt)
+
+		pr.flushComments(start)
+		text := pr.statement(stmt)
+		pr.flushComments(end)
+
+		pr.blankLineBefore(start)
+		if pr.hasComment() && pr.comments[0].Line == end {
+			text += " " + pr.comments[0].Literal
+			pr.comments = pr.comments[1:]
+		}
+		pr.line(text, end)
+	}
+}
+
+// Print the comments before a source line on lines of their own, or all remaining comments if line is 0
+func (pr *printer) flushComments(line int) {
+	for pr.hasComment() && (line == 0 || pr.comments[0].Line < line) {
+		pr.blankLineBefore(pr.comments[0].Line)
+		pr.line(pr.comments[0].Literal, pr.comments[0].Line)
+		pr.comments = pr.comments[1:]
+	}
+}
+
+// Write a blank line if the item starting at the given source line was separated from the last printed item
+func (pr *printer) blankLineBefore(line int) {
+	if pr.lastLine > 0 && line > pr.lastLine+1 {
+		pr.out.WriteString("\n")
+	}
+}
+
+// Write a line of output at the printer's indentation, recording the source line it ended on
+func (pr *printer) line(text string, sourceLine int) {
+	pr.out.WriteString(pr.indent)
+	pr.out.WriteString(text)
+	pr.out.WriteString("\n")
+	pr.lastLine = sourceLine
+}
+
+// Format a statement, wrapping it at its top-level operators if it does not fit in the configured width
+func (pr *printer) statement(stmt ast.Statement) string {
+	var prefix string
+	var exp ast.Expression
+
+	switch s := stmt.(type) {
+	case *ast.LetStatement:
+		prefix = "let " + s.Name.Value
+		if s.Exported() {
+			prefix = "export " + prefix
+		}
+		if s.Type != nil {
+			prefix += ": " + s.Type.Value
+		}
+		prefix += " = "
+		exp = s.Value
+	case *ast.ReturnStatement:
+		if s.ReturnValue == nil {
+			return "return;"
+		}
+		prefix = "return "
+		exp = s.ReturnValue
+	case *ast.ExpressionStatement:
+		exp = s.Expression
+	default:
+		return stmt.String()
+	}
+
+	if exp == nil {
+		return prefix + ";"
+	}
+
+	flat := prefix + pr.expression(exp) + ";"
+	infix, ok := exp.(*ast.InfixExpression)
+	if !ok || len(pr.indent)+len(flat) <= pr.config.Width || strings.Contains(flat, "\n") {
+		return flat
+	}
+
+	return pr.wrap(prefix, infix)
+}
+
+// Wrap a left-associative chain of same-precedence operators over several lines
+//   - Each line is filled with as many operands as fit, and ends with the operator that follows it
+//   - Continuation lines are indented once
+func (pr *printer) wrap(prefix string, infix *ast.InfixExpression) string {
+	operands, operators := pr.flatten(infix)
+
+	var out strings.Builder
+	current := prefix + operands[0]
+	for i, op := range operators {
+		next := operands[i+1]
+		// leave room for the operator or semicolon that will end the line
+		if len(pr.indent)+len(current)+len(op)+len(next)+3 > pr.config.Width {
+			out.WriteString(current + " " + op + "\n")
+			current = pr.indent + pr.config.Indent + next
+			continue
+		}
+		current += " " + op + " " + next
+	}
+	out.WriteString(current + ";")
+
+	return out.String()
+}
+
+// Split a chain of infix expressions sharing the precedence of the root into its formatted operands and operators
+func (pr *printer) flatten(infix *ast.InfixExpression) ([]string, []string) {
+	precedence := parser.Precedence(infix.Token.Type)
+
+	var operands, operators []string
+	if left, ok := infix.Left.(*ast.InfixExpression); ok && parser.Precedence(left.Token.Type) == precedence {
+		operands, operators = pr.flatten(left)
+	} else {
+		operands = []string{pr.operand(infix.Left, precedence, false)}
+	}
+
+	operands = append(operands, pr.operand(infix.Right, precedence, true))
+	operators = append(operators, infix.Operator)
+
+	return operands, operators
+}
+
+// Expression formats an expression with the minimal parentheses needed to preserve its structure
+//   - Operators bind according to the parser's precedence table
+//   - All infix operators are left-associative, so a right operand of equal precedence is parenthesized
+//   - Blocks are laid out over several lines with the default configuration
+func Expression(exp ast.Expression) string {
+	pr := &printer{config: &DefaultConfig}
+	return pr.expression(exp)
+}
+
+func (pr *printer) expression(exp ast.Expression) string {
+	switch e := exp.(type) {
+	case *ast.PrefixExpression:
+		return e.Operator + pr.operand(e.Right, parser.PREFIX, false)
+	case *ast.InfixExpression:
+		precedence := parser.Precedence(e.Token.Type)
+		return pr.operand(e.Left, precedence, false) + " " + e.Operator + " " + pr.operand(e.Right, precedence, true)
+	case *ast.IfExpression:
+		text := "if (" + pr.expression(e.Condition) + ") " + pr.block(e.Consequence)
+		if e.Alternative != nil {
+			text += " else " + pr.block(e.Alternative)
+		}
+		return text
+	case *ast.FunctionLiteral:
+		params := make([]string, len(e.Parameters))
+		for i, p := range e.Parameters {
+			params[i] = p.String()
+		}
+		text := "fn(" + strings.Join(params, ", ") + ")"
+		if e.ReturnType != nil {
+			text += " -> " + e.ReturnType.Value
+		}
+		return text + " " + pr.block(e.Body)
+	case *ast.CallExpression:
+		args := make([]string, len(e.Arguments))
+		for i, a := range e.Arguments {
+			args[i] = pr.expression(a)
+		}
+		return pr.operand(e.Function, parser.CALL, false) + "(" + strings.Join(args, ", ") + ")"
+	case *ast.MemberExpression:
+		return pr.operand(e.Object, parser.MEMBER, false) + "." + e.Member.Value
+	case nil:
+		return ""
+	default:
+		return exp.String()
+	}
+}
+
+// Format a block, printing its statements and the comments among them one level further indented
+//   - The comments before its closing brace are kept inside it
+//   - An empty block is printed as {}
+func (pr *printer) block(b *ast.BlockStatement) string {
+	if b == nil {
+		return "{}"
+	}
+
+	inner := &printer{config: pr.config, comments: pr.comments, indent: pr.indent + pr.config.Indent}
+	inner.statements(b.Statements)
+	if b.Rbrace.Line > 0 {
+		inner.flushComments(b.Rbrace.Line)
+	}
+	pr.comments = inner.comments
+
+	if inner.out.Len() == 0 {
+		return "{}"
+	}
+	return "{\n" + inner.out.String() + pr.indent + "}"
+}
+
+// Format an operand of an operator with the given precedence, parenthesizing it if it binds more loosely
+func (pr *printer) operand(exp ast.Expression, precedence int, right bool) string {
+	inner := parser.PREFIX
+	switch e := exp.(type) {
+	case *ast.InfixExpression:
+		inner = parser.Precedence(e.Token.Type)
+	case *ast.PrefixExpression:
+	default:
+		return pr.expression(exp)
+	}
+
+	if inner < precedence || (right && inner == precedence) {
+		return "(" + pr.expression(exp) + ")"
+	}
+	return pr.expression(exp)
+}
+
+// Return the first and last source lines spanned by the tokens of a statement
+func lineRange(stmt ast.Statement) (int, int) {
+	start, end := 0, 0
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		line := ast.FirstToken(n).Line
+		if block, ok := n.(*ast.BlockStatement); ok && block.Rbrace.Line > 0 {
+			line = block.Rbrace.Line
+		}
+		if line == 0 {
+			return n != nil
+		}
+		if start == 0 || line < start {
+			start = line
+		}
+		if line > end {
+			end = line
+		}
+		return true
+	})
+	return start, end
+}
@@ -0,0 +1,124 @@
+package format
+
+import (
+	"bolt/lexer"
+	"bolt/parser"
+	"bytes"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let   x=5", "let x = 5;\n"},
+		{"return", "return;\n"},
+		{"return (a)", "return a;\n"},
+		{"((a + b) * c)", "(a + b) * c;\n"},
+		{"a + (b * c)", "a + b * c;\n"},
+		{"(a + b) + c", "a + b + c;\n"},
+		{"a + (b + c)", "a + (b + c);\n"},
+		{"a - (b - c)", "a - (b - c);\n"},
+		{"-(a + b)", "-(a + b);\n"},
+		{"!(-a)", "!-a;\n"},
+		{"(5 > 4) == (3 < 4)", "5 > 4 == 3 < 4;\n"},
+		{"(a == b) == c", "a == b == c;\n"},
+		{"a == (b == c)", "a == (b == c);\n"},
+		{"a; b;\nc", "a;\nb;\nc;\n"},
+		{"a;\n\n\n\nb;", "a;\n\nb;\n"},
+		{"// leading\nlet x = 1; // trailing\n\n// closing\n", "// leading\nlet x = 1; // trailing\n\n// closing\n"},
+		{"let x = a +\n  // inner\n  b;", "// inner\nlet x = a + b;\n"},
+		{"// only a comment", "// only a comment\n"},
+		{"", ""},
+		{"let  x :int=5", "let x: int = 5;\n"},
+		{"#!/usr/bin/env bolt\r\nlet x=1", "#!/usr/bin/env bolt\nlet x = 1;\n"},
+		{"#!/usr/bin/env bolt", "#!/usr/bin/env bolt\n"},
+		{"let f=fn(a:int,b)->int{a+b}", "let f = fn(a: int, b) -> int {\n    a + b;\n};\n"},
+		{"fn(){}()", "fn() {}();\n"},
+		{"if x<y {x} else {if (y) {y}}", "if (x < y) {\n    x;\n} else {\n    if (y) {\n        y;\n    };\n};\n"},
+		{"(-f)(1, (a + b))", "(-f)(1, a + b);\n"},
+		{`import  "lib/m"as m`, "import \"lib/m\" as m;\n"},
+		{`export   let s="a\tb"+m . f(1).g`, "export let s = \"a\\tb\" + m.f(1).g;\n"},
+		{"(-m).x", "(-m).x;\n"},
+		{"let f = fn() { // trailing\n  a;\n\n  // closing\n};", "let f = fn() {\n    // trailing\n    a;\n\n    // closing\n};\n"},
+	}
+
+	for _, tt := range tests {
+		out, err := Source([]byte(tt.input))
+		if err != nil {
+			t.Fatalf("Source(%q) returned error: %s", tt.input, err)
+		}
+		if string(out) != tt.expected {
+			t.Errorf("Source(%q) wrong.\nexpected=%q\ngot=%q", tt.input, tt.expected, out)
+		}
+	}
+}
+
+func TestSourceParseError(t *testing.T) {
+	_, err := Source([]byte("let = 5;"))
+	if err == nil {
+		t.Fatalf("expected an error for invalid source")
+	}
+}
+
+func TestWrapping(t *testing.T) {
+	config := &Config{Width: 30, Indent: "    "}
+	input := "let total = alpha + bravo + charlie * delta + echo - foxtrot + golf;"
+	expected := "let total = alpha + bravo +\n" +
+		"    charlie * delta + echo -\n" +
+		"    foxtrot + golf;\n"
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	var out bytes.Buffer
+	if err := config.Fprint(&out, program); err != nil {
+		t.Fatalf("Fprint returned error: %s", err)
+	}
+	if out.String() != expected {
+		t.Errorf("wrong wrapping.\nexpected=%q\ngot=%q", expected, out.String())
+	}
+}
+
+// Formatting must preserve the meaning of a program and be idempotent
+func TestFormatPreservesTree(t *testing.T) {
+	inputs := []string{
+		"-a * b; !-a; a + b + c; a + b - c; a * b / c",
+		"a + b * c + d / e - f",
+		"3 + 4 * 5 == 3 * 1 + 4 * 5",
+		"1 + (2 + 3) + 4; (5 + 5) * 2 * (5 + 5); 2 / (5 + 5)",
+		"!(true == true); -(5 + 5)",
+		"let x = a - (b - (c - d)); return (x * (y / z));",
+		"let long = aaaaaaaaaa + bbbbbbbbbb * cccccccccc + dddddddddd - eeeeeeeeee + ffffffffff - gggggggggg;",
+		"let add = fn(a, b) { return a + b; }; add(1, add(2, 3)); fn(x) { x }(1)",
+		"if (a < b) { let c = fn() { if c { 1 } else { 2 } }; c() } else { -f(x) }",
+		"let f = fn() { let long = aaaaaaaaaa + bbbbbbbbbb * cccccccccc + dddddddddd - eeeeeeeeee + ffffffffff; long };",
+		`import "lib/m"; export let s = m.f("x").g + (-m).h;`,
+	}
+
+	for _, input := range inputs {
+		p := parser.New(lexer.New(input))
+		original := p.ParseProgram()
+
+		once, err := Source([]byte(input))
+		if err != nil {
+			t.Fatalf("Source(%q) returned error: %s", input, err)
+		}
+		twice, err := Source(once)
+		if err != nil {
+			t.Fatalf("Source(%q) returned error: %s", once, err)
+		}
+		if !bytes.Equal(once, twice) {
+			t.Errorf("formatting is not idempotent.\nfirst=%q\nsecond=%q", once, twice)
+		}
+
+		reparsed := parser.New(lexer.New(string(once))).ParseProgram()
+		if reparsed.String() != original.String() {
+			t.Errorf("formatting changed the program.\nexpected=%q\ngot=%q", original.String(), reparsed.String())
+		}
+	}
+}
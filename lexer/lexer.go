@@ -1,6 +1,10 @@
 package lexer
 
-import "bolt/token"
+import (
+	"bolt/token"
+	"strings"
+	"unicode/utf8"
+)
 
 // Lexer manages the tokenization of input
 type Lexer struct {
@@ -8,21 +12,39 @@ type Lexer struct {
 	position     int    // current position in input (points to current char)
 	readPosition int    // current reading position in input (after current char)
 	ch           byte   // current char under examination
+	line         int    // line of the current char, starting at 1
+	column       int    // column of the current char, starting at 1
+
+	comments []token.Token // line comments skipped so far, in source order
 }
 
 // Create, initialize and return a new Lexer instance
+//   - A leading shebang line, e.g. #!/usr/bin/env bolt, is skipped so that scripts can be executed directly
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 	l.readChar()
+	if strings.HasPrefix(input, "#!") {
+		for l.ch != '\n' && l.ch != 0 {
+			l.readChar()
+		}
+	}
 	return l
 }
 
 // Determine the token type of the next token in the input
 //   - Whitespace is eaten and ignored
+//   - Line comments are skipped and recorded, see Comments
+//   - Each token records the line and column of its first character
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.eatWhitespace()
+	for l.ch == '/' && l.peekChar() == '/' {
+		l.readComment()
+		l.eatWhitespace()
+	}
+
+	line, column := l.line, l.column
 
 	switch l.ch {
 	case '=':
@@ -34,6 +56,8 @@ func (l *Lexer) NextToken() token.Token {
 		}
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
+	case ':':
+		tok = newToken(token.COLON, l.ch)
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
@@ -44,10 +68,19 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.RBRACE, l.ch)
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
+	case '.':
+		tok = newToken(token.DOT, l.ch)
+	case '"':
+		tok = l.readString()
 	case '+':
 		tok = newToken(token.PLUS, l.ch)
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.ARROW, Literal: "->"}
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			l.readChar()
@@ -70,22 +103,32 @@ func (l *Lexer) NextToken() token.Token {
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Line, tok.Column = line, column
 			return tok
 		} else if isDigit(l.ch) {
 			tok.Type = token.INT
 			tok.Literal = l.readNumber()
+			tok.Line, tok.Column = line, column
 			return tok
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			tok = l.readIllegal()
 		}
 	}
 
+	tok.Line, tok.Column = line, column
 	l.readChar()
 	return tok
 }
 
 // Read the next character in the input and advance the lexer read position until the end of the input
+//   - Track the line and column of the new current character
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -127,6 +170,41 @@ func (l *Lexer) readNumber() string {
 	return l.input[position:l.position]
 }
 
+// Read a string literal, from its opening quote up to and including its closing quote
+//   - A backslash escapes the character following it, which the parser decodes
+//   - A string that is not closed on the same line is illegal
+//   - The lexer is left on the closing quote, or on the last character of an illegal string
+func (l *Lexer) readString() token.Token {
+	position := l.position
+	for {
+		switch l.peekChar() {
+		case '"':
+			l.readChar()
+			return token.Token{Type: token.STRING, Literal: l.input[position:l.readPosition]}
+		case '\\':
+			l.readChar()
+			if c := l.peekChar(); c != '\n' && c != 0 {
+				l.readChar()
+			}
+		case '\n', '\r', 0:
+			return token.Token{Type: token.ILLEGAL, Literal: l.input[position:l.readPosition]}
+		default:
+			l.readChar()
+		}
+	}
+}
+
+// Read an illegal character, keeping the bytes of a multi-byte UTF-8 character together in one token
+//   - The lexer is left on the last byte of the character
+func (l *Lexer) readIllegal() token.Token {
+	_, size := utf8.DecodeRuneInString(l.input[l.position:])
+	literal := l.input[l.position : l.position+size]
+	for i := 1; i < size; i++ {
+		l.readChar()
+	}
+	return token.Token{Type: token.ILLEGAL, Literal: literal}
+}
+
 // Determine if a character is a digit
 func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
@@ -139,6 +217,26 @@ func (l *Lexer) eatWhitespace() {
 	}
 }
 
+// Read a line comment, from the leading `//` up to (but not including) the end of the line
+//   - The comment is recorded as a token.COMMENT token rather than returned by NextToken
+func (l *Lexer) readComment() {
+	line, column, position := l.line, l.column, l.position
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+	l.comments = append(l.comments, token.Token{
+		Type:    token.COMMENT,
+		Literal: strings.TrimRight(l.input[position:l.position], "\r"),
+		Line:    line,
+		Column:  column,
+	})
+}
+
+// Return the line comments skipped by the lexer so far, in source order
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
 func newToken(tokenType token.TokenType, ch byte) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
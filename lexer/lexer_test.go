@@ -25,6 +25,7 @@ if (5 < 10) {
 
 10 == 10;
 10 != 9;
+let typed: int = 1;
 `
 
 	tests := []struct {
@@ -104,6 +105,85 @@ if (5 < 10) {
 		{token.NOT_EQ, "!="},
 		{token.INT, "9"},
 		{token.SEMICOLON, ";"},
+		{token.LET, "let"},
+		{token.IDENT, "typed"},
+		{token.COLON, ":"},
+		{token.IDENT, "int"},
+		{token.ASSIGN, "="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenPosition(t *testing.T) {
+	input := `let x = 5;
+  x == 10;
+`
+
+	tests := []struct {
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{"let", 1, 1},
+		{"x", 1, 5},
+		{"=", 1, 7},
+		{"5", 1, 9},
+		{";", 1, 10},
+		{"x", 2, 3},
+		{"==", 2, 5},
+		{"10", 2, 8},
+		{";", 2, 10},
+		{"", 3, 1},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d",
+				i, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// first
+x / y; // second
+//third`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "x"},
+		{token.SLASH, "/"},
+		{token.IDENT, "y"},
+		{token.SEMICOLON, ";"},
 		{token.EOF, ""},
 	}
 
@@ -122,4 +202,164 @@ if (5 < 10) {
 				i, tt.expectedLiteral, tok.Literal)
 		}
 	}
+
+	expected := []token.Token{
+		{Type: token.COMMENT, Literal: "// first", Line: 1, Column: 1},
+		{Type: token.COMMENT, Literal: "// second", Line: 2, Column: 8},
+		{Type: token.COMMENT, Literal: "//third", Line: 3, Column: 1},
+	}
+
+	comments := l.Comments()
+	if len(comments) != len(expected) {
+		t.Fatalf("wrong number of comments. expected=%d, got=%d", len(expected), len(comments))
+	}
+	for i, c := range comments {
+		if c != expected[i] {
+			t.Errorf("comments[%d] wrong. expected=%+v, got=%+v", i, expected[i], c)
+		}
+	}
+}
+
+// Fuzz the lexer to ensure it never panics and always reaches EOF
+//   - Every token other than EOF must have a non-empty literal
+//   - The lexer must produce at most one token per input byte before EOF
+func FuzzNextToken(f *testing.F) {
+	f.Add("let five = 5;\nlet add = fn(x, y) { x + y; };")
+	f.Add("!-/*5; 5 < 10 > 5; 10 == 10; 10 != 9;")
+	f.Add("// comment\nx // trailing")
+	f.Add("@#$%^&~`\"'\\\x00\xff")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		l := New(input)
+
+		for i := 0; ; i++ {
+			if i > len(input) {
+				t.Fatalf("lexer produced more tokens than input bytes for %q", input)
+			}
+
+			tok := l.NextToken()
+			if tok.Type == token.EOF {
+				break
+			}
+			if tok.Literal == "" {
+				t.Fatalf("token %d of type %q has an empty literal for %q", i, tok.Type, input)
+			}
+			if tok.Line < 1 || tok.Column < 1 {
+				t.Fatalf("token %d has invalid position %d:%d for %q", i, tok.Line, tok.Column, input)
+			}
+		}
+	})
+}
+
+func TestIllegalCharacters(t *testing.T) {
+	input := "a @ é\xff"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.ILLEGAL, "@"},
+		{token.ILLEGAL, "é"},
+		{token.ILLEGAL, "\xff"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestStringsAndModules(t *testing.T) {
+	input := `import "lib/math" as m;
+export let s = "a \"b\" \\";
+m.max("", "é");
+"open
+"end\`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{token.IMPORT, "import", 1, 1},
+		{token.STRING, `"lib/math"`, 1, 8},
+		{token.AS, "as", 1, 19},
+		{token.IDENT, "m", 1, 22},
+		{token.SEMICOLON, ";", 1, 23},
+		{token.EXPORT, "export", 2, 1},
+		{token.LET, "let", 2, 8},
+		{token.IDENT, "s", 2, 12},
+		{token.ASSIGN, "=", 2, 14},
+		{token.STRING, `"a \"b\" \\"`, 2, 16},
+		{token.SEMICOLON, ";", 2, 28},
+		{token.IDENT, "m", 3, 1},
+		{token.DOT, ".", 3, 2},
+		{token.IDENT, "max", 3, 3},
+		{token.LPAREN, "(", 3, 6},
+		{token.STRING, `""`, 3, 7},
+		{token.COMMA, ",", 3, 9},
+		{token.STRING, `"é"`, 3, 11},
+		{token.RPAREN, ")", 3, 15},
+		{token.SEMICOLON, ";", 3, 16},
+		{token.ILLEGAL, `"open`, 4, 1},
+		{token.ILLEGAL, `"end\`, 5, 1},
+		{token.EOF, "", 5, 6},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong token. expected=%q %q, got=%q %q",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - wrong position of %q. expected=%d:%d, got=%d:%d",
+				i, tok.Literal, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestShebang(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedTypes  []token.TokenType
+		expectedLine   int
+		expectedColumn int
+	}{
+		{"#!/usr/bin/env bolt\nlet x = 1;", []token.TokenType{token.LET, token.IDENT, token.ASSIGN, token.INT, token.SEMICOLON, token.EOF}, 2, 1},
+		{"#!/usr/bin/env bolt", []token.TokenType{token.EOF}, 1, 20},
+		{" #!/usr/bin/env bolt", []token.TokenType{token.ILLEGAL, token.BANG, token.SLASH}, 1, 2},
+		{"x\n#!/usr/bin/env bolt", []token.TokenType{token.IDENT, token.ILLEGAL}, 1, 1},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for i, expected := range tt.expectedTypes {
+			tok := l.NextToken()
+			if tok.Type != expected {
+				t.Fatalf("%q: token %d has wrong type. expected=%q, got=%q", tt.input, i, expected, tok.Type)
+			}
+			if i == 0 && (tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn) {
+				t.Errorf("%q: first token has wrong position. expected=%d:%d, got=%d:%d",
+					tt.input, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+			}
+		}
+	}
 }
@@ -0,0 +1,57 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// The name of the file `bolt vet` reads its configuration from, if it exists
+const CONFIG_FILE = ".boltvet.json"
+
+// Config enables and disables rules by name
+//   - Rules not listed are enabled
+//   - The file format is JSON, e.g. {"rules": {"unused-let": false}}
+type Config struct {
+	Rules map[string]bool `json:"rules"`
+}
+
+// Parse a configuration, returning an error if it is malformed or names a rule that is not registered
+func ParseConfig(data []byte) (*Config, error) {
+	config := &Config{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(config); err != nil {
+		return nil, fmt.Errorf("invalid config: %s", err)
+	}
+
+	for name := range config.Rules {
+		if Lookup(name) == nil {
+			return nil, fmt.Errorf("invalid config: unknown rule %q", name)
+		}
+	}
+	return config, nil
+}
+
+// Read and parse the configuration in a file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return config, nil
+}
+
+// Determine whether a rule is enabled; every rule is enabled by a nil config
+func (c *Config) Enabled(name string) bool {
+	if c == nil {
+		return true
+	}
+	enabled, ok := c.Rules[name]
+	return !ok || enabled
+}
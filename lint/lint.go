@@ -0,0 +1,161 @@
+package lint
+
+import (
+	"bolt/ast"
+	"bolt/token"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Finding is a problem reported by a rule
+//   - Rule: the name of the rule that reported it
+//   - Line, Column: the position of the token at which it was found, starting at 1
+type Finding struct {
+	Rule    string `json:"rule"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%d:%d: %s (%s)", f.Line, f.Column, f.Message, f.Rule)
+}
+
+// Rule checks a program for one kind of problem
+//   - Name: the name used to enable, disable and suppress the rule, e.g. unused-let
+//   - Description: a one-line description of the problem the rule looks for
+//   - Check: inspect the program of the pass, reporting each problem found
+type Rule struct {
+	Name        string
+	Description string
+	Check       func(pass *Pass)
+}
+
+// Pass is a run of one rule over a program
+type Pass struct {
+	Program *ast.Program
+
+	rule     *Rule
+	findings []Finding
+}
+
+// Report a problem found at a token
+func (p *Pass) Report(tok token.Token, format string, a ...interface{}) {
+	p.findings = append(p.findings, Finding{
+		Rule:    p.rule.Name,
+		Line:    tok.Line,
+		Column:  tok.Column,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+// The rules known to the linter, in the order they were registered
+var rules []*Rule
+
+// Register a rule with the linter, so that Run checks it
+//   - Panic if a rule with the same name is already registered
+func Register(rule *Rule) {
+	if Lookup(rule.Name) != nil {
+		panic(fmt.Sprintf("lint: rule %s registered twice", rule.Name))
+	}
+	rules = append(rules, rule)
+}
+
+// Return the registered rules, in the order they were registered
+func Rules() []*Rule {
+	return append([]*Rule{}, rules...)
+}
+
+// Return the registered rule with a name, or nil if there is none
+func Lookup(name string) *Rule {
+	for _, rule := range rules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+	return nil
+}
+
+// The prefix of a comment that suppresses findings, e.g. // bolt:ignore unused-let
+const SUPPRESS_DIRECTIVE = "// bolt:ignore"
+
+// Run the rules enabled by the config over a program, returning the findings in source order
+//   - A nil config enables every rule
+//   - Findings on the line of a trailing suppression comment, or on the line after a suppression comment
+//     on a line of its own, are dropped; the comment lists the rules to suppress separated by commas, optionally followed by a reason,
+//     e.g. // bolt:ignore unused-let,self-comparison kept for debugging
+func Run(program *ast.Program, config *Config) []Finding {
+	suppressed := suppressions(program)
+
+	findings := []Finding{}
+	for _, rule := range rules {
+		if !config.Enabled(rule.Name) {
+			continue
+		}
+		pass := &Pass{Program: program, rule: rule}
+		rule.Check(pass)
+
+		for _, f := range pass.findings {
+			if s := suppressed[f.Line]; s != nil && (s["*"] || s[f.Rule]) {
+				continue
+			}
+			findings = append(findings, f)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Column < findings[j].Column
+	})
+	return findings
+}
+
+// Return the rules suppressed on each line by the suppression comments of a program, where "*" stands for every rule
+//   - A comment listing no rules suppresses every rule
+func suppressions(program *ast.Program) map[int]map[string]bool {
+	// the column of the first token on each line, to tell trailing comments from those on a line of their own
+	firstColumn := map[int]int{}
+	ast.Inspect(program, func(n ast.Node) bool {
+		if tok := ast.FirstToken(n); tok.Line > 0 {
+			if column, ok := firstColumn[tok.Line]; !ok || tok.Column < column {
+				firstColumn[tok.Line] = tok.Column
+			}
+		}
+		return true
+	})
+
+	suppressed := map[int]map[string]bool{}
+	for _, comment := range program.Comments {
+		rest, ok := strings.CutPrefix(comment.Literal, SUPPRESS_DIRECTIVE)
+		if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+
+		names := map[string]bool{}
+		if fields := strings.Fields(rest); len(fields) > 0 {
+			for _, name := range strings.Split(fields[0], ",") {
+				if name != "" {
+					names[name] = true
+				}
+			}
+		}
+		if len(names) == 0 {
+			names["*"] = true
+		}
+
+		line := comment.Line
+		if column, ok := firstColumn[line]; !ok || column > comment.Column {
+			line++
+		}
+		if suppressed[line] == nil {
+			suppressed[line] = map[string]bool{}
+		}
+		for name := range names {
+			suppressed[line][name] = true
+		}
+	}
+	return suppressed
+}
@@ -0,0 +1,122 @@
+package lint
+
+import (
+	"bolt/lexer"
+	"bolt/parser"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func lint(t *testing.T, input string, config *Config) []string {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %s", input, strings.Join(p.Errors(), "; "))
+	}
+
+	findings := []string{}
+	for _, f := range Run(program, config) {
+		findings = append(findings, f.String())
+	}
+	return findings
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; x;", []string{}},
+		{"let x = 1;", []string{"1:5: x is declared but its value is never used (unused-let)"}},
+		{"let x = 1; let x = 2; x;", []string{"1:5: x is declared but its value is never used (unused-let)"}},
+		{"let x = 1; let x = x + 1; x;", []string{}},
+		{"let _x = 1;", []string{}},
+		{`export let x = 1; import "lib/m"; let y = m.f;`, []string{"1:39: y is declared but its value is never used (unused-let)"}},
+		{`"a" == "b";`, []string{`1:1: condition ("a" == "b") is always false (constant-condition)`}},
+		{"let x = 1; x == x;", []string{"1:14: comparison of x with itself is always true (self-comparison)"}},
+		{"let x = 1; x + 1 < x + 1;", []string{"1:18: comparison of (x + 1) with itself is always false (self-comparison)"}},
+		{"let x = 1; x == -x;", []string{}},
+		{"let f = fn() { 1 }; f() == f();", []string{}},
+		{"let f = fn(x) { x }; f(1) + 1 < f(1) + 1;", []string{}},
+		{"1 < 2;", []string{"1:1: condition (1 < 2) is always true (constant-condition)"}},
+		{"!(1 == 2);", []string{"1:1: condition (!(1 == 2)) is always true (constant-condition)"}},
+		{"let x = 1; 2 * 3 > x;", []string{}},
+		{"1 + 2;", []string{}},
+		{"1 / 0 == 1;", []string{}},
+		{"return 1;\nlet x = 2;\n3;", []string{
+			"2:1: unreachable code (unreachable-code)",
+			"2:5: x is declared but its value is never used (unused-let)",
+		}},
+		{"let f = fn(a, b) { let c = a; 1 }; f(1, 2);", []string{"1:24: c is declared but its value is never used (unused-let)"}},
+		{"let f = fn() { f() };", []string{}},
+		{"let x = 1; if (true) { x } else { 1 < 2 };", []string{
+			"1:16: condition true is always true (constant-condition)",
+			"1:35: condition (1 < 2) is always true (constant-condition)",
+		}},
+		{"let x = 1; if (1 > 2) { x };", []string{"1:16: condition (1 > 2) is always false (constant-condition)"}},
+		{"let x = 1; if (x) { x };", []string{}},
+		{"let f = fn() { return 1; 2 };\nif (f()) { return; f() };", []string{
+			"1:26: unreachable code (unreachable-code)",
+			"2:20: unreachable code (unreachable-code)",
+		}},
+	}
+
+	for _, tt := range tests {
+		if got := lint(t, tt.input, nil); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("wrong findings for %q.\nexpected=%q\ngot=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestSuppression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"let x = 1; // bolt:ignore", 0},
+		{"let x = 1; // bolt:ignore unused-let", 0},
+		{"let x = 1; // bolt:ignore self-comparison,unused-let because", 0},
+		{"// bolt:ignore unused-let\nlet x = 1;", 0},
+		{"let x = 1; // bolt:ignore self-comparison", 1},
+		{"// bolt:ignore\n\nlet x = 1;", 1},
+		{"let x = 1; // bolt:ignored", 1},
+		{"let y = 1; // bolt:ignore\nlet x = 1;", 1},
+		{"let x =\n  // bolt:ignore\n  1 == 1;", 1},
+	}
+
+	for _, tt := range tests {
+		if got := lint(t, tt.input, nil); len(got) != tt.expected {
+			t.Errorf("wrong number of findings for %q. expected=%d, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	config, err := ParseConfig([]byte(`{"rules": {"unused-let": false, "self-comparison": true}}`))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %s", err)
+	}
+	if config.Enabled("unused-let") || !config.Enabled("self-comparison") || !config.Enabled("unreachable-code") {
+		t.Errorf("wrong rules enabled. got=%+v", config.Rules)
+	}
+	if got := lint(t, "let x = 1; return; x == x;", config); len(got) != 2 {
+		t.Errorf("wrong findings with config. got=%q", got)
+	}
+
+	for _, input := range []string{`{"rules": {"no-such-rule": false}}`, `{"rule": {}}`, `{`} {
+		if _, err := ParseConfig([]byte(input)); err == nil {
+			t.Errorf("ParseConfig(%q) did not return an error", input)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("registering a rule twice did not panic")
+		}
+	}()
+	Register(&Rule{Name: UnusedLet.Name})
+}
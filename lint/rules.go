@@ -0,0 +1,188 @@
+package lint
+
+import (
+	"bolt/ast"
+	"bolt/evaluator"
+	"bolt/object"
+	"bolt/resolver"
+	"strings"
+)
+
+// The rules provided by the linter
+var (
+	UnusedLet = &Rule{
+		Name:        "unused-let",
+		Description: "let bindings whose value is never used; names starting with _ are exempt",
+		Check:       checkUnusedLet,
+	}
+	SelfComparison = &Rule{
+		Name:        "self-comparison",
+		Description: "comparisons of an expression with itself, such as x == x",
+		Check:       checkSelfComparison,
+	}
+	ConstantCondition = &Rule{
+		Name:        "constant-condition",
+		Description: "conditions of if expressions, and comparisons, whose operands are all constants, so that they always have the same result",
+		Check:       checkConstantCondition,
+	}
+	UnreachableCode = &Rule{
+		Name:        "unreachable-code",
+		Description: "statements after a return statement in the same block, which are never run",
+		Check:       checkUnreachableCode,
+	}
+)
+
+func init() {
+	Register(UnusedLet)
+	Register(SelfComparison)
+	Register(ConstantCondition)
+	Register(UnreachableCode)
+}
+
+// Report let bindings that are not referred to before the end of their scope or being declared again
+//   - Function parameters are not reported, as a function may need to accept arguments it does not use
+//   - Exported let bindings are not reported, as they are used by the modules importing them
+func checkUnusedLet(pass *Pass) {
+	info := resolver.Resolve(pass.Program, nil)
+
+	used := map[*resolver.Declaration]bool{}
+	for _, d := range info.Uses {
+		used[d] = true
+	}
+	for _, d := range info.Declarations {
+		if let, ok := d.Node.(*ast.LetStatement); !ok || let.Exported() {
+			continue
+		}
+		if !used[d] && !strings.HasPrefix(d.Name, "_") {
+			pass.Report(d.Ident.Token, "%s is declared but its value is never used", d.Name)
+		}
+	}
+}
+
+// Determine whether an expression contains a call
+func hasCall(exp ast.Expression) bool {
+	found := false
+	ast.Inspect(exp, func(n ast.Node) bool {
+		if _, ok := n.(*ast.CallExpression); ok {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// Determine whether an infix operator compares its operands
+func isComparison(operator string) bool {
+	switch operator {
+	case "==", "!=", "<", ">":
+		return true
+	}
+	return false
+}
+
+// Report comparisons of an expression that refers to a binding with itself
+//   - Comparisons of constants are left to the constant-condition rule
+//   - Expressions containing calls are skipped, since a call may return a different value each time
+func checkSelfComparison(pass *Pass) {
+	ast.Inspect(pass.Program, func(n ast.Node) bool {
+		infix, ok := n.(*ast.InfixExpression)
+		if !ok || !isComparison(infix.Operator) || isConstant(infix.Left) {
+			return true
+		}
+		if infix.Left == nil || infix.Right == nil || hasCall(infix.Left) {
+			return true
+		}
+		if infix.Left.String() == infix.Right.String() {
+			result := infix.Operator == "=="
+			pass.Report(infix.Token, "comparison of %s with itself is always %t", infix.Left.String(), result)
+		}
+		return true
+	})
+}
+
+// Report conditions of if expressions, and comparisons and negations of them elsewhere, whose operands
+// are all constants
+func checkConstantCondition(pass *Pass) {
+	var visit func(ast.Node) bool
+	visit = func(n ast.Node) bool {
+		var condition ast.Expression
+		switch n := n.(type) {
+		case *ast.IfExpression:
+			if n.Condition != nil && isConstant(n.Condition) {
+				reportConstantCondition(pass, n.Condition)
+				// the condition is reported, so only look for others in the blocks
+				if n.Consequence != nil {
+					ast.Inspect(n.Consequence, visit)
+				}
+				if n.Alternative != nil {
+					ast.Inspect(n.Alternative, visit)
+				}
+				return false
+			}
+		case *ast.InfixExpression:
+			if isComparison(n.Operator) {
+				condition = n
+			}
+		case *ast.PrefixExpression:
+			if n.Operator == "!" {
+				condition = n
+			}
+		}
+		if condition == nil || !isConstant(condition) {
+			return true
+		}
+
+		// report the outermost constant condition only
+		reportConstantCondition(pass, condition)
+		return false
+	}
+	ast.Inspect(pass.Program, visit)
+}
+
+// Report a constant condition with its truthiness, unless evaluating it fails
+func reportConstantCondition(pass *Pass, condition ast.Expression) {
+	var always bool
+	switch result := evaluator.Eval(condition, object.NewEnvironment()).(type) {
+	case *object.Boolean:
+		always = result.Value
+	case *object.Integer:
+		always = true
+	default:
+		return
+	}
+	pass.Report(ast.FirstToken(condition), "condition %s is always %t", condition.String(), always)
+}
+
+// Determine whether an expression is made of literals only, so that it always has the same value
+func isConstant(exp ast.Expression) bool {
+	switch e := exp.(type) {
+	case *ast.IntegerLiteral, *ast.StringLiteral, *ast.Boolean:
+		return true
+	case *ast.PrefixExpression:
+		return isConstant(e.Right)
+	case *ast.InfixExpression:
+		return isConstant(e.Left) && isConstant(e.Right)
+	default:
+		return false
+	}
+}
+
+// Report the first statement after a return statement, in the program and in each block
+func checkUnreachableCode(pass *Pass) {
+	reportUnreachable(pass, pass.Program.Statements)
+	ast.Inspect(pass.Program, func(n ast.Node) bool {
+		if block, ok := n.(*ast.BlockStatement); ok {
+			reportUnreachable(pass, block.Statements)
+		}
+		return true
+	})
+}
+
+func reportUnreachable(pass *Pass, statements []ast.Statement) {
+	for i, stmt := range statements {
+		if _, ok := stmt.(*ast.ReturnStatement); ok && i+1 < len(statements) {
+			pass.Report(ast.FirstToken(statements[i+1]), "unreachable code")
+			return
+		}
+	}
+}
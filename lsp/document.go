@@ -0,0 +1,189 @@
+package lsp
+
+import (
+	"bolt/ast"
+	"bolt/lexer"
+	"bolt/parser"
+	"bolt/resolver"
+	"bolt/token"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is an open text document and the result of analysing it
+//   - lines: the text of the document split into lines, without their line endings
+//   - bindings: the let bindings and function parameters of the program, in source order
+type document struct {
+	uri      string
+	text     string
+	lines    []string
+	program  *ast.Program
+	errors   []*parser.ParseError
+	resolved *resolver.Info
+	bindings []*binding
+}
+
+// binding is a declaration made by the program and the identifiers that refer to the value it binds
+type binding struct {
+	declaration *resolver.Declaration
+	references  []token.Token
+}
+
+// Return the identifier naming the binding
+func (b *binding) name() *ast.Identifier {
+	return b.declaration.Ident
+}
+
+// Return the let statement making the binding, or nil for a function parameter
+func (b *binding) let() *ast.LetStatement {
+	let, _ := b.declaration.Node.(*ast.LetStatement)
+	return let
+}
+
+// Return the import making the binding, or nil for a let statement or function parameter
+func (b *binding) module() *ast.ImportStatement {
+	stmt, _ := b.declaration.Node.(*ast.ImportStatement)
+	return stmt
+}
+
+// Return the first token of the statement or function literal making the binding
+func (b *binding) start() token.Token {
+	return ast.FirstToken(b.declaration.Node)
+}
+
+// Determine whether the binding is made at the top level of the program
+func (b *binding) global(d *document) bool {
+	return b.declaration.Scope == d.resolved.Scope
+}
+
+// Determine whether the binding is a let statement binding a function literal
+func (b *binding) function() bool {
+	let := b.let()
+	if let == nil {
+		return false
+	}
+	_, ok := let.Value.(*ast.FunctionLiteral)
+	return ok
+}
+
+// Create a document from its text, parsing it and resolving its names
+//   - predeclared: the names bound by the host before a program runs
+func newDocument(uri, text string, predeclared []string) *document {
+	p := parser.New(lexer.New(text))
+	d := &document{
+		uri:     uri,
+		text:    text,
+		lines:   strings.Split(text, "\n"),
+		program: p.ParseProgram(),
+		errors:  p.ParseErrors(),
+	}
+	d.resolved = resolver.Resolve(d.program, resolver.Predeclared(predeclared...))
+
+	bindings := map[*ast.Identifier]*binding{}
+	for _, decl := range d.resolved.Declarations {
+		b := &binding{declaration: decl}
+		d.bindings = append(d.bindings, b)
+		bindings[decl.Ident] = b
+	}
+
+	// visit the identifiers in source order, so that the references of each binding are too
+	ast.Inspect(d.program, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Identifier); ok {
+			if decl := d.resolved.Uses[ident]; decl != nil && bindings[decl.Ident] != nil {
+				b := bindings[decl.Ident]
+				b.references = append(b.references, ident.Token)
+			}
+		}
+		return true
+	})
+
+	return d
+}
+
+// Return the diagnostics of the document, one for each parse or name error
+//   - Names are only reported once the document parses, as the errors of a partial program would be misleading
+func (d *document) diagnostics() []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, err := range d.errors {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    d.tokenRange(err.Token),
+			Severity: SEVERITY_ERROR,
+			Source:   "bolt",
+			Message:  err.Message,
+		})
+	}
+	if len(d.errors) != 0 {
+		return diagnostics
+	}
+
+	for _, err := range d.resolved.Errors {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    d.tokenRange(err.Token),
+			Severity: SEVERITY_ERROR,
+			Source:   "bolt",
+			Message:  err.Message,
+		})
+	}
+	return diagnostics
+}
+
+// Return the binding whose name, or a reference to it, is at a position, or nil if there is none
+//   - The position may be at either end of the identifier, so that a cursor just after it still finds it
+func (d *document) bindingAt(pos Position) (*binding, token.Token) {
+	for _, b := range d.bindings {
+		if d.contains(b.name().Token, pos) {
+			return b, b.name().Token
+		}
+		for _, ref := range b.references {
+			if d.contains(ref, pos) {
+				return b, ref
+			}
+		}
+	}
+	return nil, token.Token{}
+}
+
+// Determine whether a position is within or at either end of a token
+func (d *document) contains(tok token.Token, pos Position) bool {
+	r := d.tokenRange(tok)
+	return r.Start.Line == pos.Line && r.Start.Character <= pos.Character && pos.Character <= r.End.Character
+}
+
+// Return the range of a token, which the lexer locates by its one-based line and byte column
+func (d *document) tokenRange(tok token.Token) Range {
+	start := d.position(tok.Line, tok.Column)
+	end := start
+	end.Character += utf16Len(tok.Literal)
+	return Range{Start: start, End: end}
+}
+
+// Convert a one-based line and byte column to a position
+func (d *document) position(line, column int) Position {
+	if line < 1 || line > len(d.lines) {
+		return Position{Line: line - 1, Character: column - 1}
+	}
+	text := d.lines[line-1]
+	if column-1 > len(text) {
+		column = len(text) + 1
+	}
+	return Position{Line: line - 1, Character: utf16Len(text[:column-1])}
+}
+
+// Return the position just after the last character of the document
+func (d *document) end() Position {
+	last := len(d.lines) - 1
+	return Position{Line: last, Character: utf16Len(d.lines[last])}
+}
+
+// Return the number of UTF-16 code units needed to encode a string, which is how LSP measures characters
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 && r <= utf8.MaxRune {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,159 @@
+package lsp
+
+import "encoding/json"
+
+// The subset of the Language Server Protocol used by the server, see
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/
+
+// JSON-RPC error codes
+const (
+	PARSE_ERROR            = -32700
+	INVALID_REQUEST        = -32600
+	METHOD_NOT_FOUND       = -32601
+	INVALID_PARAMS         = -32602
+	SERVER_NOT_INITIALIZED = -32002
+)
+
+// message is any JSON-RPC message: a request, a notification or a response
+//   - Requests have an ID and a Method, notifications only a Method, and responses only an ID
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *ResponseError  `json:"error,omitempty"`
+}
+
+// ResponseError is the error of a failed request
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string { return e.Message }
+
+// Position is a zero-based line and UTF-16 character offset in a document
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is the span of a document from Start up to (but not including) End
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in a document
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// Diagnostic severities
+const (
+	SEVERITY_ERROR   = 1
+	SEVERITY_WARNING = 2
+)
+
+// Diagnostic is a problem reported in a document
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// TextEdit replaces a range of a document
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// Symbol and completion item kinds
+const (
+	SYMBOL_MODULE       = 2
+	SYMBOL_FUNCTION     = 12
+	SYMBOL_VARIABLE     = 13
+	COMPLETION_FUNCTION = 3
+	COMPLETION_VARIABLE = 6
+	COMPLETION_MODULE   = 9
+	COMPLETION_KEYWORD  = 14
+)
+
+// DocumentSymbol is a named entity of a document, such as a let binding
+type DocumentSymbol struct {
+	Name           string `json:"name"`
+	Kind           int    `json:"kind"`
+	Range          Range  `json:"range"`
+	SelectionRange Range  `json:"selectionRange"`
+}
+
+// CompletionItem is a candidate for completing the word at the cursor
+type CompletionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// MarkupContent is text shown to the user, such as the contents of a hover
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Hover is the information shown when the cursor rests on a symbol
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    Range         `json:"range"`
+}
+
+// The parameters of the requests and notifications handled by the server
+
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+type DidChangeTextDocumentParams struct {
+	TextDocument   TextDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type ReferenceParams struct {
+	TextDocumentPositionParams
+	Context struct {
+		IncludeDeclaration bool `json:"includeDeclaration"`
+	} `json:"context"`
+}
+
+type DocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
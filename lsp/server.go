@@ -0,0 +1,417 @@
+package lsp
+
+import (
+	"bolt/ast"
+	"bolt/format"
+	"bolt/token"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrExitWithoutShutdown is returned by Serve if the client asks the server to exit before shutting it down
+var ErrExitWithoutShutdown = errors.New("exit notification received before shutdown")
+
+// Server is a Language Server Protocol server for Bolt, speaking JSON-RPC over a pair of streams
+//   - Documents are synchronized in full on every change, and parse errors are published as diagnostics
+//   - Hover, go-to-definition and find-references work on let bindings, imports and function parameters,
+//     and document symbols list the let bindings and imports at the top level of the program
+//   - Completion offers the keywords and the names bound before the cursor
+//   - Formatting uses the canonical format of `bolt fmt`
+type Server struct {
+	Version     string   // the version reported to the client
+	Predeclared []string // the names bound by the host before a program runs
+
+	in  *bufio.Reader
+	out io.Writer
+
+	documents   map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+// handler handles a request or notification, returning the result of a request
+type handler func(s *Server, params json.RawMessage) (interface{}, error)
+
+// The handlers of the methods supported by the server, by name
+var handlers map[string]handler
+
+func init() {
+	handlers = map[string]handler{
+		"initialize":                  (*Server).initialize,
+		"initialized":                 (*Server).ignore,
+		"shutdown":                    (*Server).shutdownServer,
+		"textDocument/didOpen":        (*Server).didOpen,
+		"textDocument/didChange":      (*Server).didChange,
+		"textDocument/didClose":       (*Server).didClose,
+		"textDocument/didSave":        (*Server).ignore,
+		"textDocument/hover":          (*Server).hover,
+		"textDocument/definition":     (*Server).definition,
+		"textDocument/references":     (*Server).references,
+		"textDocument/documentSymbol": (*Server).documentSymbol,
+		"textDocument/completion":     (*Server).completion,
+		"textDocument/formatting":     (*Server).formatting,
+	}
+}
+
+// Create, initialize and return a new Server reading messages from in and writing them to out
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{in: bufio.NewReader(in), out: out, documents: map[string]*document{}}
+}
+
+// Serve messages until the client sends the exit notification or closes the input
+//   - Return nil after an orderly shutdown and exit, or an error otherwise
+func (s *Server) Serve() error {
+	for {
+		content, err := readMessage(s.in)
+		if err == io.EOF && s.shutdown {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(content, &msg); err != nil {
+			if err := s.respond(json.RawMessage("null"), nil, &ResponseError{PARSE_ERROR, err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+		if err := s.handle(&msg); err != nil {
+			return err
+		}
+	}
+}
+
+// Handle a request or notification, responding to requests
+//   - Notifications that fail or are not supported are dropped, as the protocol has no way to report them
+func (s *Server) handle(msg *message) error {
+	if msg.Method == "" {
+		// a response, but the server never sends requests
+		return nil
+	}
+	isRequest := len(msg.ID) != 0
+
+	var result interface{}
+	var err error
+	h, ok := handlers[msg.Method]
+	switch {
+	case !s.initialized && msg.Method != "initialize":
+		err = &ResponseError{SERVER_NOT_INITIALIZED, "the server has not been initialized"}
+	case s.shutdown:
+		err = &ResponseError{INVALID_REQUEST, "the server has been shut down"}
+	case !ok:
+		err = &ResponseError{METHOD_NOT_FOUND, fmt.Sprintf("method not supported: %s", msg.Method)}
+	default:
+		result, err = h(s, msg.Params)
+	}
+
+	// handlers only fail with other errors when a notification cannot be written to the client
+	if _, ok := err.(*ResponseError); err != nil && !ok {
+		return err
+	}
+	if !isRequest {
+		return nil
+	}
+	return s.respond(msg.ID, result, err)
+}
+
+// Send the response to a request, which is either a result or a *ResponseError
+func (s *Server) respond(id json.RawMessage, result interface{}, err error) error {
+	msg := &message{JSONRPC: "2.0", ID: id}
+	if err != nil {
+		msg.Error = err.(*ResponseError)
+		return writeMessage(s.out, msg)
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	msg.Result = encoded
+	return writeMessage(s.out, msg)
+}
+
+// Send a notification to the client
+func (s *Server) notify(method string, params interface{}) error {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return writeMessage(s.out, &message{JSONRPC: "2.0", Method: method, Params: encoded})
+}
+
+// Decode the parameters of a request or notification
+func decode(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &ResponseError{INVALID_PARAMS, err.Error()}
+	}
+	return nil
+}
+
+// Return the open document with a URI
+func (s *Server) document(uri string) (*document, error) {
+	d, ok := s.documents[uri]
+	if !ok {
+		return nil, &ResponseError{INVALID_PARAMS, fmt.Sprintf("document not open: %s", uri)}
+	}
+	return d, nil
+}
+
+func (s *Server) ignore(params json.RawMessage) (interface{}, error) {
+	return nil, nil
+}
+
+// Reply with the capabilities of the server
+func (s *Server) initialize(params json.RawMessage) (interface{}, error) {
+	s.initialized = true
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync":           1, // full
+			"hoverProvider":              true,
+			"definitionProvider":         true,
+			"referencesProvider":         true,
+			"documentSymbolProvider":     true,
+			"completionProvider":         map[string]interface{}{},
+			"documentFormattingProvider": true,
+		},
+		"serverInfo": map[string]string{"name": "bolt", "version": s.Version},
+	}, nil
+}
+
+func (s *Server) shutdownServer(params json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+// Open a document and publish its diagnostics
+func (s *Server) didOpen(params json.RawMessage) (interface{}, error) {
+	var p DidOpenTextDocumentParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	return nil, s.update(p.TextDocument.URI, p.TextDocument.Text)
+}
+
+// Replace the text of a document with the last of its changes, and publish its diagnostics
+func (s *Server) didChange(params json.RawMessage) (interface{}, error) {
+	var p DidChangeTextDocumentParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.ContentChanges) == 0 {
+		return nil, nil
+	}
+	return nil, s.update(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+}
+
+// Close a document, clearing its diagnostics
+func (s *Server) didClose(params json.RawMessage) (interface{}, error) {
+	var p DidCloseTextDocumentParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	delete(s.documents, p.TextDocument.URI)
+	return nil, s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+}
+
+// Analyse the new text of a document and publish its diagnostics
+func (s *Server) update(uri, text string) error {
+	d := newDocument(uri, text, s.Predeclared)
+	s.documents[uri] = d
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: d.diagnostics()})
+}
+
+// Show the let statement, import or parameter that binds the identifier at the cursor
+func (s *Server) hover(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	b, tok := d.bindingAt(p.Position)
+	if b == nil {
+		return nil, nil
+	}
+	var text string
+	switch node := b.declaration.Node.(type) {
+	case *ast.LetStatement:
+		text = "let " + node.Name.Value
+		if node.Exported() {
+			text = "export " + text
+		}
+		if node.Type != nil {
+			text += ": " + node.Type.Value
+		}
+		text += " = " + format.Expression(node.Value) + ";"
+	case *ast.ImportStatement:
+		text = "(module) " + node.Name.Value + " from " + node.Path.String()
+	case *ast.FunctionLiteral:
+		for _, param := range node.Parameters {
+			if param.Name == b.name() {
+				text = "(parameter) " + param.String()
+			}
+		}
+	}
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```bolt\n" + text + "\n```"},
+		Range:    d.tokenRange(tok),
+	}, nil
+}
+
+// Find the let statement or parameter that binds the identifier at the cursor
+func (s *Server) definition(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	b, _ := d.bindingAt(p.Position)
+	if b == nil {
+		return nil, nil
+	}
+	return &Location{URI: d.uri, Range: d.tokenRange(b.name().Token)}, nil
+}
+
+// Find the identifiers referring to the same binding as the identifier at the cursor
+//   - The name in the let statement or parameter is included if the client asks for the declaration
+func (s *Server) references(params json.RawMessage) (interface{}, error) {
+	var p ReferenceParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := []Location{}
+	b, _ := d.bindingAt(p.Position)
+	if b == nil {
+		return locations, nil
+	}
+	if p.Context.IncludeDeclaration {
+		locations = append(locations, Location{URI: d.uri, Range: d.tokenRange(b.name().Token)})
+	}
+	for _, ref := range b.references {
+		locations = append(locations, Location{URI: d.uri, Range: d.tokenRange(ref)})
+	}
+	return locations, nil
+}
+
+// List the let bindings at the top level of a document
+//   - The range of a symbol runs from the let keyword to the end of the name
+//   - A binding whose value is a function literal is a function symbol
+func (s *Server) documentSymbol(params json.RawMessage) (interface{}, error) {
+	var p DocumentParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := []DocumentSymbol{}
+	for _, b := range d.bindings {
+		if !b.global(d) {
+			continue
+		}
+		kind := SYMBOL_VARIABLE
+		switch {
+		case b.function():
+			kind = SYMBOL_FUNCTION
+		case b.module() != nil:
+			kind = SYMBOL_MODULE
+		}
+		name := d.tokenRange(b.name().Token)
+		symbols = append(symbols, DocumentSymbol{
+			Name:           b.name().Value,
+			Kind:           kind,
+			Range:          Range{Start: d.tokenRange(b.start()).Start, End: name.End},
+			SelectionRange: name,
+		})
+	}
+	return symbols, nil
+}
+
+// Offer the keywords, and the names bound by let statements and imports at the top level before the cursor
+func (s *Server) completion(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []CompletionItem{}
+	for _, keyword := range token.Keywords() {
+		items = append(items, CompletionItem{Label: keyword, Kind: COMPLETION_KEYWORD, Detail: "keyword"})
+	}
+
+	seen := map[string]bool{}
+	for _, b := range d.bindings {
+		if !b.global(d) {
+			continue
+		}
+		start := d.tokenRange(b.start()).Start
+		if start.Line > p.Position.Line || (start.Line == p.Position.Line && start.Character >= p.Position.Character) {
+			break
+		}
+		if name := b.name().Value; !seen[name] {
+			seen[name] = true
+			kind, detail := COMPLETION_VARIABLE, "let "+name
+			switch {
+			case b.function():
+				kind = COMPLETION_FUNCTION
+			case b.module() != nil:
+				kind, detail = COMPLETION_MODULE, "import "+b.module().Path.String()
+			}
+			items = append(items, CompletionItem{Label: name, Kind: kind, Detail: detail})
+		}
+	}
+	return items, nil
+}
+
+// Format a document, replacing its whole text
+//   - A document that does not parse is left as it is
+func (s *Server) formatting(params json.RawMessage) (interface{}, error) {
+	var p DocumentParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	formatted, err := format.Source([]byte(d.text))
+	if err != nil {
+		return nil, nil
+	}
+	edits := []TextEdit{}
+	if string(formatted) != d.text {
+		edits = append(edits, TextEdit{Range: Range{End: d.end()}, NewText: string(formatted)})
+	}
+	return edits, nil
+}
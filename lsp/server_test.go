@@ -0,0 +1,430 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testURI = "file:///test.bolt"
+
+// client is a scripted LSP client talking to a Server running in another goroutine
+type client struct {
+	t        *testing.T
+	out      *io.PipeWriter
+	messages chan *message
+	done     chan error
+	nextID   int
+
+	notifications []*message
+}
+
+// Start a server and return a client connected to it
+func newClient(t *testing.T) *client {
+	t.Helper()
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{t: t, out: clientOut, messages: make(chan *message, 16), done: make(chan error, 1)}
+	go func() {
+		err := NewServer(serverIn, serverOut).Serve()
+		serverOut.Close()
+		c.done <- err
+	}()
+	go func() {
+		r := bufio.NewReader(clientIn)
+		for {
+			content, err := readMessage(r)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			var msg message
+			if err := json.Unmarshal(content, &msg); err != nil {
+				t.Errorf("server sent invalid JSON %q: %s", content, err)
+			}
+			c.messages <- &msg
+		}
+	}()
+	return c
+}
+
+func (c *client) send(msg *message) {
+	c.t.Helper()
+	if err := writeMessage(c.out, msg); err != nil {
+		c.t.Fatalf("could not send %s: %s", msg.Method, err)
+	}
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.t.Helper()
+	encoded, _ := json.Marshal(params)
+	c.send(&message{JSONRPC: "2.0", Method: method, Params: encoded})
+}
+
+// Send a request and wait for its response, keeping the notifications received in the meantime
+func (c *client) request(method string, params interface{}) *message {
+	c.t.Helper()
+	c.nextID++
+	id, _ := json.Marshal(c.nextID)
+	encoded, _ := json.Marshal(params)
+	c.send(&message{JSONRPC: "2.0", ID: id, Method: method, Params: encoded})
+
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			if !ok {
+				c.t.Fatalf("connection closed while waiting for the response to %s", method)
+			}
+			if msg.Method != "" {
+				c.notifications = append(c.notifications, msg)
+				continue
+			}
+			if string(msg.ID) != string(id) {
+				c.t.Fatalf("response has wrong id. expected=%s, got=%s", id, msg.ID)
+			}
+			return msg
+		case <-time.After(5 * time.Second):
+			c.t.Fatalf("timed out waiting for the response to %s", method)
+		}
+	}
+}
+
+// Send a request and decode its result into v
+func (c *client) call(method string, params, v interface{}) {
+	c.t.Helper()
+	resp := c.request(method, params)
+	if resp.Error != nil {
+		c.t.Fatalf("%s failed: %s", method, resp.Error.Message)
+	}
+	if err := json.Unmarshal(resp.Result, v); err != nil {
+		c.t.Fatalf("could not decode the result of %s %s: %s", method, resp.Result, err)
+	}
+}
+
+// Return the diagnostics most recently published, after the server has handled the messages sent so far
+func (c *client) diagnostics() []Diagnostic {
+	c.t.Helper()
+	c.request("$/sync", nil) // any request, as the server handles messages in order
+	for i := len(c.notifications) - 1; i >= 0; i-- {
+		if c.notifications[i].Method == "textDocument/publishDiagnostics" {
+			var p PublishDiagnosticsParams
+			json.Unmarshal(c.notifications[i].Params, &p)
+			return p.Diagnostics
+		}
+	}
+	c.t.Fatalf("no diagnostics were published")
+	return nil
+}
+
+// Shut down and exit the server, returning the error of Serve
+func (c *client) close() error {
+	c.t.Helper()
+	c.request("shutdown", nil)
+	c.notify("exit", nil)
+	return <-c.done
+}
+
+func (c *client) open(text string) {
+	c.t.Helper()
+	c.request("initialize", map[string]interface{}{})
+	c.notify("initialized", map[string]interface{}{})
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: testURI, LanguageID: "bolt", Version: 1, Text: text},
+	})
+}
+
+func at(line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: testURI},
+		Position:     Position{Line: line, Character: character},
+	}
+}
+
+func span(line, start, end int) Range {
+	return Range{Start: Position{line, start}, End: Position{line, end}}
+}
+
+func TestLifecycle(t *testing.T) {
+	c := newClient(t)
+
+	resp := c.request("textDocument/hover", at(0, 0))
+	if resp.Error == nil || resp.Error.Code != SERVER_NOT_INITIALIZED {
+		t.Errorf("requests before initialize must fail. got=%+v", resp.Error)
+	}
+
+	var result struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	c.call("initialize", map[string]interface{}{}, &result)
+	for _, capability := range []string{"hoverProvider", "definitionProvider", "referencesProvider", "documentSymbolProvider", "completionProvider", "documentFormattingProvider"} {
+		if result.Capabilities[capability] == nil {
+			t.Errorf("capability %s is not advertised", capability)
+		}
+	}
+
+	resp = c.request("workspace/symbol", map[string]interface{}{})
+	if resp.Error == nil || resp.Error.Code != METHOD_NOT_FOUND {
+		t.Errorf("unsupported methods must fail. got=%+v", resp.Error)
+	}
+
+	if err := c.close(); err != nil {
+		t.Errorf("Serve returned error after an orderly exit: %s", err)
+	}
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	c := newClient(t)
+	c.request("initialize", map[string]interface{}{})
+	c.notify("exit", nil)
+	if err := <-c.done; err != ErrExitWithoutShutdown {
+		t.Errorf("wrong error. expected=%q, got=%v", ErrExitWithoutShutdown, err)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+	c.open("let x = 1;\nlet = 2;")
+
+	expected := []Diagnostic{{
+		Range:    span(1, 4, 5),
+		Severity: SEVERITY_ERROR,
+		Source:   "bolt",
+		Message:  "expected next token to be IDENT, got = instead",
+	}}
+	if got := c.diagnostics(); !reflect.DeepEqual(got[:1], expected) {
+		t.Errorf("wrong diagnostics.\nexpected=%+v\ngot=%+v", expected, got)
+	}
+
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": testURI, "version": 2},
+		"contentChanges": []map[string]string{{"text": "let y = 2;"}},
+	})
+	if got := c.diagnostics(); len(got) != 0 {
+		t.Errorf("diagnostics were not cleared after a fix. got=%+v", got)
+	}
+
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": testURI, "version": 3},
+		"contentChanges": []map[string]string{{"text": "let y = 2;\ny + z;"}},
+	})
+	expected = []Diagnostic{{
+		Range:    span(1, 4, 5),
+		Severity: SEVERITY_ERROR,
+		Source:   "bolt",
+		Message:  "identifier not found: z",
+	}}
+	if got := c.diagnostics(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong name diagnostics.\nexpected=%+v\ngot=%+v", expected, got)
+	}
+
+	c.close()
+}
+
+func TestNavigation(t *testing.T) {
+	c := newClient(t)
+	// line 2 rebinds x using the first binding, and line 3 refers to the second
+	c.open("let x = 1;\nlet y = x + x;\nlet x = y * 2;\nx;")
+
+	var location Location
+	c.call("textDocument/definition", at(1, 8), &location)
+	if location.Range != span(0, 4, 5) {
+		t.Errorf("wrong definition. got=%+v", location.Range)
+	}
+	c.call("textDocument/definition", at(3, 1), &location)
+	if location.Range != span(2, 4, 5) {
+		t.Errorf("wrong definition after rebinding. got=%+v", location.Range)
+	}
+
+	params := ReferenceParams{TextDocumentPositionParams: at(0, 4)}
+	params.Context.IncludeDeclaration = true
+	var locations []Location
+	c.call("textDocument/references", params, &locations)
+	expected := []Range{span(0, 4, 5), span(1, 8, 9), span(1, 12, 13)}
+	if len(locations) != len(expected) {
+		t.Fatalf("wrong number of references. expected=%d, got=%+v", len(expected), locations)
+	}
+	for i, l := range locations {
+		if l.URI != testURI || l.Range != expected[i] {
+			t.Errorf("references[%d] wrong. expected=%+v, got=%+v", i, expected[i], l)
+		}
+	}
+
+	var hover Hover
+	c.call("textDocument/hover", at(2, 8), &hover)
+	if hover.Contents.Value != "```bolt\nlet y = x + x;\n```" || hover.Range != span(2, 8, 9) {
+		t.Errorf("wrong hover. got=%+v", hover)
+	}
+
+	if resp := c.request("textDocument/hover", at(0, 8)); string(resp.Result) != "null" {
+		t.Errorf("hover on a literal must be null. got=%s", resp.Result)
+	}
+
+	var symbols []DocumentSymbol
+	c.call("textDocument/documentSymbol", DocumentParams{TextDocument: TextDocumentIdentifier{URI: testURI}}, &symbols)
+	names := []string{}
+	for _, s := range symbols {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, ",") != "x,y,x" || symbols[1].Range != span(1, 0, 5) || symbols[1].SelectionRange != span(1, 4, 5) {
+		t.Errorf("wrong symbols. got=%+v", symbols)
+	}
+
+	c.close()
+}
+
+func TestFunctions(t *testing.T) {
+	c := newClient(t)
+	c.open("let add = fn(a: int, b) {\n  let c = a + b;\n  c\n};\nadd(1, 2);")
+
+	var hover Hover
+	c.call("textDocument/hover", at(1, 10), &hover)
+	if hover.Contents.Value != "```bolt\n(parameter) a: int\n```" || hover.Range != span(1, 10, 11) {
+		t.Errorf("wrong hover on a parameter. got=%+v", hover)
+	}
+
+	var location Location
+	c.call("textDocument/definition", at(2, 2), &location)
+	if location.Range != span(1, 6, 7) {
+		t.Errorf("wrong definition of a local. got=%+v", location.Range)
+	}
+
+	var symbols []DocumentSymbol
+	c.call("textDocument/documentSymbol", DocumentParams{TextDocument: TextDocumentIdentifier{URI: testURI}}, &symbols)
+	if len(symbols) != 1 || symbols[0].Name != "add" || symbols[0].Kind != SYMBOL_FUNCTION {
+		t.Errorf("only the top-level function must be listed. got=%+v", symbols)
+	}
+
+	var items []CompletionItem
+	c.call("textDocument/completion", at(4, 0), &items)
+	kinds := map[string]int{}
+	for _, item := range items {
+		kinds[item.Label] = item.Kind
+	}
+	if kinds["add"] != COMPLETION_FUNCTION || kinds["c"] != 0 || kinds["a"] != 0 {
+		t.Errorf("wrong completion items. got=%+v", items)
+	}
+
+	c.close()
+}
+
+func TestCompletion(t *testing.T) {
+	c := newClient(t)
+	c.open("let total = 1;\nlet count = 2;\nlet total = 3;\n")
+
+	var items []CompletionItem
+	c.call("textDocument/completion", at(2, 0), &items)
+
+	labels := map[string]int{}
+	for _, item := range items {
+		labels[item.Label] = item.Kind
+	}
+	for _, keyword := range []string{"fn", "let", "if", "else", "return", "true", "false", "import", "export", "as"} {
+		if labels[keyword] != COMPLETION_KEYWORD {
+			t.Errorf("keyword %q is not offered", keyword)
+		}
+	}
+	if labels["total"] != COMPLETION_VARIABLE || labels["count"] != COMPLETION_VARIABLE {
+		t.Errorf("bound names are not offered. got=%+v", items)
+	}
+	if len(items) != len(labels) {
+		t.Errorf("duplicate completion items. got=%+v", items)
+	}
+
+	c.call("textDocument/completion", at(0, 0), &items)
+	if len(items) != 10 {
+		t.Errorf("names must not be offered before they are bound. got=%+v", items)
+	}
+
+	c.close()
+}
+
+func TestModules(t *testing.T) {
+	c := newClient(t)
+	c.open("import \"lib/math\" as m;\nexport let y = m.max;\n")
+
+	var hover Hover
+	c.call("textDocument/hover", at(1, 15), &hover)
+	if hover.Contents.Value != "```bolt\n(module) m from \"lib/math\"\n```" || hover.Range != span(1, 15, 16) {
+		t.Errorf("wrong hover on a module. got=%+v", hover)
+	}
+	c.call("textDocument/hover", at(1, 12), &hover)
+	if hover.Contents.Value != "```bolt\nexport let y = m.max;\n```" {
+		t.Errorf("wrong hover on an export. got=%+v", hover)
+	}
+	if resp := c.request("textDocument/hover", at(1, 19)); string(resp.Result) != "null" {
+		t.Errorf("hover on a member must be null. got=%s", resp.Result)
+	}
+
+	var symbols []DocumentSymbol
+	c.call("textDocument/documentSymbol", DocumentParams{TextDocument: TextDocumentIdentifier{URI: testURI}}, &symbols)
+	if len(symbols) != 2 || symbols[0].Kind != SYMBOL_MODULE || symbols[0].Range != span(0, 0, 22) || symbols[1].Range != span(1, 0, 12) {
+		t.Errorf("wrong symbols. got=%+v", symbols)
+	}
+
+	var items []CompletionItem
+	c.call("textDocument/completion", at(2, 0), &items)
+	found := false
+	for _, item := range items {
+		if item.Label == "m" {
+			found = item.Kind == COMPLETION_MODULE && item.Detail == "import \"lib/math\""
+		}
+	}
+	if !found {
+		t.Errorf("the module is not offered. got=%+v", items)
+	}
+
+	c.close()
+}
+
+func TestFormatting(t *testing.T) {
+	c := newClient(t)
+	c.open("let x=1\nx+ 2")
+
+	doc := DocumentParams{TextDocument: TextDocumentIdentifier{URI: testURI}}
+	var edits []TextEdit
+	c.call("textDocument/formatting", doc, &edits)
+	expected := []TextEdit{{Range: Range{End: Position{1, 4}}, NewText: "let x = 1;\nx + 2;\n"}}
+	if !reflect.DeepEqual(edits, expected) {
+		t.Errorf("wrong edits.\nexpected=%+v\ngot=%+v", expected, edits)
+	}
+
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": testURI},
+		"contentChanges": []map[string]string{{"text": "let x = 1;\n"}},
+	})
+	c.call("textDocument/formatting", doc, &edits)
+	if len(edits) != 0 {
+		t.Errorf("formatted documents must not be edited. got=%+v", edits)
+	}
+
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": testURI},
+		"contentChanges": []map[string]string{{"text": "let = "}},
+	})
+	if resp := c.request("textDocument/formatting", doc); resp.Error != nil || string(resp.Result) != "null" {
+		t.Errorf("documents that do not parse must not be formatted. got=%+v", resp)
+	}
+
+	c.close()
+}
+
+func TestPositions(t *testing.T) {
+	// LSP counts characters in UTF-16 code units, while the lexer counts bytes
+	d := newDocument(testURI, "let a = 1;\n😀é let b = a;", nil)
+
+	b, tok := d.bindingAt(Position{Line: 1, Character: 12})
+	if b == nil || tok.Literal != "a" {
+		t.Fatalf("no reference found after multi-byte characters")
+	}
+	if r := d.tokenRange(tok); r != span(1, 12, 13) {
+		t.Errorf("wrong range. expected=%+v, got=%+v", span(1, 12, 13), r)
+	}
+	if end := d.end(); end != (Position{Line: 1, Character: 14}) {
+		t.Errorf("wrong end of document. got=%+v", end)
+	}
+}
@@ -0,0 +1,70 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The largest message content the server accepts, so that a bad Content-Length cannot exhaust memory
+const MAX_MESSAGE_SIZE = 64 << 20
+
+// Read the content of a message framed by a Content-Length header
+//   - Headers other than Content-Length are ignored
+//   - A Content-Length above MAX_MESSAGE_SIZE is an error, and nothing is allocated for the content
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line != "" {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed header %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid Content-Length %q", value)
+			}
+			if length > MAX_MESSAGE_SIZE {
+				return nil, fmt.Errorf("message length %d exceeds the maximum of %d bytes", length, MAX_MESSAGE_SIZE)
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// Write a message as JSON framed by a Content-Length header
+func writeMessage(w io.Writer, msg *message) error {
+	content, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
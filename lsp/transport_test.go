@@ -0,0 +1,38 @@
+package lsp
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedContent string
+		expectedError   string
+	}{
+		{"Content-Length: 2\r\n\r\n{}", "{}", ""},
+		{"Content-Type: application/json\r\ncontent-length: 2\r\n\r\n{}", "{}", ""},
+		{"\r\n{}", "", "missing Content-Length header"},
+		{"Content-Length: x\r\n\r\n", "", `invalid Content-Length " x"`},
+		{"Content-Length: -1\r\n\r\n", "", `invalid Content-Length " -1"`},
+		{"Content-Length: 9999999999\r\n\r\n", "", fmt.Sprintf("message length 9999999999 exceeds the maximum of %d bytes", MAX_MESSAGE_SIZE)},
+		{"Content-Length: 4\r\n\r\n{}", "", "unexpected EOF"},
+		{"Content-Length\r\n\r\n", "", `malformed header "Content-Length"`},
+	}
+
+	for _, tt := range tests {
+		content, err := readMessage(bufio.NewReader(strings.NewReader(tt.input)))
+		if tt.expectedError != "" {
+			if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("wrong error for %q. expected=%q, got=%v", tt.input, tt.expectedError, err)
+			}
+			continue
+		}
+		if err != nil || string(content) != tt.expectedContent {
+			t.Errorf("wrong content for %q. expected=%q, got=%q (%v)", tt.input, tt.expectedContent, content, err)
+		}
+	}
+}
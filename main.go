@@ -1,22 +1,137 @@
 package main
 
 import (
-	"bolt/repl"
 	"fmt"
+	"io"
 	"os"
-	"os/user"
+	"sort"
 )
 
-// Start the Bolt REPL
-//   - Get the current user
-//   - Print a welcome message
-//   - Start the REPL
+// The version of Bolt, overridable at build time with -ldflags "-X main.version=..."
+var version = "0.1.0"
+
+// Exit codes of the bolt command
+const (
+	EXIT_SUCCESS     = 0
+	EXIT_FAILURE     = 1 // runtime errors, vet findings, and files that cannot be read or written
+	EXIT_USAGE       = 2 // unknown commands, flags or missing arguments
+	EXIT_PARSE_ERROR = 3 // source that does not parse
+	EXIT_CHECK_ERROR = 4 // source that parses but fails static checks, such as undefined names or type errors
+)
+
+// streams are the standard input, output and error of a command
+type streams struct {
+	in  io.Reader
+	out io.Writer
+	err io.Writer
+}
+
+// command runs a subcommand of bolt with its arguments, returning the process exit code
+type command struct {
+	usage       string
+	description string
+	run         func(args []string, std *streams) int
+}
+
+// The subcommands of bolt, by name
+var commands map[string]*command
+
+func init() {
+	commands = map[string]*command{
+		"run":     {"run [-path dirs] [file.bolt] [args...]", "run a Bolt program", runRun},
+		"repl":    {"repl", "start the interactive REPL", runRepl},
+		"lex":     {"lex [file.bolt]", "print the tokens of a Bolt program", runLex},
+		"parse":   {"parse [--json] [file.bolt]", "print the syntax tree of a Bolt program", runParse},
+		"fmt":     {"fmt [-l] [-w] [path ...]", "format Bolt source files", runFmt},
+		"check":   {"check [-infer] [file.bolt ...]", "report syntax, name and type errors without running", runCheck},
+		"lsp":     {"lsp", "start the language server on standard input and output", runLsp},
+		"vet":     {"vet [-config file] [-json] [-rules] [path ...]", "report suspicious code", runVet},
+		"mod":     {"mod [-C dir] <init [name] | tidy | vendor>", "manage the bolt.mod and bolt.sum of a project", runMod},
+		"version": {"version", "print the Bolt version", runVersion},
+		"help":    {"help", "show this help", runHelp},
+	}
+}
+
+// Run the bolt command line
+//   - With no arguments, start the REPL
+//   - Otherwise run the named subcommand, see `bolt help`
+//   - A path to a script in place of a subcommand runs it, so scripts can start with #!/usr/bin/env bolt
+//   - Files named "-", or omitted, are read from standard input
 func main() {
-	user, err := user.Current()
-	if err != nil {
-		panic(err)
+	std := &streams{in: os.Stdin, out: os.Stdout, err: os.Stderr}
+	os.Exit(dispatch(os.Args[1:], std))
+}
+
+// Run the subcommand named by the first argument, returning the process exit code
+func dispatch(args []string, std *streams) int {
+	if len(args) == 0 {
+		return runRepl(nil, std)
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok && isFile(args[0]) {
+		return runRun(args, std)
+	}
+	if !ok {
+		fmt.Fprintf(std.err, "bolt: unknown command %q\n", args[0])
+		printUsage(std.err)
+		return EXIT_USAGE
+	}
+	return cmd.run(args[1:], std)
+}
+
+// Print the usage of every subcommand
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: bolt <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-30s %s\n", commands[name].usage, commands[name].description)
+	}
+}
+
+func runHelp(args []string, std *streams) int {
+	printUsage(std.out)
+	return EXIT_SUCCESS
+}
+
+func runVersion(args []string, std *streams) int {
+	fmt.Fprintf(std.out, "bolt %s\n", version)
+	return EXIT_SUCCESS
+}
+
+// Read the source of a program from a file, or from standard input if the name is "-"
+func readSource(name string, std *streams) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(std.in)
+	}
+	return os.ReadFile(name)
+}
+
+// Determine whether a path names an existing file rather than a directory
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// Return the name of the file given in the arguments, or "-" for standard input if there is none
+func sourceName(args []string) string {
+	if len(args) == 0 {
+		return "-"
+	}
+	return args[0]
+}
+
+// Return a name to show for a source file in messages
+func displayName(name string) string {
+	if name == "-" {
+		return "<stdin>"
 	}
-	fmt.Printf("Hello %s! Welcome to Bolt ⚡️\n", user.Username)
-	fmt.Printf("Type a command and press Enter to execute it.\n")
-	repl.Start(os.Stdin, os.Stdout)
+	return name
 }
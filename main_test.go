@@ -0,0 +1,474 @@
+package main
+
+import (
+	"bolt/evaluator"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Run the bolt command line with the given standard input, returning the exit code and output
+func runBolt(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var out, err bytes.Buffer
+	code := dispatch(args, &streams{in: strings.NewReader(stdin), out: &out, err: &err})
+	return code, out.String(), err.String()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedCode int
+		expectedErr  string
+	}{
+		{"let x = 5; x * 2;", EXIT_SUCCESS, ""},
+		{"let x = 5 / 0;", EXIT_FAILURE, "<stdin>: ERROR: division by zero\n"},
+		{"let = 5;", EXIT_PARSE_ERROR, "<stdin>:1:5: expected next token to be IDENT, got = instead\n"},
+	}
+
+	for _, tt := range tests {
+		code, _, stderr := runBolt(t, tt.input, "run")
+		if code != tt.expectedCode {
+			t.Errorf("bolt run with %q: wrong exit code. expected=%d, got=%d", tt.input, tt.expectedCode, code)
+		}
+		if !strings.HasPrefix(stderr, tt.expectedErr) {
+			t.Errorf("bolt run with %q: wrong stderr. expected=%q, got=%q", tt.input, tt.expectedErr, stderr)
+		}
+	}
+}
+
+func TestRunFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.bolt")
+	if err := os.WriteFile(file, []byte("let x = true;\n-x;\n"), 0o644); err != nil {
+		t.Fatalf("could not write file: %s", err)
+	}
+
+	code, _, stderr := runBolt(t, "", "run", file, "extra", "args")
+	if code != EXIT_FAILURE {
+		t.Errorf("wrong exit code. expected=%d, got=%d", EXIT_FAILURE, code)
+	}
+	if stderr != file+": ERROR: unknown operator: -BOOLEAN\n" {
+		t.Errorf("wrong stderr. got=%q", stderr)
+	}
+
+	code, _, stderr = runBolt(t, "", "run", filepath.Join(dir, "missing.bolt"))
+	if code != EXIT_FAILURE || !strings.Contains(stderr, "no such file") {
+		t.Errorf("wrong result for a missing file. code=%d, stderr=%q", code, stderr)
+	}
+}
+
+func TestImports(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app/main.bolt":     "import \"./shapes\";\nimport \"geometry\" as g;\nreturn shapes.area(g.side);\n",
+		"app/shapes.bolt":   "import \"geometry\";\nexport let area = fn(side) { geometry.square(side) };\n",
+		"lib/geometry.bolt": "export let side = 3;\nexport let square = fn(x) { x * x };\n",
+		"app/cycle.bolt":    "import \"./cycle\";\n",
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatalf("could not write file: %s", err)
+		}
+	}
+	main := filepath.Join(dir, "app", "main.bolt")
+	lib := filepath.Join(dir, "lib")
+
+	if code, _, stderr := runBolt(t, "", "run", "-path", lib, main); code != 9 {
+		t.Errorf("wrong exit code with -path. expected=9, got=%d, stderr=%q", code, stderr)
+	}
+
+	t.Setenv(BOLTPATH_ENV, filepath.Join(dir, "missing")+string(filepath.ListSeparator)+lib)
+	if code, _, stderr := runBolt(t, "", main); code != 9 {
+		t.Errorf("wrong exit code with %s. expected=9, got=%d, stderr=%q", BOLTPATH_ENV, code, stderr)
+	}
+
+	t.Setenv(BOLTPATH_ENV, "")
+	code, _, stderr := runBolt(t, "", "run", main)
+	if code != EXIT_FAILURE || stderr != main+": ERROR: cannot find module \"geometry\": the search path is empty\n" {
+		t.Errorf("wrong result without a search path. code=%d, stderr=%q", code, stderr)
+	}
+
+	cycle := filepath.Join(dir, "app", "cycle.bolt")
+	code, _, stderr = runBolt(t, "", "run", cycle)
+	if code != EXIT_FAILURE || stderr != cycle+": ERROR: import cycle: "+cycle+" -> "+cycle+"\n" {
+		t.Errorf("wrong result for an import cycle. code=%d, stderr=%q", code, stderr)
+	}
+}
+
+func TestMod(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app/main.bolt":        "import \"geometry/shapes\";\nreturn shapes.area(3);\n",
+		"geometry/shapes.bolt": "export let area = fn(x) { x * x };\n",
+		"unused/unused.bolt":   "",
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatalf("could not write file: %s", err)
+		}
+	}
+	app := filepath.Join(dir, "app")
+	main := filepath.Join(app, "main.bolt")
+
+	code, stdout, _ := runBolt(t, "", "mod", "-C", app, "init")
+	if code != EXIT_SUCCESS || stdout != "created "+filepath.Join(app, "bolt.mod")+" for app\n" {
+		t.Fatalf("wrong result of mod init. code=%d, stdout=%q", code, stdout)
+	}
+	manifest := "module app\n\nbolt 0.1\n\nrequire geometry ../geometry\nrequire unused ../unused\n"
+	if err := os.WriteFile(filepath.Join(app, "bolt.mod"), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, stderr := runBolt(t, "", "run", main); code != EXIT_FAILURE || stderr != "bolt: missing bolt.sum entry for geometry; run bolt mod tidy\n" {
+		t.Errorf("wrong result of running an untidy project. code=%d, stderr=%q", code, stderr)
+	}
+
+	code, stdout, _ = runBolt(t, "", "mod", "-C", app, "tidy")
+	if code != EXIT_SUCCESS || stdout != "removed unused dependency unused\n" {
+		t.Errorf("wrong result of mod tidy. code=%d, stdout=%q", code, stdout)
+	}
+	if code, _, stderr := runBolt(t, "", "run", main); code != 9 {
+		t.Errorf("wrong exit code of the project. expected=9, got=%d, stderr=%q", code, stderr)
+	}
+
+	code, stdout, _ = runBolt(t, "", "mod", "-C", app, "vendor")
+	if code != EXIT_SUCCESS || stdout != "vendored geometry into vendor/geometry.zip\n" {
+		t.Errorf("wrong result of mod vendor. code=%d, stdout=%q", code, stdout)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "geometry")); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, stderr := runBolt(t, "", "run", main); code != 9 {
+		t.Errorf("wrong exit code of the vendored project. expected=9, got=%d, stderr=%q", code, stderr)
+	}
+
+	if code, _, stderr := runBolt(t, "", "mod", "-C", dir, "tidy"); code != EXIT_FAILURE || stderr != "bolt: no bolt.mod found in "+dir+" or any parent directory\n" {
+		t.Errorf("wrong result outside of a project. code=%d, stderr=%q", code, stderr)
+	}
+	if code, _, _ := runBolt(t, "", "mod", "get"); code != EXIT_USAGE {
+		t.Errorf("wrong exit code of an unknown subcommand. expected=%d, got=%d", EXIT_USAGE, code)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.bolt")
+	bad := filepath.Join(dir, "bad.bolt")
+	os.WriteFile(good, []byte("let x = 1;"), 0o644)
+	os.WriteFile(bad, []byte("let x = 1;\nlet y = ;"), 0o644)
+
+	code, _, stderr := runBolt(t, "", "check", good, bad)
+	if code != EXIT_PARSE_ERROR {
+		t.Errorf("wrong exit code. expected=%d, got=%d", EXIT_PARSE_ERROR, code)
+	}
+	if stderr != bad+":2:9: no prefix parse function for ; found\n" {
+		t.Errorf("wrong stderr. got=%q", stderr)
+	}
+
+	if code, _, _ := runBolt(t, "5 / 0", "check"); code != EXIT_SUCCESS {
+		t.Errorf("check must not run the program. got exit code %d", code)
+	}
+}
+
+func TestLexAndParse(t *testing.T) {
+	_, stdout, _ := runBolt(t, "let x = 1;", "lex")
+	expected := "1:1\tLET\t\"let\"\n1:5\tIDENT\t\"x\"\n1:7\t=\t\"=\"\n1:9\tINT\t\"1\"\n1:10\t;\t\";\"\n"
+	if stdout != expected {
+		t.Errorf("wrong lex output.\nexpected=%q\ngot=%q", expected, stdout)
+	}
+
+	_, stdout, _ = runBolt(t, "1 + 2 * 3", "parse")
+	if stdout != "(1 + (2 * 3))\n" {
+		t.Errorf("wrong parse output. got=%q", stdout)
+	}
+
+	_, stdout, _ = runBolt(t, "x", "parse", "--json", "-")
+	if !strings.HasPrefix(stdout, `{"kind":"Program"`) {
+		t.Errorf("wrong parse --json output. got=%q", stdout)
+	}
+}
+
+func TestFmt(t *testing.T) {
+	_, stdout, _ := runBolt(t, "let x=(1+2)", "fmt")
+	if stdout != "let x = 1 + 2;\n" {
+		t.Errorf("wrong fmt output. got=%q", stdout)
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.bolt")
+	os.WriteFile(file, []byte("a+b"), 0o644)
+
+	_, stdout, _ = runBolt(t, "", "fmt", "-l", dir)
+	if stdout != file+"\n" {
+		t.Errorf("wrong fmt -l output. got=%q", stdout)
+	}
+
+	runBolt(t, "", "fmt", "-w", dir)
+	if data, _ := os.ReadFile(file); string(data) != "a + b;\n" {
+		t.Errorf("fmt -w did not rewrite the file. got=%q", data)
+	}
+
+	if code, _, _ := runBolt(t, "let = ", "fmt"); code != EXIT_PARSE_ERROR {
+		t.Errorf("wrong exit code. expected=%d, got=%d", EXIT_PARSE_ERROR, code)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	code, _, stderr := runBolt(t, "", "frobnicate")
+	if code != EXIT_USAGE || !strings.Contains(stderr, "unknown command") {
+		t.Errorf("wrong result for an unknown command. code=%d, stderr=%q", code, stderr)
+	}
+
+	code, stdout, _ := runBolt(t, "", "version")
+	if code != EXIT_SUCCESS || stdout != "bolt "+version+"\n" {
+		t.Errorf("wrong version output. code=%d, stdout=%q", code, stdout)
+	}
+
+	code, stdout, _ = runBolt(t, "1 + 1\n", "repl")
+	if code != EXIT_SUCCESS || !strings.Contains(stdout, "2\n") {
+		t.Errorf("wrong repl output. code=%d, stdout=%q", code, stdout)
+	}
+}
+
+func TestScript(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "script")
+	src := "#!/usr/bin/env bolt\nlet n = 2;\nreturn n * 3;\nreturn 1;\n"
+	if err := os.WriteFile(script, []byte(src), 0o755); err != nil {
+		t.Fatalf("could not write file: %s", err)
+	}
+
+	if code, _, stderr := runBolt(t, "", script, "a", "-b"); code != 6 {
+		t.Errorf("wrong exit code. expected=6, got=%d, stderr=%q", code, stderr)
+	}
+	if code, _, stderr := runBolt(t, "", "run", script); code != 6 {
+		t.Errorf("wrong exit code. expected=6, got=%d, stderr=%q", code, stderr)
+	}
+	if code, _, _ := runBolt(t, "", dir); code != EXIT_USAGE {
+		t.Errorf("a directory must not be run as a script. got exit code %d", code)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedCode int
+	}{
+		{"return 0;", EXIT_SUCCESS},
+		{"return 42;", 42},
+		{"return;", EXIT_SUCCESS},
+		{"return true;", EXIT_SUCCESS},
+		{"return 256;", EXIT_FAILURE},
+		{"return -1;", EXIT_FAILURE},
+		{"let x = 7; x;", EXIT_SUCCESS},
+	}
+
+	for _, tt := range tests {
+		if code, _, _ := runBolt(t, tt.input, "run"); code != tt.expectedCode {
+			t.Errorf("bolt run with %q: wrong exit code. expected=%d, got=%d", tt.input, tt.expectedCode, code)
+		}
+	}
+}
+
+func TestCallDepthLimit(t *testing.T) {
+	expected := fmt.Sprintf("<stdin>: ERROR: call depth limit of %d exceeded\n", evaluator.DEFAULT_MAX_DEPTH)
+	code, _, stderr := runBolt(t, "let f = fn() { f() }; f()", "run")
+	if code != EXIT_FAILURE || stderr != expected {
+		t.Errorf("wrong result of runaway recursion. code=%d, stderr=%q", code, stderr)
+	}
+
+	dir := t.TempDir()
+	src := "export let f = fn() { f() };\n"
+	if err := os.WriteFile(filepath.Join(dir, "loop.bolt"), []byte(src), 0o644); err != nil {
+		t.Fatalf("could not write file: %s", err)
+	}
+	code, _, stderr = runBolt(t, `import "loop"; loop.f()`, "run", "-path", dir)
+	if code != EXIT_FAILURE || stderr != expected {
+		t.Errorf("wrong result of runaway recursion in a module. code=%d, stderr=%q", code, stderr)
+	}
+}
+
+func TestScriptEnvironment(t *testing.T) {
+	env := scriptEnvironment("tool.bolt", []string{"x", "--verbose"}, []string{"HOME=/home/bolt", "EMPTY=", "BROKEN"}, io.Discard)
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"SCRIPT", `"tool.bolt"`},
+		{"ARGS", `["x", "--verbose"]`},
+		{"ENV", `{"EMPTY": "", "HOME": "/home/bolt"}`},
+	}
+
+	for _, tt := range tests {
+		obj, ok := env.Get(tt.name)
+		if !ok {
+			t.Fatalf("%s is not bound", tt.name)
+		}
+		if obj.Inspect() != tt.expected {
+			t.Errorf("%s has wrong value. expected=%s, got=%s", tt.name, tt.expected, obj.Inspect())
+		}
+	}
+}
+
+func TestScriptBuiltins(t *testing.T) {
+	t.Setenv("BOLT_TEST_HOME", "/home/bolt")
+	tests := []struct {
+		input          string
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string
+	}{
+		{`puts(SCRIPT, len(ARGS), get(ARGS, 0), get(ARGS, 1));`, EXIT_SUCCESS, "- 2 x --verbose\n", ""},
+		{`puts(get(ENV, "BOLT_TEST_HOME"), get(ENV, "BOLT_TEST_MISSING"));`, EXIT_SUCCESS, "/home/bolt null\n", ""},
+		{`puts(); puts(1 + 2, true, "a b", ARGS);`, EXIT_SUCCESS, "\n3 true a b [\"x\", \"--verbose\"]\n", ""},
+		{`return len(get(ARGS, 1));`, 9, "", ""},
+		{`puts(get(ARGS, 2));`, EXIT_SUCCESS, "null\n", ""},
+		{`len(1);`, EXIT_FAILURE, "", "<stdin>: ERROR: len: unsupported argument of type INTEGER\n"},
+		{`get(ARGS, "x");`, EXIT_FAILURE, "", "<stdin>: ERROR: get: cannot index ARRAY with STRING\n"},
+		{`len(ARGS, ENV);`, EXIT_FAILURE, "", "<stdin>: ERROR: wrong number of arguments to len: want=1, got=2\n"},
+	}
+
+	for _, tt := range tests {
+		code, stdout, stderr := runBolt(t, tt.input, "run", "-", "x", "--verbose")
+		if code != tt.expectedCode || stdout != tt.expectedStdout || stderr != tt.expectedStderr {
+			t.Errorf("bolt run with %q: wrong result.\nexpected code=%d, stdout=%q, stderr=%q\ngot code=%d, stdout=%q, stderr=%q",
+				tt.input, tt.expectedCode, tt.expectedStdout, tt.expectedStderr, code, stdout, stderr)
+		}
+	}
+}
+
+func TestLsp(t *testing.T) {
+	var stdin strings.Builder
+	for _, msg := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	} {
+		fmt.Fprintf(&stdin, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+
+	code, stdout, stderr := runBolt(t, stdin.String(), "lsp")
+	if code != EXIT_SUCCESS {
+		t.Fatalf("wrong exit code. expected=%d, got=%d, stderr=%q", EXIT_SUCCESS, code, stderr)
+	}
+	if !strings.Contains(stdout, `"serverInfo":{"name":"bolt","version":"`+version+`"}`) {
+		t.Errorf("wrong initialize response. got=%q", stdout)
+	}
+
+	if code, _, _ := runBolt(t, "", "lsp"); code != EXIT_FAILURE {
+		t.Errorf("closing the input without shutdown must fail. got exit code %d", code)
+	}
+}
+
+func TestVet(t *testing.T) {
+	code, stdout, _ := runBolt(t, "let x = 1;\nlet y = 2; // bolt:ignore\nx == x;\n", "vet")
+	if code != EXIT_FAILURE || stdout != "<stdin>:3:3: comparison of x with itself is always true (self-comparison)\n" {
+		t.Errorf("wrong vet result. code=%d, stdout=%q", code, stdout)
+	}
+
+	if code, stdout, _ := runBolt(t, "let x = 1; x;", "vet"); code != EXIT_SUCCESS || stdout != "" {
+		t.Errorf("clean source must pass. code=%d, stdout=%q", code, stdout)
+	}
+	if code, _, _ := runBolt(t, "let = 1;", "vet"); code != EXIT_PARSE_ERROR {
+		t.Errorf("wrong exit code. expected=%d, got=%d", EXIT_PARSE_ERROR, code)
+	}
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, "vet.json")
+	os.WriteFile(config, []byte(`{"rules": {"self-comparison": false}}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "a.bolt"), []byte("let a = 1;\na == a;\n"), 0o644)
+
+	code, stdout, _ = runBolt(t, "", "vet", "-json", "-config", config, dir)
+	if code != EXIT_SUCCESS {
+		t.Errorf("wrong exit code. expected=%d, got=%d", EXIT_SUCCESS, code)
+	}
+	var findings []map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &findings); err != nil {
+		t.Fatalf("vet -json printed invalid JSON %q: %s", stdout, err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("disabled rules must not report. got=%v", findings)
+	}
+
+	code, stdout, _ = runBolt(t, "return;\n1;", "vet", "-json")
+	expected := `[{"file":"<stdin>","rule":"unreachable-code","line":2,"column":1,"message":"unreachable code"}]`
+	if compact := compactJSON(t, stdout); code != EXIT_FAILURE || compact != expected {
+		t.Errorf("wrong vet -json output.\nexpected=%s\ngot=%s", expected, compact)
+	}
+
+	if code, _, stderr := runBolt(t, "", "vet", "-config", filepath.Join(dir, "a.bolt")); code != EXIT_USAGE {
+		t.Errorf("an invalid config must be rejected. code=%d, stderr=%q", code, stderr)
+	}
+}
+
+func compactJSON(t *testing.T, s string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := json.Compact(&out, []byte(s)); err != nil {
+		t.Fatalf("invalid JSON %q: %s", s, err)
+	}
+	return out.String()
+}
+
+func TestStaticChecks(t *testing.T) {
+	tests := []struct {
+		command      string
+		input        string
+		expectedCode int
+		expectedErr  string
+	}{
+		{"run", "return 5; y;", EXIT_CHECK_ERROR, "<stdin>:1:11: identifier not found: y\n"},
+		{"run", "let n = ARGS; return 0;", EXIT_SUCCESS, ""},
+		{"check", "x;\nlet x = 1;", EXIT_CHECK_ERROR, "<stdin>:1:1: x used before its declaration at 2:5\n"},
+		{"check", "let x = 1;\nlet x = 2;", EXIT_CHECK_ERROR, "<stdin>:2:5: x redeclared in this scope, previous declaration at 1:5\n"},
+		{"check", "let SCRIPT = ENV; SCRIPT;", EXIT_SUCCESS, ""},
+		{"check", "let x: int = 1;\nx + true;", EXIT_CHECK_ERROR, "<stdin>:2:3: type mismatch: int + bool\n"},
+		{"check", "let s: bool = SCRIPT;", EXIT_CHECK_ERROR, "<stdin>:1:15: cannot use string value as bool in let s\n"},
+		{"check", "let s: string = SCRIPT + SCRIPT; ENV + 1;", EXIT_SUCCESS, ""},
+		{"run", "let x: bool = 1; x;", EXIT_SUCCESS, ""},
+	}
+
+	for _, tt := range tests {
+		code, _, stderr := runBolt(t, tt.input, tt.command)
+		if code != tt.expectedCode || stderr != tt.expectedErr {
+			t.Errorf("bolt %s with %q: wrong result. expected=%d %q, got=%d %q",
+				tt.command, tt.input, tt.expectedCode, tt.expectedErr, code, stderr)
+		}
+	}
+}
+
+func TestInfer(t *testing.T) {
+	input := "let id = fn(x) { x };\nlet inc = fn(n) { n + 1 };\nlet name = id(SCRIPT);\n"
+	code, stdout, stderr := runBolt(t, input, "check", "-infer")
+	expected := "<stdin>:1:5: id: forall a. fn(a) -> a\n" +
+		"<stdin>:2:5: inc: fn(int) -> int\n" +
+		"<stdin>:3:5: name: string\n"
+	if code != EXIT_SUCCESS || stdout != expected || stderr != "" {
+		t.Errorf("wrong result. expected=%q, got=%d %q %q", expected, code, stdout, stderr)
+	}
+
+	code, stdout, stderr = runBolt(t, "let inc = fn(n) { n + 1 };\ninc(true);", "check", "-infer")
+	if code != EXIT_CHECK_ERROR || stdout != "<stdin>:1:5: inc: fn(int) -> int\n" ||
+		stderr != "<stdin>:2:1: cannot unify bool with int in argument 1 of inc(true)\n" {
+		t.Errorf("wrong result for a unification failure. got=%d %q %q", code, stdout, stderr)
+	}
+
+	// without -infer, unannotated parameters are not checked
+	if code, _, _ := runBolt(t, "let inc = fn(n) { n + 1 };\ninc(true);", "check"); code != EXIT_SUCCESS {
+		t.Errorf("bolt check without -infer must accept unannotated code. got=%d", code)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bolt/project"
+	"flag"
+	"fmt"
+	"path/filepath"
+)
+
+// The subcommands of bolt mod, by name
+var modCommands = map[string]func(dir string, args []string, std *streams) int{
+	"init":   runModInit,
+	"tidy":   runModTidy,
+	"vendor": runModVendor,
+}
+
+// Manage the bolt.mod manifest and bolt.sum lockfile of a project, working offline on local paths only
+//   - init [name]: create a bolt.mod in the directory, naming the project after it by default
+//   - tidy: remove the dependencies that are not imported, and record the hashes of the others in bolt.sum
+//   - vendor: copy the dependencies into the vendor directory of the project as .zip archives
+//   - -C: the directory to run in, rather than the current directory; tidy and vendor use the project holding it
+func runMod(args []string, std *streams) int {
+	flags := flag.NewFlagSet("mod", flag.ContinueOnError)
+	flags.SetOutput(std.err)
+	dir := flags.String("C", ".", "run in this directory")
+	if err := flags.Parse(args); err != nil {
+		return EXIT_USAGE
+	}
+
+	var run func(dir string, args []string, std *streams) int
+	if flags.NArg() != 0 {
+		run = modCommands[flags.Arg(0)]
+	}
+	if run == nil {
+		fmt.Fprintln(std.err, "usage: bolt mod [-C dir] <init [name] | tidy | vendor>")
+		return EXIT_USAGE
+	}
+	return run(*dir, flags.Args()[1:], std)
+}
+
+func runModInit(dir string, args []string, std *streams) int {
+	if len(args) > 1 {
+		fmt.Fprintln(std.err, "usage: bolt mod init [name]")
+		return EXIT_USAGE
+	}
+
+	var name string
+	if len(args) == 1 {
+		name = args[0]
+	} else {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			fmt.Fprintf(std.err, "bolt: %s\n", err)
+			return EXIT_FAILURE
+		}
+		name = filepath.Base(abs)
+	}
+
+	if _, err := project.Init(dir, name); err != nil {
+		fmt.Fprintf(std.err, "bolt: %s\n", err)
+		return EXIT_FAILURE
+	}
+	fmt.Fprintf(std.out, "created %s for %s\n", filepath.Join(dir, project.MANIFEST_FILE), name)
+	return EXIT_SUCCESS
+}
+
+func runModTidy(dir string, args []string, std *streams) int {
+	p, code := findProject(dir, args, "tidy", std)
+	if p == nil {
+		return code
+	}
+	removed, err := p.Tidy()
+	if err != nil {
+		fmt.Fprintf(std.err, "bolt: %s\n", err)
+		return EXIT_FAILURE
+	}
+	for _, name := range removed {
+		fmt.Fprintf(std.out, "removed unused dependency %s\n", name)
+	}
+	return EXIT_SUCCESS
+}
+
+func runModVendor(dir string, args []string, std *streams) int {
+	p, code := findProject(dir, args, "vendor", std)
+	if p == nil {
+		return code
+	}
+	vendored, err := p.Vendor()
+	if err != nil {
+		fmt.Fprintf(std.err, "bolt: %s\n", err)
+		return EXIT_FAILURE
+	}
+	for _, name := range vendored {
+		fmt.Fprintf(std.out, "vendored %s into %s\n", name, p.Manifest.Lookup(name).Path)
+	}
+	return EXIT_SUCCESS
+}
+
+// Find the project holding a directory for a subcommand of bolt mod taking no arguments
+//   - Return nil if there is none, or the arguments are wrong, along with the exit code to use
+func findProject(dir string, args []string, command string, std *streams) (*project.Project, int) {
+	if len(args) != 0 {
+		fmt.Fprintf(std.err, "usage: bolt mod %s\n", command)
+		return nil, EXIT_USAGE
+	}
+	p, err := project.Find(dir)
+	if err != nil {
+		fmt.Fprintf(std.err, "bolt: %s\n", err)
+		return nil, EXIT_FAILURE
+	}
+	if p == nil {
+		fmt.Fprintf(std.err, "bolt: no %s found in %s or any parent directory\n", project.MANIFEST_FILE, dir)
+		return nil, EXIT_FAILURE
+	}
+	return p, EXIT_SUCCESS
+}
@@ -0,0 +1,259 @@
+package module
+
+import (
+	"bolt/ast"
+	"bolt/evaluator"
+	"bolt/lexer"
+	"bolt/object"
+	"bolt/parser"
+	"bolt/resolver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+)
+
+// The extension of Bolt source files, appended to import paths that do not end with it
+const EXTENSION = ".bolt"
+
+// NotFoundError fails an import whose path does not name a module file
+//   - Path: the path as written in the import statement
+//   - Searched: the files looked for, in order
+type NotFoundError struct {
+	Path     string
+	Searched []string
+}
+
+func (e *NotFoundError) Error() string {
+	if len(e.Searched) == 0 {
+		return fmt.Sprintf("cannot find module %q: the search path is empty", e.Path)
+	}
+	return fmt.Sprintf("cannot find module %q (searched %s)", e.Path, strings.Join(e.Searched, ", "))
+}
+
+// CycleError fails an import of a module that is still being loaded, because it imports itself
+// directly or through other modules
+//   - Chain: the files of the modules importing each other, starting and ending with the same one
+type CycleError struct {
+	Chain []string
+}
+
+func (e *CycleError) Error() string {
+	return "import cycle: " + strings.Join(e.Chain, " -> ")
+}
+
+// ModuleError fails an import of a module whose file does not parse, whose names do not resolve or
+// whose evaluation failed, such as by a runtime error, an exceeded limit or an error of a host function
+//   - Path: the file of the module that failed
+//   - Err: the error it failed with, which can be detected with errors.As; a *parser.ParseError or
+//     *resolver.Error gives its position in the file
+type ModuleError struct {
+	Path string
+	Err  error
+}
+
+func (e *ModuleError) Error() string {
+	switch e.Err.(type) {
+	case *parser.ParseError, *resolver.Error:
+		return e.Path + ":" + e.Err.Error()
+	}
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *ModuleError) Unwrap() error {
+	return e.Err
+}
+
+// Loader loads the .bolt files named by import statements as modules, evaluating each file at most once
+//   - FS: the filesystem the files are read from, such as an embed.FS or an fstest.MapFS, whose paths
+//     are slash-separated and relative to its root; if nil, files are read from the operating system
+//   - SearchPath: the directories searched, in order, for import paths that do not start with
+//     "./" or "../" and are not absolute
+//   - Environment: creates the top-level environment each module is evaluated in, such as one binding
+//     builtins or limiting evaluation; if nil, each module is evaluated in a new, empty environment
+//   - modules: the modules loaded, by file
+//   - loading: the files of the modules being loaded, outermost first
+//
+// A Loader is not safe for concurrent use.
+type Loader struct {
+	FS          fs.FS
+	SearchPath  []string
+	Environment func() *object.Environment
+
+	modules map[string]*object.Module
+	loading []string
+}
+
+// Create, initialize and return a new Loader reading files from the operating system and
+// searching the directories of searchPath
+func NewLoader(searchPath ...string) *Loader {
+	return &Loader{SearchPath: searchPath, modules: map[string]*object.Module{}}
+}
+
+// Create, initialize and return a new Loader reading files from fsys and searching the directories
+// of searchPath in it
+func NewFSLoader(fsys fs.FS, searchPath ...string) *Loader {
+	return &Loader{FS: fsys, SearchPath: searchPath, modules: map[string]*object.Module{}}
+}
+
+// Return an importer for the program in a file in dir, resolving relative import paths against dir
+func (l *Loader) Importer(dir string) object.Importer {
+	return &importer{loader: l, dir: dir}
+}
+
+// importer imports modules for the programs in one directory
+type importer struct {
+	loader *Loader
+	dir    string
+}
+
+func (i *importer) Import(path string) (*object.Module, error) {
+	return i.loader.load(i.dir, path)
+}
+
+// Return the module at an import path, loading it unless it was loaded before
+//   - Paths starting with "./" or "../" are relative to dir, and absolute paths are used as they are
+//     unless the files are read from FS, which has none; other paths are looked for in each directory
+//     of the search path in turn
+//   - ".bolt" is appended to paths that do not end with it
+//   - Return a *NotFoundError if there is no such file, a *CycleError if the module is being loaded,
+//     and an error giving the position in the file if it does not parse, resolve or evaluate
+func (l *Loader) load(dir, path string) (*object.Module, error) {
+	file, err := l.find(dir, path)
+	if err != nil {
+		return nil, err
+	}
+	if module, ok := l.modules[file]; ok {
+		return module, nil
+	}
+	for i, loading := range l.loading {
+		if loading == file {
+			chain := append(append([]string{}, l.loading[i:]...), file)
+			return nil, &CycleError{Chain: chain}
+		}
+	}
+
+	l.loading = append(l.loading, file)
+	defer func() { l.loading = l.loading[:len(l.loading)-1] }()
+
+	module, err := l.evaluate(file)
+	if err != nil {
+		return nil, err
+	}
+	if l.modules == nil {
+		l.modules = map[string]*object.Module{}
+	}
+	l.modules[file] = module
+	return module, nil
+}
+
+// Return the file of the module at an import path, see load
+func (l *Loader) find(dir, path string) (string, error) {
+	var candidates []string
+	switch {
+	case strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../"):
+		candidates = []string{l.join(dir, path)}
+	case l.FS != nil && pathpkg.IsAbs(path):
+		// not a valid path of FS, so only reported as not found
+		candidates = []string{path}
+	case l.FS == nil && filepath.IsAbs(path):
+		candidates = []string{filepath.Clean(path)}
+	default:
+		for _, d := range l.SearchPath {
+			candidates = append(candidates, l.join(d, path))
+		}
+	}
+
+	for i, candidate := range candidates {
+		if filepath.Ext(candidate) != EXTENSION {
+			candidate += EXTENSION
+			candidates[i] = candidate
+		}
+		if info, err := l.stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", &NotFoundError{Path: path, Searched: candidates}
+}
+
+// Join a directory and a slash-separated path, as a path of FS or of the operating system
+func (l *Loader) join(dir, name string) string {
+	if l.FS != nil {
+		return pathpkg.Join(dir, name)
+	}
+	return filepath.Join(dir, filepath.FromSlash(name))
+}
+
+// Return the directory of a file, as a path of FS or of the operating system
+func (l *Loader) dir(file string) string {
+	if l.FS != nil {
+		return pathpkg.Dir(file)
+	}
+	return filepath.Dir(file)
+}
+
+// Describe a file of FS or of the operating system
+func (l *Loader) stat(file string) (fs.FileInfo, error) {
+	if l.FS != nil {
+		return fs.Stat(l.FS, file)
+	}
+	return os.Stat(file)
+}
+
+// Read a file of FS or of the operating system
+func (l *Loader) readFile(file string) ([]byte, error) {
+	if l.FS != nil {
+		return fs.ReadFile(l.FS, file)
+	}
+	return os.ReadFile(file)
+}
+
+// Parse, resolve and evaluate the file of a module, and collect the values of its exported let statements
+//   - Its own errors are returned as a *ModuleError giving the file
+//   - An error of a module it imports in turn is returned as it is, so that its position is that of
+//     the module it occurred in
+func (l *Loader) evaluate(file string) (*object.Module, error) {
+	src, err := l.readFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if errs := p.ParseErrors(); len(errs) != 0 {
+		return nil, &ModuleError{Path: file, Err: errs[0]}
+	}
+
+	env := object.NewEnvironment()
+	if l.Environment != nil {
+		env = l.Environment()
+	}
+	env.SetImporter(l.Importer(l.dir(file)))
+
+	info := resolver.Resolve(program, resolver.Predeclared(env.Names()...))
+	if len(info.Errors) != 0 {
+		return nil, &ModuleError{Path: file, Err: info.Errors[0]}
+	}
+
+	if err, ok := evaluator.Eval(program, env).(*object.Error); ok {
+		switch err.Err.(type) {
+		case *ModuleError, *NotFoundError, *CycleError:
+			return nil, err.Err
+		case nil:
+			return nil, &ModuleError{Path: file, Err: errors.New(err.Message)}
+		}
+		return nil, &ModuleError{Path: file, Err: err.Err}
+	}
+
+	name := strings.TrimSuffix(pathpkg.Base(filepath.ToSlash(file)), EXTENSION)
+	module := &object.Module{Name: name, Path: file, Exports: map[string]object.Object{}}
+	for _, stmt := range program.Statements {
+		if let, ok := stmt.(*ast.LetStatement); ok && let.Exported() && let.Name != nil {
+			module.Exports[let.Name.Value], _ = env.Get(let.Name.Value)
+		}
+	}
+	return module, nil
+}
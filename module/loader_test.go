@@ -0,0 +1,189 @@
+package module
+
+import (
+	"bolt/evaluator"
+	"bolt/lexer"
+	"bolt/object"
+	"bolt/parser"
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// Write files under a new temporary directory, creating their directories, and return it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// Evaluate a program in dir with the importer of a loader
+func run(loader *Loader, dir, src string) object.Object {
+	env := object.NewEnvironment()
+	env.SetImporter(loader.Importer(dir))
+	return evaluator.Eval(parser.New(lexer.New(src)).ParseProgram(), env)
+}
+
+func TestImport(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"app/main.bolt":    "",
+		"app/util.bolt":    `import "../lib/math"; export let twice = fn(x) { math.double(x) };`,
+		"lib/math.bolt":    "let two = 2; export let double = fn(x) { x * two };",
+		"vendor/text.bolt": `export let hello = "hello";`,
+	})
+	loader := NewLoader(filepath.Join(root, "lib"), filepath.Join(root, "vendor"))
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "./util"; util.twice(21)`, "42"},
+		{`import "./util.bolt" as u; u.twice(2)`, "4"},
+		{`import "math"; math.double(5)`, "10"},
+		{`import "text"; text.hello`, `"hello"`},
+		{`import "math"; math.two`, "ERROR: two is not exported by module math"},
+	}
+
+	for _, tt := range tests {
+		evaluated := run(loader, filepath.Join(root, "app"), tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestImportOnce(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"counter.bolt": "export let calls = count();",
+		"a.bolt":       `import "./counter"; export let calls = counter.calls;`,
+	})
+	calls := int64(0)
+	loader := NewLoader(root)
+	loader.Environment = func() *object.Environment {
+		env := object.NewEnvironment()
+		env.Set("count", &object.Builtin{Name: "count", Fn: func(args ...object.Object) object.Object {
+			calls++
+			return &object.Integer{Value: calls}
+		}})
+		return env
+	}
+
+	evaluated := run(loader, root, `import "counter"; import "a"; import "./counter.bolt" as c; counter.calls + a.calls + c.calls`)
+	if evaluated.Inspect() != "3" || calls != 1 {
+		t.Errorf("expected the module to be evaluated once. got=%s after %d calls", evaluated.Inspect(), calls)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"a.bolt":      `import "./b"; export let x = 1;`,
+		"b.bolt":      `import "./c";`,
+		"c.bolt":      `import "./a";`,
+		"self.bolt":   `import "./self";`,
+		"syntax.bolt": "let = 1;",
+		"names.bolt":  "export let x = y;",
+		"fails.bolt":  "let x = 1 + true;",
+		"lib.bolt":    `import "./fails";`,
+	})
+	join := func(name string) string { return filepath.Join(root, name) }
+
+	tests := []struct {
+		input    string
+		expected string
+		cycle    bool
+	}{
+		{`import "./a";`, "import cycle: " + join("a.bolt") + " -> " + join("b.bolt") + " -> " + join("c.bolt") + " -> " + join("a.bolt"), true},
+		{`import "./self";`, "import cycle: " + join("self.bolt") + " -> " + join("self.bolt"), true},
+		{`import "./syntax";`, join("syntax.bolt") + ":1:5: expected next token to be IDENT, got = instead", false},
+		{`import "./names";`, join("names.bolt") + ":1:16: identifier not found: y", false},
+		{`import "./lib";`, join("fails.bolt") + ": type mismatch: INTEGER + BOOLEAN", false},
+		{`import "./missing";`, `cannot find module "./missing" (searched ` + join("missing.bolt") + ")", false},
+		{`import "missing";`, `cannot find module "missing": the search path is empty`, false},
+	}
+
+	for _, tt := range tests {
+		evaluated, ok := run(NewLoader(), root, tt.input).(*object.Error)
+		if !ok || evaluated.Message != tt.expected {
+			t.Errorf("%q: wrong error. expected=%s, got=%v", tt.input, tt.expected, evaluated)
+			continue
+		}
+		var cycle *CycleError
+		if errors.As(evaluated.Err, &cycle) != tt.cycle {
+			t.Errorf("%q: wrong type of error. got=%T", tt.input, evaluated.Err)
+		}
+	}
+}
+
+func TestModuleError(t *testing.T) {
+	errBoom := errors.New("boom")
+	root := writeFiles(t, map[string]string{
+		"lib/host.bolt": "fail();",
+		"app.bolt":      `import "./lib/host";`,
+	})
+	loader := NewLoader()
+	loader.Environment = func() *object.Environment {
+		env := object.NewEnvironment()
+		env.Set("fail", &object.Builtin{Name: "fail", Fn: func(args ...object.Object) object.Object {
+			return &object.Error{Message: errBoom.Error(), Err: errBoom}
+		}})
+		return env
+	}
+
+	evaluated, ok := run(loader, root, `import "./app";`).(*object.Error)
+	host := filepath.Join(root, "lib", "host.bolt")
+	if !ok || evaluated.Message != host+": boom" {
+		t.Fatalf("wrong error. expected=%s: boom, got=%v", host, evaluated)
+	}
+	var moduleErr *ModuleError
+	if !errors.As(evaluated.Err, &moduleErr) || moduleErr.Path != host || !errors.Is(evaluated.Err, errBoom) {
+		t.Errorf("wrong error. got=%#v", evaluated.Err)
+	}
+}
+
+//go:embed testdata
+var testdata embed.FS
+
+func TestFS(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"app/main.bolt":     {Data: []byte(`import "../lib/geometry"; export let side = geometry.side;`)},
+		"lib/geometry.bolt": {Data: []byte("export let side = 5;")},
+		"lib/dir.bolt":      {Mode: fs.ModeDir},
+	}
+	sub, err := fs.Sub(testdata, "testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		fsys     fs.FS
+		input    string
+		expected string
+	}{
+		{mapFS, `import "./app/main"; main.side`, "5"},
+		{mapFS, `import "geometry"; geometry.side`, "5"},
+		{mapFS, `import "dir";`, `ERROR: cannot find module "dir" (searched lib/dir.bolt)`},
+		{mapFS, `import "../outside";`, `ERROR: cannot find module "../outside" (searched ../outside.bolt)`},
+		{mapFS, `import "/lib/geometry";`, `ERROR: cannot find module "/lib/geometry" (searched /lib/geometry.bolt)`},
+		{sub, `import "./app/shapes"; import "geometry"; shapes.area(geometry.side, 2)`, "14"},
+	}
+
+	for _, tt := range tests {
+		evaluated := run(NewFSLoader(tt.fsys, "lib"), ".", tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
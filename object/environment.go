@@ -0,0 +1,78 @@
+package object
+
+import "sort"
+
+// Environment stores the values bound to identifiers by let statements and function parameters
+//   - store: the bindings of this environment
+//   - outer: the enclosing environment, consulted for names this one does not bind, or nil
+//   - limits: the limits of evaluation in this environment, shared with the environments it encloses, or nil
+//   - importer: loads the modules imported in this environment, shared with the environments it encloses, or nil
+type Environment struct {
+	store    map[string]Object
+	outer    *Environment
+	limits   *Limits
+	importer Importer
+}
+
+// Create, initialize and return a new, empty Environment
+func NewEnvironment() *Environment {
+	s := make(map[string]Object)
+	return &Environment{store: s}
+}
+
+// Create, initialize and return a new, empty Environment enclosed by outer,
+// such as that of a function call or a block
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	env.limits = outer.limits
+	env.importer = outer.importer
+	return env
+}
+
+// Return the limits of evaluation in the environment, or nil if it is not limited
+func (e *Environment) Limits() *Limits {
+	return e.limits
+}
+
+// Limit evaluation in the environment, and in the environments it encloses from now on,
+// such as those of calls to the functions created in it
+func (e *Environment) SetLimits(limits *Limits) {
+	e.limits = limits
+}
+
+// Return the importer of modules in the environment, or nil if it cannot import
+func (e *Environment) Importer() Importer {
+	return e.importer
+}
+
+// Import modules in the environment, and in the environments it encloses from now on, with importer
+func (e *Environment) SetImporter(importer Importer) {
+	e.importer = importer
+}
+
+// Return the object bound to a name in this or the nearest enclosing environment binding it,
+// and whether the name is bound at all
+func (e *Environment) Get(name string) (Object, bool) {
+	obj, ok := e.store[name]
+	if !ok && e.outer != nil {
+		return e.outer.Get(name)
+	}
+	return obj, ok
+}
+
+// Bind an object to a name, replacing any previous binding, and return the object
+func (e *Environment) Set(name string, val Object) Object {
+	e.store[name] = val
+	return val
+}
+
+// Return the names bound in the environment, not counting enclosing environments, in sorted order
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
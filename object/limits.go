@@ -0,0 +1,39 @@
+package object
+
+import (
+	"context"
+	"time"
+)
+
+// Limits bound the evaluation of code in the environments that share them, see Environment.SetLimits
+//   - MaxSteps: the maximum number of nodes a run may evaluate, or 0 for no limit
+//   - MaxDepth: the maximum depth of nested function calls, or 0 for no limit
+//   - Timeout: the maximum duration of a run, or 0 for no limit
+//   - MaxAllocated: the maximum number of bytes a run may allocate in total, approximately, or 0 for no limit;
+//     bytes are never given back, so this bounds the allocations of a run rather than its live memory
+//
+// The next fields hold the state of the run in progress, set up by the host that starts it
+//   - Context: stops the run when done, or nil if no run is in progress
+//   - Deadline: the time by which the run must finish, or the zero time for none
+//   - Steps: the number of nodes the run has evaluated
+//   - Depth: the depth of the function call being evaluated
+//   - Allocated: the approximate number of bytes the run has allocated in total so far
+//
+// The last fields accumulate over every run, and are updated by the host as each one finishes
+//   - MostAllocated: the most bytes allocated in total by any one run
+//   - TotalAllocated: the bytes allocated by all runs
+type Limits struct {
+	MaxSteps     int64
+	MaxDepth     int
+	Timeout      time.Duration
+	MaxAllocated int64
+
+	Context   context.Context
+	Deadline  time.Time
+	Steps     int64
+	Depth     int
+	Allocated int64
+
+	MostAllocated  int64
+	TotalAllocated int64
+}
@@ -0,0 +1,170 @@
+package object
+
+import (
+	"bolt/ast"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type ObjectType string
+
+const (
+	INTEGER_OBJ      = "INTEGER"
+	BOOLEAN_OBJ      = "BOOLEAN"
+	NULL_OBJ         = "NULL"
+	STRING_OBJ       = "STRING"
+	ARRAY_OBJ        = "ARRAY"
+	HASH_OBJ         = "HASH"
+	FUNCTION_OBJ     = "FUNCTION"
+	BUILTIN_OBJ      = "BUILTIN"
+	MODULE_OBJ       = "MODULE"
+	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	ERROR_OBJ        = "ERROR"
+)
+
+// Object is the interface that every value produced by evaluating Bolt code implements.
+//   - Type: returns the type of the object
+//   - Inspect: returns a human-readable representation of the object
+type Object interface {
+	Type() ObjectType
+	Inspect() string
+}
+
+// Integer wraps a 64-bit integer value
+type Integer struct {
+	Value int64
+}
+
+func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
+func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
+
+// Boolean wraps a boolean value
+type Boolean struct {
+	Value bool
+}
+
+func (b *Boolean) Type() ObjectType { return BOOLEAN_OBJ }
+func (b *Boolean) Inspect() string  { return fmt.Sprintf("%t", b.Value) }
+
+// Null represents the absence of a value
+type Null struct{}
+
+func (n *Null) Type() ObjectType { return NULL_OBJ }
+func (n *Null) Inspect() string  { return "null" }
+
+// String wraps a string value
+type String struct {
+	Value string
+}
+
+func (s *String) Type() ObjectType { return STRING_OBJ }
+func (s *String) Inspect() string  { return strconv.Quote(s.Value) }
+
+// Array is an ordered list of objects
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() ObjectType { return ARRAY_OBJ }
+func (a *Array) Inspect() string {
+	elements := make([]string, len(a.Elements))
+	for i, el := range a.Elements {
+		elements[i] = el.Inspect()
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// Hash maps string keys to objects
+//   - Inspect lists the pairs in order of their keys
+type Hash struct {
+	Pairs map[string]Object
+}
+
+func (h *Hash) Type() ObjectType { return HASH_OBJ }
+func (h *Hash) Inspect() string {
+	keys := make([]string, 0, len(h.Pairs))
+	for key := range h.Pairs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = strconv.Quote(key) + ": " + h.Pairs[key].Inspect()
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// Function is a function literal closed over the environment it was evaluated in
+//   - Parameters: the parameters bound to the arguments of a call
+//   - Body: the block evaluated by a call
+//   - Env: the environment the function was created in, enclosing that of each call
+type Function struct {
+	Parameters []*ast.Parameter
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
+func (f *Function) Inspect() string {
+	params := make([]string, len(f.Parameters))
+	for i, p := range f.Parameters {
+		params[i] = p.String()
+	}
+	return "fn(" + strings.Join(params, ", ") + ") " + f.Body.String()
+}
+
+// BuiltinFunction is the signature of a function implemented in Go
+//   - It returns an *Error to fail the call
+type BuiltinFunction func(args ...Object) Object
+
+// Builtin is a function implemented in Go, such as one provided by a host application
+//   - Name: the name the function is known by, used by Inspect
+//   - Fn: the function called with the arguments of a call
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
+func (b *Builtin) Inspect() string  { return "builtin " + b.Name }
+
+// Module is an imported .bolt file, whose exported bindings are accessed as members, e.g. math.max
+//   - Name: the name the module is bound to by default, from the last element of its path
+//   - Path: the resolved path of the file, which identifies the module
+//   - Exports: the values bound by the exported let statements of the file
+type Module struct {
+	Name    string
+	Path    string
+	Exports map[string]Object
+}
+
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+func (m *Module) Inspect() string  { return "module " + m.Name }
+
+// Importer loads the modules named by import statements
+//   - Import: returns the module at a path, as written in an import statement, or an error if it
+//     cannot be found, loaded or evaluated
+type Importer interface {
+	Import(path string) (*Module, error)
+}
+
+// ReturnValue wraps the value of a return statement while it unwinds through the evaluator
+type ReturnValue struct {
+	Value Object
+}
+
+func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
+func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
+
+// Error represents a runtime error, which stops evaluation of the program
+//   - Err: the Go error that caused it, such as one returned by a builtin or a limit being exceeded, or nil
+type Error struct {
+	Message string
+	Err     error
+}
+
+func (e *Error) Type() ObjectType { return ERROR_OBJ }
+func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
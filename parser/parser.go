@@ -6,6 +6,7 @@ import (
 	"bolt/token"
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -17,6 +18,7 @@ const (
 	PRODUCT     // *
 	PREFIX      // -X or !X
 	CALL        // myFunction(X)
+	MEMBER      // module.member
 )
 
 var precedences = map[token.TokenType]int{
@@ -28,6 +30,20 @@ var precedences = map[token.TokenType]int{
 	token.MINUS:    SUM,
 	token.SLASH:    PRODUCT,
 	token.ASTERISK: PRODUCT,
+	token.LPAREN:   CALL,
+	token.DOT:      MEMBER,
+}
+
+// ParseError describes a problem found while parsing
+//   - Token: the token at which the problem was detected, giving its position
+//   - Message: a description of the problem
+type ParseError struct {
+	Token   token.Token
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Token.Line, e.Token.Column, e.Message)
 }
 
 // Type definition for the Bolt Parser
@@ -37,7 +53,7 @@ var precedences = map[token.TokenType]int{
 //   - errors: a list of errors encountered during parsing
 type Parser struct {
 	l      *lexer.Lexer
-	errors []string
+	errors []*ParseError
 
 	curToken  token.Token
 	peekToken token.Token
@@ -58,7 +74,7 @@ type infixParseFn func(ast.Expression) ast.Expression
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
-		errors: []string{},
+		errors: []*ParseError{},
 	}
 	p.nextToken()
 	p.nextToken()
@@ -66,11 +82,14 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
+	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -81,6 +100,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
 	return p
 }
 
@@ -90,21 +111,35 @@ func (p *Parser) nextToken() {
 	p.peekToken = p.l.NextToken()
 }
 
-// Return the current program's errors
+// Return the messages of the current program's errors
 func (p *Parser) Errors() []string {
+	msgs := make([]string, len(p.errors))
+	for i, err := range p.errors {
+		msgs[i] = err.Message
+	}
+	return msgs
+}
+
+// Return the current program's errors, including their positions
+func (p *Parser) ParseErrors() []*ParseError {
 	return p.errors
 }
 
+// Record an error detected at the given token
+func (p *Parser) addError(tok token.Token, format string, a ...interface{}) {
+	p.errors = append(p.errors, &ParseError{Token: tok, Message: fmt.Sprintf(format, a...)})
+}
+
 // Error message for when the next token is not of the expected type
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.addError(p.peekToken, "expected next token to be %s, got %s instead", t, p.peekToken.Type)
 }
 
 // Parse the input program
 //   - Create a new AST program
 //   - Iterate over the input tokens and parse each statement
 //   - Append each statement to the program
+//   - Attach the comments collected by the lexer to the program
 //   - Return the program
 func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
@@ -118,16 +153,39 @@ func (p *Parser) ParseProgram() *ast.Program {
 		p.nextToken()
 	}
 
+	program.Comments = p.l.Comments()
+
 	return program
 }
 
 // Attempt to parse an individual statement based on the current token type
 //   - If the current token is a LET token, parse a let statement
+//   - If the current token is an EXPORT token, parse the let statement it exports
+//   - If the current token is an IMPORT token, parse an import statement
 //   - If the current token is a RETURN token, parse a return statement
+//   - A malformed let or import statement yields a nil ast.Statement rather than a nil pointer
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.LET:
-		return p.parseLetStatement()
+		if stmt := p.parseLetStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
+	case token.EXPORT:
+		export := p.curToken
+		if !p.expectPeek(token.LET) {
+			return nil
+		}
+		if stmt := p.parseLetStatement(); stmt != nil {
+			stmt.Export = export
+			return stmt
+		}
+		return nil
+	case token.IMPORT:
+		if stmt := p.parseImportStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
 	case token.RETURN:
 		return p.parseReturnStatement()
 	default:
@@ -138,8 +196,9 @@ func (p *Parser) parseStatement() ast.Statement {
 // Parse a let statement to ensure that it is well-formed
 //   - The statement must start with the token.LET token
 //   - The next token must be an identifier
+//   - An optional type annotation follows, a colon and the name of a type
 //   - The next token must be an assignment token
-//   - Skip over any tokens until we encounter a semicolon
+//   - Parse the bound expression, consuming an optional trailing semicolon
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: p.curToken}
 
@@ -149,26 +208,101 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Type = &ast.TypeName{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
 
-	// TODO
-	for !p.curTokenIs(token.SEMICOLON) {
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// Parse an import statement to ensure that it is well-formed
+//   - The statement must start with the token.IMPORT token, followed by the path of the module as a string
+//   - An optional `as` and an identifier name the module; otherwise the name is the last element of the path
+//     without its .bolt extension, which must then be a valid identifier
+//   - Consume an optional trailing semicolon
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+	path, ok := p.parseStringLiteral().(*ast.StringLiteral)
+	if !ok {
+		return nil
+	}
+	stmt.Path = path
+
+	if p.peekTokenIs(token.AS) {
+		p.nextToken()
+		stmt.As = p.curToken
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	} else {
+		name := ModuleName(path.Value)
+		if token.LookupIdent(name) != token.IDENT || !isIdentifier(name) {
+			p.addError(path.Token, "cannot name module %s after its path, name it with as", path.Token.Literal)
+			return nil
+		}
+		stmt.Name = &ast.Identifier{Token: path.Token, Value: name}
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
 	return stmt
 }
 
+// Return the name of a module derived from its import path: its last element without the .bolt extension
+func ModuleName(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		path = path[i+1:]
+	}
+	return strings.TrimSuffix(path, ".bolt")
+}
+
+// Determine whether a name can be spelled as an identifier
+func isIdentifier(name string) bool {
+	l := lexer.New(name)
+	tok := l.NextToken()
+	return tok.Type == token.IDENT && tok.Literal == name && l.NextToken().Type == token.EOF
+}
+
 // Parse a return statement to ensure that it is well-formed
 //   - The statement must start with the token.RETURN token
-//   - Skip over any tokens until we encounter a semicolon
+//   - A bare `return;` has no return value
+//   - Otherwise parse the returned expression, consuming an optional trailing semicolon
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 
-	// TODO
-	if !p.curTokenIs(token.SEMICOLON) {
+	if p.peekTokenIs(token.SEMICOLON) || p.peekTokenIs(token.EOF) {
+		p.nextToken()
+		return stmt
+	}
+
+	p.nextToken()
+
+	stmt.ReturnValue = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -248,8 +382,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.addError(p.curToken, "no prefix parse function for %s found", t)
 }
 
 // Parse an identifier expression to ensure that it is well-formed
@@ -266,14 +399,23 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken, "could not parse %q as integer", p.curToken.Literal)
 		return nil
 	}
 	lit.Value = value
 	return lit
 }
 
+// Parse a string literal, decoding its escapes as in a Go string literal
+func (p *Parser) parseStringLiteral() ast.Expression {
+	value, err := strconv.Unquote(p.curToken.Literal)
+	if err != nil {
+		p.addError(p.curToken, "invalid escape in string %s", p.curToken.Literal)
+		return nil
+	}
+	return &ast.StringLiteral{Token: p.curToken, Value: value}
+}
+
 // Parse a prefix expression to ensure that it is well-formed
 //   - Create a new prefix expression
 //   - Set the operator to the current token's literal value
@@ -312,20 +454,22 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
-// Return the precedence of the next token
-func (p *Parser) peekPrecedence() int {
-	if p, ok := precedences[p.peekToken.Type]; ok {
+// Return the precedence of an infix operator token, or LOWEST if the token is not an infix operator
+func Precedence(t token.TokenType) int {
+	if p, ok := precedences[t]; ok {
 		return p
 	}
 	return LOWEST
 }
 
+// Return the precedence of the next token
+func (p *Parser) peekPrecedence() int {
+	return Precedence(p.peekToken.Type)
+}
+
 // Return the precedence of the current token
 func (p *Parser) curPrecedence() int {
-	if p, ok := precedences[p.curToken.Type]; ok {
-		return p
-	}
-	return LOWEST
+	return Precedence(p.curToken.Type)
 }
 
 // Parse a boolean expression to ensure that it is well-formed
@@ -348,3 +492,169 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 
 	return exp
 }
+
+// Parse a block statement, starting with a left brace
+//   - Parse statements until the matching right brace or the end of the input
+//   - A block that is not closed before the end of the input is an error
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: p.curToken, Statements: []ast.Statement{}}
+
+	p.nextToken()
+
+	for !p.curTokenIs(token.RBRACE) {
+		if p.curTokenIs(token.EOF) {
+			p.addError(p.curToken, "expected %s to close the block at %d:%d, got EOF instead",
+				token.RBRACE, block.Token.Line, block.Token.Column)
+			return block
+		}
+		if stmt := p.parseStatement(); stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+		p.nextToken()
+	}
+	block.Rbrace = p.curToken
+
+	return block
+}
+
+// Parse an if expression, starting with the token.IF token
+//   - Parse the condition, whose parentheses are optional
+//   - The condition must be followed by a block, the consequence
+//   - An optional else and a second block, the alternative, may follow
+func (p *Parser) parseIfExpression() ast.Expression {
+	expression := &ast.IfExpression{Token: p.curToken}
+
+	p.nextToken()
+	expression.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	expression.Consequence = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.ELSE) {
+		p.nextToken()
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		expression.Alternative = p.parseBlockStatement()
+	}
+
+	return expression
+}
+
+// Parse a function literal, starting with the token.FUNCTION token
+//   - The parameters follow in parentheses, each an identifier with an optional type annotation
+//   - An optional arrow and the name of a type annotate the result
+//   - The body must be a block
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	if lit.Parameters = p.parseFunctionParameters(); lit.Parameters == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(token.ARROW) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		lit.ReturnType = &ast.TypeName{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	lit.Body = p.parseBlockStatement()
+
+	return lit
+}
+
+// Parse the comma-separated parameters of a function literal, up to the closing parenthesis
+//   - Return nil if the parameters are malformed
+func (p *Parser) parseFunctionParameters() []*ast.Parameter {
+	params := []*ast.Parameter{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return params
+	}
+
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		param := &ast.Parameter{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			param.Type = &ast.TypeName{Token: p.curToken, Value: p.curToken.Literal}
+		}
+		params = append(params, param)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	return params
+}
+
+// Parse a call expression, the left parenthesis following the expression being called
+func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	exp := &ast.CallExpression{Token: p.curToken, Function: function}
+	if exp.Arguments = p.parseCallArguments(); exp.Arguments == nil {
+		return nil
+	}
+	return exp
+}
+
+// Parse a member expression, the dot following the expression evaluating to a module and the name of the member
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.curToken, Object: object}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Member = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return exp
+}
+
+// Parse the comma-separated arguments of a call, up to the closing parenthesis
+//   - Return nil if the arguments are malformed
+func (p *Parser) parseCallArguments() []ast.Expression {
+	args := []ast.Expression{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return args
+	}
+
+	for {
+		p.nextToken()
+		arg := p.parseExpression(LOWEST)
+		if arg == nil {
+			return nil
+		}
+		args = append(args, arg)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	return args
+}
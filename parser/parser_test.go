@@ -3,44 +3,44 @@ package parser
 import (
 	"bolt/ast"
 	"bolt/lexer"
+	"bolt/token"
 	"fmt"
+	"reflect"
 	"testing"
 )
 
 func TestLetStatements(t *testing.T) {
-	input := `
-let x = 5;
-let y = 10;
-let foobar = 838383;
-`
-
-	l := lexer.New(input)
-	p := New(l)
-	program := p.ParseProgram()
-	checkParserErrors(t, p)
-
-	if program == nil {
-		t.Fatalf("ParseProgram() returned nil")
-	}
-	if len(program.Statements) != 3 {
-		t.Fatalf("program.Statements does not contain 3 statements, got=%d", len(program.Statements))
-	}
-
 	tests := []struct {
+		input              string
 		expectedIdentifier string
+		expectedValue      interface{}
 	}{
-		{"x"},
-		{"y"},
-		{"foobar"},
+		{"let x = 5;", "x", 5},
+		{"let y = true;", "y", true},
+		{"let foobar = y;", "foobar", "y"},
+		{"let z = 838383", "z", 838383},
 	}
 
-	for i, tt := range tests {
-		stmt := program.Statements[i]
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement, got=%d", len(program.Statements))
+		}
+
+		stmt := program.Statements[0]
 		if !testLetStatement(t, stmt, tt.expectedIdentifier) {
 			return
 		}
-	}
 
+		val := stmt.(*ast.LetStatement).Value
+		if !testLiteralExpression(t, val, tt.expectedValue) {
+			return
+		}
+	}
 }
 
 // Test that a parsed let statement in a program is correctly formed
@@ -69,30 +69,105 @@ func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
 	return true
 }
 
+func TestTypeAnnotations(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType string
+		expected     string
+	}{
+		{"let x: int = 5;", "int", "let x: int = 5;"},
+		{"let ok:bool = true", "bool", "let ok: bool = true;"},
+		{"let y = x;", "", "let y = x;"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		let := program.Statements[0].(*ast.LetStatement)
+		if tt.expectedType == "" {
+			if let.Type != nil {
+				t.Errorf("%q: unexpected type annotation %q", tt.input, let.Type.Value)
+			}
+		} else if let.Type == nil || let.Type.Value != tt.expectedType || let.Type.Token.Type != token.IDENT {
+			t.Errorf("%q: wrong type annotation. expected=%q, got=%+v", tt.input, tt.expectedType, let.Type)
+		}
+		if program.String() != tt.expected {
+			t.Errorf("%q: wrong string. expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+
+	p := New(lexer.New("let x: = 5;"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || p.Errors()[0] != "expected next token to be IDENT, got = instead" {
+		t.Errorf("a missing type name was not reported. got=%q", p.Errors())
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
-	input := `
-   return;
-   return;
-   return;
-   `
+	tests := []struct {
+		input         string
+		expectedValue interface{}
+	}{
+		{"return;", nil},
+		{"return 5;", 5},
+		{"return true;", true},
+		{"return foobar", "foobar"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d",
+				len(program.Statements))
+		}
+
+		returnStmt, ok := program.Statements[0].(*ast.ReturnStatement)
+		if !ok {
+			t.Fatalf("stmt not *ast.ReturnStatement. got=%T", program.Statements[0])
+		}
+		if returnStmt.TokenLiteral() != "return" {
+			t.Errorf("returnStmt.TokenLiteral not 'return', got %q", returnStmt.TokenLiteral())
+		}
+
+		if tt.expectedValue == nil {
+			if returnStmt.ReturnValue != nil {
+				t.Errorf("returnStmt.ReturnValue not nil. got=%s", returnStmt.ReturnValue)
+			}
+			continue
+		}
+		if !testLiteralExpression(t, returnStmt.ReturnValue, tt.expectedValue) {
+			return
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading
+let x = 5; // trailing
+// closing`
+
 	l := lexer.New(input)
 	p := New(l)
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
 
-	if len(program.Statements) != 3 {
-		t.Fatalf("program.Statements does not contain 3 statements. got=%d",
-			len(program.Statements))
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
 	}
 
-	for _, stmt := range program.Statements {
-		returnStmt, ok := stmt.(*ast.ReturnStatement)
-		if !ok {
-			t.Errorf("stmt not *ast.ReturnStatement. got=%T", stmt)
-			continue
-		}
-		if returnStmt.TokenLiteral() != "return" {
-			t.Errorf("returnStmt.TokenLiteral not 'return', got %q", returnStmt.TokenLiteral())
+	expected := []string{"// leading", "// trailing", "// closing"}
+	if len(program.Comments) != len(expected) {
+		t.Fatalf("program.Comments does not contain %d comments. got=%d", len(expected), len(program.Comments))
+	}
+	for i, c := range program.Comments {
+		if c.Literal != expected[i] {
+			t.Errorf("program.Comments[%d] wrong. expected=%q, got=%q", i, expected[i], c.Literal)
 		}
 	}
 }
@@ -280,7 +355,7 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		},
 		{
 			"3 + 4; -5 * 5",
-			"(3 + 4)((-5) * 5)",
+			"(3 + 4);\n((-5) * 5)",
 		},
 		{
 			"5 > 4 == 3 < 4",
@@ -332,6 +407,30 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"!(true == true)",
 			"(!(true == true))",
 		},
+		{
+			"a + add(b * c) + d",
+			"((a + add((b * c))) + d)",
+		},
+		{
+			"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))",
+			"add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))",
+		},
+		{
+			"-f(x)",
+			"(-f(x))",
+		},
+		{
+			"f(x)(y)",
+			"f(x)(y)",
+		},
+		{
+			"-m.x * a.b",
+			"((-m.x) * a.b)",
+		},
+		{
+			"m.f(1) + g(2).y",
+			"(m.f(1) + g(2).y)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -384,6 +483,197 @@ func TestBooleanExpression(t *testing.T) {
 	}
 }
 
+func TestIfExpression(t *testing.T) {
+	tests := []struct {
+		input       string
+		alternative bool
+		expected    string
+	}{
+		{"if (x < y) { x }", false, "if (x < y) { x }"},
+		{"if x < y { x } else { y; }", true, "if (x < y) { x } else { y }"},
+		{"if (x) { let z = x; z } else { }", true, "if x { let z = x; z } else { }"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("%q: wrong number of statements. got=%d", tt.input, len(program.Statements))
+		}
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.IfExpression)
+		if !ok {
+			t.Fatalf("%q: exp is not *ast.IfExpression. got=%T", tt.input, stmt.Expression)
+		}
+		if exp.Consequence == nil || len(exp.Consequence.Statements) == 0 {
+			t.Errorf("%q: consequence is empty", tt.input)
+		}
+		if (exp.Alternative != nil) != tt.alternative {
+			t.Errorf("%q: wrong alternative. got=%v", tt.input, exp.Alternative)
+		}
+		if program.String() != tt.expected {
+			t.Errorf("%q: wrong string. expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestFunctionLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedParams []string
+		expectedResult string
+		expected       string
+	}{
+		{"fn() {};", []string{}, "", "fn() { }"},
+		{"fn(x) { x };", []string{"x"}, "", "fn(x) { x }"},
+		{"fn(x, y, z) { x + y; };", []string{"x", "y", "z"}, "", "fn(x, y, z) { (x + y) }"},
+		{"fn(a: int, b:int) -> int { return a + b; }", []string{"a: int", "b: int"}, "int", "fn(a: int, b: int) -> int { return (a + b); }"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		fn, ok := stmt.Expression.(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("%q: exp is not *ast.FunctionLiteral. got=%T", tt.input, stmt.Expression)
+		}
+
+		params := []string{}
+		for _, param := range fn.Parameters {
+			params = append(params, param.String())
+		}
+		if !reflect.DeepEqual(params, tt.expectedParams) {
+			t.Errorf("%q: wrong parameters. expected=%q, got=%q", tt.input, tt.expectedParams, params)
+		}
+		if (fn.ReturnType == nil && tt.expectedResult != "") || (fn.ReturnType != nil && fn.ReturnType.Value != tt.expectedResult) {
+			t.Errorf("%q: wrong result type. expected=%q, got=%+v", tt.input, tt.expectedResult, fn.ReturnType)
+		}
+		if program.String() != tt.expected {
+			t.Errorf("%q: wrong string. expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestCallExpressionParsing(t *testing.T) {
+	p := New(lexer.New("add(1, 2 * 3, 4 + 5);"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.CallExpression. got=%T", stmt.Expression)
+	}
+	if !testIdentifier(t, exp.Function, "add") {
+		return
+	}
+	if len(exp.Arguments) != 3 {
+		t.Fatalf("wrong length of arguments. got=%d", len(exp.Arguments))
+	}
+	testLiteralExpression(t, exp.Arguments[0], 1)
+	testInfixExpression(t, exp.Arguments[1], 2, "*", 3)
+	testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
+}
+
+func TestModuleStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		path     string
+		name     string
+		expected string
+	}{
+		{`import "lib/math";`, "lib/math", "math", `import "lib/math";`},
+		{`import "./util.bolt"`, "./util.bolt", "util", `import "./util.bolt";`},
+		{`import "lib/math-v2" as m;`, "lib/math-v2", "m", `import "lib/math-v2" as m;`},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ImportStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not *ast.ImportStatement. got=%T", program.Statements[0])
+		}
+		if stmt.Path.Value != tt.path || stmt.Name.Value != tt.name || stmt.String() != tt.expected {
+			t.Errorf("wrong import for %q. got path=%q name=%q string=%q", tt.input, stmt.Path.Value, stmt.Name.Value, stmt.String())
+		}
+	}
+
+	p := New(lexer.New(`export let greeting: string = "hi\t\"you\"";`))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	let, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok || !let.Exported() {
+		t.Fatalf("expected an exported let statement. got=%#v", program.Statements[0])
+	}
+	str, ok := let.Value.(*ast.StringLiteral)
+	if !ok || str.Value != "hi\t\"you\"" {
+		t.Fatalf("wrong string literal. got=%#v", let.Value)
+	}
+	if let.String() != `export let greeting: string = "hi\t\"you\"";` {
+		t.Errorf("wrong string of let. got=%q", let.String())
+	}
+	if tok := ast.FirstToken(let); tok.Type != token.EXPORT || tok.Column != 1 {
+		t.Errorf("wrong first token of exported let. got=%+v", tok)
+	}
+}
+
+func TestModuleErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"import math;", "1:8: expected next token to be STRING, got IDENT instead"},
+		{`import "lib/math-v2";`, `1:8: cannot name module "lib/math-v2" after its path, name it with as`},
+		{`import "lib/if";`, `1:8: cannot name module "lib/if" after its path, name it with as`},
+		{`import "m" as "n";`, "1:15: expected next token to be IDENT, got STRING instead"},
+		{"export fn() {};", "1:8: expected next token to be LET, got FUNCTION instead"},
+		{"m.1", "1:3: expected next token to be IDENT, got INT instead"},
+		{`"\q"`, `1:1: invalid escape in string "\q"`},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.ParseErrors()
+		if len(errors) == 0 || errors[0].Error() != tt.expected {
+			t.Errorf("%q: wrong first error. expected=%q, got=%v", tt.input, tt.expected, p.Errors())
+		}
+	}
+}
+
+func TestBlockErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"if (x) { x", "1:11: expected } to close the block at 1:8, got EOF instead"},
+		{"fn(x y) { x }", "1:6: expected next token to be ), got IDENT instead"},
+		{"fn(x) -> { x }", "1:10: expected next token to be IDENT, got { instead"},
+		{"f(1, )", "1:6: no prefix parse function for ) found"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		ast.Inspect(program, func(ast.Node) bool { return true })
+
+		errors := p.ParseErrors()
+		if len(errors) == 0 || errors[0].Error() != tt.expected {
+			t.Errorf("%q: wrong first error. expected=%q, got=%v", tt.input, tt.expected, p.Errors())
+		}
+	}
+}
+
 func testIntegerLiteral(t *testing.T, il ast.Expression, value int64) bool {
 	integ, ok := il.(*ast.IntegerLiteral)
 	if !ok {
@@ -488,6 +778,94 @@ func testBooleanLiteral(t *testing.T, exp ast.Expression, value bool) bool {
 	return true
 }
 
+func TestParseErrorPositions(t *testing.T) {
+	input := `let x = 5;
+let = 10;
+  5 + ;`
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	expected := []string{
+		"2:5: expected next token to be IDENT, got = instead",
+		"2:5: no prefix parse function for = found",
+		"3:7: no prefix parse function for ; found",
+	}
+
+	errors := p.ParseErrors()
+	if len(errors) != len(expected) {
+		t.Fatalf("wrong number of errors. expected=%d, got=%d (%v)", len(expected), len(errors), p.Errors())
+	}
+	for i, err := range errors {
+		if err.Error() != expected[i] {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, expected[i], err.Error())
+		}
+		if p.Errors()[i] != err.Message {
+			t.Errorf("Errors()[%d] wrong. expected=%q, got=%q", i, err.Message, p.Errors()[i])
+		}
+	}
+}
+
+// Fuzz the parser to ensure it never panics on malformed input
+//   - Every program, including those with errors, can be printed and walked
+//   - If the parse is error-free, re-parsing program.String() yields an identical tree
+func FuzzParseProgram(f *testing.F) {
+	f.Add("let x = 5; return x;")
+	f.Add("-a * b + c / (d - e) == !true != false")
+	f.Add("(5 + 5) * 2 * (5 + 5)")
+	f.Add("let = ; return (; a + ; -")
+	f.Add("a; b; 5; 6; -7")
+	f.Add("// comment\nlet x = y; // trailing")
+	f.Add("let x: int = 5;")
+	f.Add("let add = fn(a: int, b) -> int { return a + b; }; add(1, 2)")
+	f.Add("if (x < y) { x } else { y }; (1)")
+	f.Add("fn(x) { if x { } }(f(1)(2))")
+	f.Add(`import "lib/m" as m; export let s = m.f("a\n");`)
+
+	f.Fuzz(func(t *testing.T, input string) {
+		p := New(lexer.New(input))
+		program := p.ParseProgram()
+
+		printed := program.String()
+		ast.Inspect(program, func(ast.Node) bool { return true })
+
+		if len(p.Errors()) != 0 {
+			return
+		}
+
+		reparser := New(lexer.New(printed))
+		reparsed := reparser.ParseProgram()
+		if len(reparser.Errors()) != 0 {
+			t.Fatalf("re-parsing %q (from %q) failed: %v", printed, input, reparser.Errors())
+		}
+
+		expected, got := treeShape(program), treeShape(reparsed)
+		if !reflect.DeepEqual(expected, got) {
+			t.Fatalf("re-parsing %q (from %q) changed the tree.\nexpected=%v\ngot=%v", printed, input, expected, got)
+		}
+	})
+}
+
+// Describe the structure of a tree, ignoring token positions
+//   - Programs and expression statements take the literal of their first token, which may be an extra
+//     parenthesis, so it is ignored
+func treeShape(node ast.Node) []string {
+	var shape []string
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil:
+			shape = append(shape, "end")
+		case *ast.Program, *ast.ExpressionStatement:
+			shape = append(shape, fmt.Sprintf("%T", n))
+		default:
+			shape = append(shape, fmt.Sprintf("%T %s", n, n.TokenLiteral()))
+		}
+		return true
+	})
+	return shape
+}
+
 // Check if the parser produced any errors. If it did, log them and fail the test
 func checkParserErrors(t *testing.T, p *Parser) {
 	errors := p.Errors()
@@ -0,0 +1,217 @@
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The names of the files of a project, at its root
+const (
+	MANIFEST_FILE = "bolt.mod"
+	SUM_FILE      = "bolt.sum"
+)
+
+// The version of the Bolt language implemented, the newest a manifest may require
+const LANGUAGE_VERSION = "0.1"
+
+// Manifest is the content of a bolt.mod file, which declares a project and its dependencies
+//   - Module: the name of the project
+//   - Bolt: the version of the language the project is written in, e.g. 0.1
+//   - Require: the dependencies of the project, sorted by name
+//
+// A manifest is a list of directives, one per line, with // comments:
+//
+//	module app
+//	bolt 0.1
+//	require geometry ../geometry
+//	require text vendor/text.zip
+type Manifest struct {
+	Module  string
+	Bolt    string
+	Require []*Require
+}
+
+// Require is a dependency of a project, imported by the paths starting with its name, e.g. geometry/shapes
+//   - Name: the name of the dependency, a single element of a path
+//   - Path: the directory or .zip archive holding its modules, relative to the root of the project
+//     unless it is absolute, with its files at the top
+type Require struct {
+	Name string
+	Path string
+}
+
+// Parse the content of a manifest, returning an error giving the line of the first problem found
+//   - file: the name of the manifest in errors
+//   - The module and bolt directives are required and may only appear once, and the names of
+//     dependencies must be unique
+func ParseManifest(file string, data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	names := map[string]bool{}
+	for i, line := range strings.Split(string(data), "\n") {
+		errorf := func(format string, a ...interface{}) error {
+			return fmt.Errorf("%s:%d: %s", file, i+1, fmt.Sprintf(format, a...))
+		}
+
+		fields, err := splitFields(line)
+		if err != nil {
+			return nil, errorf("%s", err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "module":
+			if len(fields) != 2 {
+				return nil, errorf("usage: module <name>")
+			}
+			if m.Module != "" {
+				return nil, errorf("repeated module directive")
+			}
+			m.Module = fields[1]
+
+		case "bolt":
+			if len(fields) != 2 {
+				return nil, errorf("usage: bolt <version>")
+			}
+			if m.Bolt != "" {
+				return nil, errorf("repeated bolt directive")
+			}
+			if _, _, ok := parseVersion(fields[1]); !ok {
+				return nil, errorf("invalid bolt version %q, want major.minor", fields[1])
+			}
+			m.Bolt = fields[1]
+
+		case "require":
+			if len(fields) != 3 {
+				return nil, errorf("usage: require <name> <path>")
+			}
+			name := fields[1]
+			if !validName(name) {
+				return nil, errorf("invalid dependency name %q, want a single path element", name)
+			}
+			if names[name] {
+				return nil, errorf("%s required twice", name)
+			}
+			names[name] = true
+			m.Require = append(m.Require, &Require{Name: name, Path: fields[2]})
+
+		default:
+			return nil, errorf("unknown directive %q", fields[0])
+		}
+	}
+
+	switch {
+	case m.Module == "":
+		return nil, fmt.Errorf("%s: missing module directive", file)
+	case m.Bolt == "":
+		return nil, fmt.Errorf("%s: missing bolt directive", file)
+	}
+	m.sort()
+	return m, nil
+}
+
+// Return the canonical content of the manifest, with its dependencies sorted by name
+func (m *Manifest) Format() []byte {
+	m.sort()
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "module %s\n\nbolt %s\n", quote(m.Module), m.Bolt)
+	if len(m.Require) != 0 {
+		out.WriteString("\n")
+	}
+	for _, r := range m.Require {
+		fmt.Fprintf(&out, "require %s %s\n", r.Name, quote(r.Path))
+	}
+	return out.Bytes()
+}
+
+// Return the dependency with a name, or nil if there is none
+func (m *Manifest) Lookup(name string) *Require {
+	for _, r := range m.Require {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// Return an error if the manifest requires a newer version of the language than LANGUAGE_VERSION
+func (m *Manifest) CheckVersion() error {
+	major, minor, _ := parseVersion(m.Bolt)
+	current, currentMinor, _ := parseVersion(LANGUAGE_VERSION)
+	if major > current || (major == current && minor > currentMinor) {
+		return fmt.Errorf("%s requires bolt %s, but this is bolt %s", m.Module, m.Bolt, LANGUAGE_VERSION)
+	}
+	return nil
+}
+
+func (m *Manifest) sort() {
+	sort.Slice(m.Require, func(i, j int) bool { return m.Require[i].Name < m.Require[j].Name })
+}
+
+// Split a line into fields separated by spaces, each of which may be a double-quoted Go string,
+// up to a // comment
+func splitFields(line string) ([]string, error) {
+	var fields []string
+	for {
+		line = strings.TrimLeft(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "//") {
+			return fields, nil
+		}
+		if line[0] != '"' {
+			end := strings.IndexAny(line, " \t\r")
+			if end < 0 {
+				end = len(line)
+			}
+			if comment := strings.Index(line[:end], "//"); comment >= 0 {
+				end = comment
+			}
+			fields = append(fields, line[:end])
+			line = line[end:]
+			continue
+		}
+
+		prefix, err := strconv.QuotedPrefix(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted string %s", line)
+		}
+		field, _ := strconv.Unquote(prefix)
+		fields = append(fields, field)
+		line = line[len(prefix):]
+	}
+}
+
+// Quote a field if it would not be read back as a single field otherwise
+func quote(field string) string {
+	if field == "" || strings.ContainsAny(field, " \t\r\"") || strings.Contains(field, "//") {
+		return strconv.Quote(field)
+	}
+	return field
+}
+
+// Determine whether a name of a dependency is a single valid element of a path
+func validName(name string) bool {
+	return fs.ValidPath(name) && name != "." && !strings.Contains(name, "/")
+}
+
+// Parse a version of the language, formatted as major.minor
+func parseVersion(version string) (int, int, bool) {
+	majorText, minorText, ok := strings.Cut(version, ".")
+	if !ok {
+		return 0, 0, false
+	}
+	major, err := strconv.ParseUint(majorText, 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.ParseUint(minorText, 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	return int(major), int(minor), true
+}
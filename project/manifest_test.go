@@ -0,0 +1,95 @@
+package project
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseManifest(t *testing.T) {
+	input := `// the application
+module app
+bolt 0.1 // the language version
+
+require text vendor/text.zip
+require geometry "../shared libs/geometry"
+require url ../url// a comment
+`
+	m, err := ParseManifest(MANIFEST_FILE, []byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := &Manifest{
+		Module: "app",
+		Bolt:   "0.1",
+		Require: []*Require{
+			{Name: "geometry", Path: "../shared libs/geometry"},
+			{Name: "text", Path: "vendor/text.zip"},
+			{Name: "url", Path: "../url"},
+		},
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Fatalf("wrong manifest.\nexpected=%+v\ngot=%+v", expected, m)
+	}
+
+	formatted := "module app\n\nbolt 0.1\n\nrequire geometry \"../shared libs/geometry\"\nrequire text vendor/text.zip\nrequire url ../url\n"
+	if got := string(m.Format()); got != formatted {
+		t.Errorf("wrong format.\nexpected=%q\ngot=%q", formatted, got)
+	}
+	if again, err := ParseManifest(MANIFEST_FILE, m.Format()); err != nil || !reflect.DeepEqual(again, m) {
+		t.Errorf("formatting changed the manifest. got=%+v (%v)", again, err)
+	}
+	if m.Lookup("text") != m.Require[1] || m.Lookup("missing") != nil {
+		t.Errorf("wrong lookup of dependencies")
+	}
+}
+
+func TestParseManifestErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"bolt 0.1", "bolt.mod: missing module directive"},
+		{"module app", "bolt.mod: missing bolt directive"},
+		{"module app\nmodule other", "bolt.mod:2: repeated module directive"},
+		{"module app b", "bolt.mod:1: usage: module <name>"},
+		{"module app\nbolt 1", `bolt.mod:2: invalid bolt version "1", want major.minor`},
+		{"module app\nbolt 0.x", `bolt.mod:2: invalid bolt version "0.x", want major.minor`},
+		{"module app\nrequire a", "bolt.mod:2: usage: require <name> <path>"},
+		{"module app\nrequire a/b ../b", `bolt.mod:2: invalid dependency name "a/b", want a single path element`},
+		{"module app\nrequire .. ../b", `bolt.mod:2: invalid dependency name "..", want a single path element`},
+		{"module app\nrequire a ../a\nrequire a ../b", "bolt.mod:3: a required twice"},
+		{"module app\nreplace a ../a", `bolt.mod:2: unknown directive "replace"`},
+		{`module "app`, `bolt.mod:1: invalid quoted string "app`},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseManifest(MANIFEST_FILE, []byte(tt.input))
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		err     bool
+	}{
+		{"0.0", false},
+		{LANGUAGE_VERSION, false},
+		{"0.2", true},
+		{"1.0", true},
+	}
+
+	for _, tt := range tests {
+		err := (&Manifest{Module: "app", Bolt: tt.version}).CheckVersion()
+		if (err != nil) != tt.err {
+			t.Errorf("wrong result checking version %s. got=%v", tt.version, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "app requires bolt "+tt.version) {
+			t.Errorf("wrong error checking version %s. got=%s", tt.version, err)
+		}
+	}
+}
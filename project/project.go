@@ -0,0 +1,393 @@
+package project
+
+import (
+	"archive/zip"
+	"bolt/ast"
+	"bolt/lexer"
+	"bolt/module"
+	"bolt/parser"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// The directory of a project that bolt mod vendor copies dependencies to, as .zip archives
+const VENDOR_DIR = "vendor"
+
+// ChecksumError fails to open a project whose dependency does not have the content recorded in bolt.sum
+//   - Name: the name of the dependency
+//   - Want: the hash recorded in bolt.sum
+//   - Got: the hash of the content of the dependency
+type ChecksumError struct {
+	Name string
+	Want string
+	Got  string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s:\n\t%s: %s\n\tcontent:  %s", e.Name, SUM_FILE, e.Want, e.Got)
+}
+
+// Project is a directory holding a bolt.mod manifest, and the Bolt files under it
+//   - Dir: the root directory of the project
+//   - Manifest: the content of its bolt.mod
+//   - Sum: the content of its bolt.sum, empty if it has none yet
+type Project struct {
+	Dir      string
+	Manifest *Manifest
+	Sum      Sum
+}
+
+// Find the project holding a directory: the nearest one holding a bolt.mod, starting with dir and
+// going up, or nil if there is none
+func Find(dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, MANIFEST_FILE)); err == nil {
+			return Load(dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Load the project whose root is dir, reading its bolt.mod and, if there is one, its bolt.sum
+//   - Return an error if the manifest requires a newer version of the language, see Manifest.CheckVersion
+func Load(dir string) (*Project, error) {
+	data, err := os.ReadFile(filepath.Join(dir, MANIFEST_FILE))
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseManifest(filepath.Join(dir, MANIFEST_FILE), data)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.CheckVersion(); err != nil {
+		return nil, err
+	}
+
+	sum := Sum{}
+	data, err = os.ReadFile(filepath.Join(dir, SUM_FILE))
+	switch {
+	case err == nil:
+		if sum, err = ParseSum(filepath.Join(dir, SUM_FILE), data); err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+	return &Project{Dir: dir, Manifest: m, Sum: sum}, nil
+}
+
+// Create a project named name in dir, writing a bolt.mod for the current language version without dependencies
+//   - Return an error if dir already holds a bolt.mod
+func Init(dir, name string) (*Project, error) {
+	file := filepath.Join(dir, MANIFEST_FILE)
+	if _, err := os.Stat(file); err == nil {
+		return nil, fmt.Errorf("%s already exists", file)
+	}
+	p := &Project{Dir: dir, Manifest: &Manifest{Module: name, Bolt: LANGUAGE_VERSION}, Sum: Sum{}}
+	if err := os.WriteFile(file, p.Manifest.Format(), 0o644); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Write the bolt.mod and bolt.sum of the project, removing the bolt.sum if there are no dependencies
+func (p *Project) Save() error {
+	if err := os.WriteFile(filepath.Join(p.Dir, MANIFEST_FILE), p.Manifest.Format(), 0o644); err != nil {
+		return err
+	}
+	sumFile := filepath.Join(p.Dir, SUM_FILE)
+	if len(p.Sum) == 0 {
+		if err := os.Remove(sumFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(sumFile, p.Sum.Format(), 0o644)
+}
+
+// Return the path of a file of the project given as in the manifest, slash-separated and relative to its root
+func (p *Project) path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(p.Dir, filepath.FromSlash(name))
+}
+
+// Return the files of a dependency: those of a directory, or the content of a .zip archive
+func (p *Project) Dependency(r *Require) (fs.FS, error) {
+	file := p.path(r.Path)
+	if filepath.Ext(file) != ".zip" {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("dependency %s: %w", r.Name, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("dependency %s: %s is neither a directory nor a .zip archive", r.Name, r.Path)
+		}
+		return os.DirFS(file), nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("dependency %s: %w", r.Name, err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("dependency %s: %s: %w", r.Name, r.Path, err)
+	}
+	return archive, nil
+}
+
+// Return the files of a dependency, after checking that their hash is the one recorded in bolt.sum
+//   - Return a *ChecksumError if it is not, and an error if bolt.sum has no entry for the dependency
+func (p *Project) verified(r *Require) (fs.FS, error) {
+	want, ok := p.Sum[r.Name]
+	if !ok {
+		return nil, fmt.Errorf("missing %s entry for %s; run bolt mod tidy", SUM_FILE, r.Name)
+	}
+	fsys, err := p.Dependency(r)
+	if err != nil {
+		return nil, err
+	}
+	got, err := Hash(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("dependency %s: %w", r.Name, err)
+	}
+	if got != want {
+		return nil, &ChecksumError{Name: r.Name, Want: want, Got: got}
+	}
+	return fsys, nil
+}
+
+// Open the files of the project as a single filesystem, to load its modules from
+//   - The files of each dependency are found under its name, e.g. geometry/shapes.bolt, in place of
+//     any directory of the project with the same name
+//   - The content of each dependency is verified against bolt.sum first, see verified
+func (p *Project) Open() (fs.FS, error) {
+	mounts := map[string]fs.FS{}
+	for _, r := range p.Manifest.Require {
+		fsys, err := p.verified(r)
+		if err != nil {
+			return nil, err
+		}
+		mounts[r.Name] = fsys
+	}
+	return &mountFS{root: os.DirFS(p.Dir), mounts: mounts}, nil
+}
+
+// Remove the dependencies that are not imported by the project, and record the hash of those that are in bolt.sum
+//   - The imports of the .bolt files of the project are followed, along with those of the files of
+//     each dependency they import, in turn
+//   - Return the names of the dependencies removed
+//   - Return an error, without changing the project, if a file does not parse or imports a path
+//     that is neither relative, nor that of a dependency or of a file of the project
+func (p *Project) Tidy() ([]string, error) {
+	var problems []string
+	used := map[string]bool{}
+	var pending []*Require
+	dependencies := map[string]fs.FS{}
+
+	// the directories of dependencies inside the project are only scanned if they are used
+	skip := map[string]bool{VENDOR_DIR: true}
+	for _, r := range p.Manifest.Require {
+		if rel, err := filepath.Rel(p.Dir, p.path(r.Path)); err == nil {
+			skip[filepath.ToSlash(rel)] = true
+		}
+	}
+
+	root := os.DirFS(p.Dir)
+	scan := func(fsys fs.FS, prefix string) error {
+		return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			switch {
+			case err != nil:
+				return err
+			case d.IsDir() && prefix == "" && skip[name]:
+				return fs.SkipDir
+			case d.IsDir() || path.Ext(name) != module.EXTENSION:
+				return nil
+			}
+
+			imports, err := importsOf(fsys, name)
+			if err != nil {
+				problems = append(problems, path.Join(prefix, name)+":"+err.Error())
+				return nil
+			}
+			for _, imp := range imports {
+				importPath := imp.Path.Value
+				first, _, _ := strings.Cut(importPath, "/")
+				if strings.HasPrefix(importPath, "./") || strings.HasPrefix(importPath, "../") {
+					continue
+				}
+				if r := p.Manifest.Lookup(first); r != nil {
+					if !used[first] {
+						used[first] = true
+						pending = append(pending, r)
+					}
+					continue
+				}
+				file := importPath
+				if path.Ext(file) != module.EXTENSION {
+					file += module.EXTENSION
+				}
+				if _, err := fs.Stat(root, file); err != nil {
+					tok := imp.Path.Token
+					problems = append(problems, fmt.Sprintf("%s:%d:%d: no dependency or file of the project provides %q",
+						path.Join(prefix, name), tok.Line, tok.Column, importPath))
+				}
+			}
+			return nil
+		})
+	}
+
+	if err := scan(root, ""); err != nil {
+		return nil, err
+	}
+	for len(pending) != 0 {
+		r := pending[0]
+		pending = pending[1:]
+		fsys, err := p.Dependency(r)
+		if err != nil {
+			return nil, err
+		}
+		dependencies[r.Name] = fsys
+		if err := scan(fsys, r.Name); err != nil {
+			return nil, fmt.Errorf("dependency %s: %w", r.Name, err)
+		}
+	}
+	if len(problems) != 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+
+	var removed []string
+	var require []*Require
+	sum := Sum{}
+	for _, r := range p.Manifest.Require {
+		if !used[r.Name] {
+			removed = append(removed, r.Name)
+			continue
+		}
+		hash, err := Hash(dependencies[r.Name])
+		if err != nil {
+			return nil, fmt.Errorf("dependency %s: %w", r.Name, err)
+		}
+		require = append(require, r)
+		sum[r.Name] = hash
+	}
+	p.Manifest.Require = require
+	p.Sum = sum
+	return removed, p.Save()
+}
+
+// Copy each dependency that is not vendored yet into the vendor directory of the project as a .zip archive,
+// and require it from there, so that the project holds everything it needs
+//   - The content of each dependency is verified against bolt.sum first, and its hash is unchanged by vendoring
+//   - Return the names of the dependencies vendored
+func (p *Project) Vendor() ([]string, error) {
+	var vendored []string
+	for _, r := range p.Manifest.Require {
+		archive := VENDOR_DIR + "/" + r.Name + ".zip"
+		if r.Path == archive {
+			continue
+		}
+		fsys, err := p.verified(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(p.path(VENDOR_DIR), 0o755); err != nil {
+			return nil, err
+		}
+		if err := writeArchive(p.path(archive), fsys); err != nil {
+			return nil, fmt.Errorf("dependency %s: %w", r.Name, err)
+		}
+		r.Path = archive
+		vendored = append(vendored, r.Name)
+	}
+	if len(vendored) == 0 {
+		return nil, nil
+	}
+	return vendored, p.Save()
+}
+
+// Write the regular files of a filesystem to a .zip archive, in order and without timestamps,
+// so that the same files always give the same archive
+func writeArchive(file string, fsys fs.FS) error {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(file, buf.Bytes(), 0o644)
+}
+
+// Return the import statements of a .bolt file, or an error if it does not parse
+func importsOf(fsys fs.FS, name string) ([]*ast.ImportStatement, error) {
+	src, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if errs := p.ParseErrors(); len(errs) != 0 {
+		return nil, errs[0]
+	}
+
+	var imports []*ast.ImportStatement
+	for _, stmt := range program.Statements {
+		if imp, ok := stmt.(*ast.ImportStatement); ok {
+			imports = append(imports, imp)
+		}
+	}
+	return imports, nil
+}
+
+// mountFS is the filesystem of a project, with the files of each dependency under its name
+type mountFS struct {
+	root   fs.FS
+	mounts map[string]fs.FS
+}
+
+func (m *mountFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	first, rest, _ := strings.Cut(name, "/")
+	if fsys, ok := m.mounts[first]; ok {
+		if rest == "" {
+			rest = "."
+		}
+		return fsys.Open(rest)
+	}
+	return m.root.Open(name)
+}
@@ -0,0 +1,157 @@
+package project
+
+import (
+	"bolt/evaluator"
+	"bolt/lexer"
+	"bolt/module"
+	"bolt/object"
+	"bolt/parser"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Create a project under a new temporary directory, with dependencies in shared/ beside it,
+// and return its directory
+func newProject(t *testing.T, manifest string) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"app/bolt.mod":                manifest,
+		"app/main.bolt":               "import \"geometry/shapes\";\nimport \"lib/util\";\nreturn shapes.area(util.side);\n",
+		"app/lib/util.bolt":           "export let side = 4;",
+		"shared/geometry/shapes.bolt": "import \"text/text\";\nexport let area = fn(x) { x * x };\n",
+		"shared/text/text.bolt":       `export let hello = "hello";`,
+		"shared/unused/u.bolt":        "",
+	}
+	for name, src := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(root, "app")
+}
+
+const MANIFEST = "module app\nbolt 0.1\nrequire geometry ../shared/geometry\nrequire text ../shared/text\nrequire unused ../shared/unused\n"
+
+// Evaluate the main.bolt of a project loaded from the filesystem of the project
+func runMain(t *testing.T, p *Project) object.Object {
+	t.Helper()
+	fsys, err := p.Open()
+	if err != nil {
+		t.Fatalf("unexpected error opening the project: %s", err)
+	}
+	src, err := os.ReadFile(filepath.Join(p.Dir, "main.bolt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := object.NewEnvironment()
+	env.SetImporter(module.NewFSLoader(fsys, ".").Importer("."))
+	result := evaluator.Eval(parser.New(lexer.New(string(src))).ParseProgram(), env)
+	if rv, ok := result.(*object.ReturnValue); ok {
+		return rv.Value
+	}
+	return result
+}
+
+func TestTidyVendorAndOpen(t *testing.T) {
+	dir := newProject(t, MANIFEST)
+
+	p, err := Find(filepath.Join(dir, "lib"))
+	if err != nil || p == nil || p.Dir != dir {
+		t.Fatalf("wrong project found. got=%+v (%v)", p, err)
+	}
+	if _, err := p.Open(); err == nil || err.Error() != "missing bolt.sum entry for geometry; run bolt mod tidy" {
+		t.Errorf("wrong error opening a project without bolt.sum. got=%v", err)
+	}
+
+	removed, err := p.Tidy()
+	if err != nil || !reflect.DeepEqual(removed, []string{"unused"}) {
+		t.Fatalf("wrong result of tidy. got=%v (%v)", removed, err)
+	}
+	p, err = Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(p.Manifest.Require) != 2 || len(p.Sum) != 2 || p.Sum["text"] == "" {
+		t.Fatalf("wrong project after tidy. got=%+v, sum=%v", p.Manifest, p.Sum)
+	}
+	if result := runMain(t, p); result.Inspect() != "16" {
+		t.Errorf("wrong result of the project. got=%s", result.Inspect())
+	}
+
+	sum := Sum{"geometry": p.Sum["geometry"], "text": p.Sum["text"]}
+	vendored, err := p.Vendor()
+	if err != nil || !reflect.DeepEqual(vendored, []string{"geometry", "text"}) {
+		t.Fatalf("wrong result of vendor. got=%v (%v)", vendored, err)
+	}
+	// the vendored archives are used rather than the directories, with the same hashes
+	if err := os.RemoveAll(filepath.Join(dir, "..", "shared")); err != nil {
+		t.Fatal(err)
+	}
+	p, err = Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if p.Manifest.Lookup("geometry").Path != "vendor/geometry.zip" || !reflect.DeepEqual(p.Sum, sum) {
+		t.Errorf("wrong project after vendor. got=%+v, sum=%v", p.Manifest.Require, p.Sum)
+	}
+	if result := runMain(t, p); result.Inspect() != "16" {
+		t.Errorf("wrong result of the vendored project. got=%s", result.Inspect())
+	}
+	if vendored, err := p.Vendor(); err != nil || len(vendored) != 0 {
+		t.Errorf("expected nothing more to vendor. got=%v (%v)", vendored, err)
+	}
+
+	// a dependency changed after it was locked is refused
+	tampered := "geometry " + sum["geometry"] + "\ntext h1:changed=\n"
+	if err := os.WriteFile(filepath.Join(dir, SUM_FILE), []byte(tampered), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, _ = Load(dir)
+	var checksumErr *ChecksumError
+	if _, err := p.Open(); !errors.As(err, &checksumErr) || checksumErr.Name != "text" || checksumErr.Got != sum["text"] {
+		t.Errorf("wrong error opening a changed dependency. got=%v", err)
+	}
+}
+
+func TestTidyErrors(t *testing.T) {
+	dir := newProject(t, "module app\nbolt 0.1\nrequire geometry ../shared/geometry\n")
+	if err := os.WriteFile(filepath.Join(dir, "bad.bolt"), []byte("let = 1;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_, err = p.Tidy()
+	expected := "bad.bolt:1:5: expected next token to be IDENT, got = instead\n" +
+		`geometry/shapes.bolt:1:8: no dependency or file of the project provides "text/text"`
+	if err == nil || err.Error() != expected {
+		t.Errorf("wrong error of tidy.\nexpected=%q\ngot=%v", expected, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, SUM_FILE)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected tidy not to change a project with errors. got=%v", err)
+	}
+
+	if _, err := Init(dir, "again"); err == nil || !strings.HasSuffix(err.Error(), "bolt.mod already exists") {
+		t.Errorf("wrong error initializing a project twice. got=%v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, MANIFEST_FILE), []byte("module app\nbolt 9.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir); err == nil || err.Error() != "app requires bolt 9.0, but this is bolt "+LANGUAGE_VERSION {
+		t.Errorf("wrong error loading a project for a newer language. got=%v", err)
+	}
+	if p, err := Find(t.TempDir()); p != nil || err != nil {
+		t.Errorf("expected no project outside of one. got=%+v (%v)", p, err)
+	}
+}
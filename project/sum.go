@@ -0,0 +1,81 @@
+package project
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// The prefix of the hashes computed by Hash, naming the algorithm
+const HASH_PREFIX = "h1:"
+
+// Sum is the content of a bolt.sum file, the hash of the content of each dependency of a project
+// by name, see Hash
+//
+// A sum file has a line for each dependency, sorted by name:
+//
+//	geometry h1:K6vO1mGr2y+Yx1s6kVq2q4Yk3u0V0nqQ1s9X5Qe4mYc=
+type Sum map[string]string
+
+// Parse the content of a sum file, returning an error giving the line of the first problem found
+//   - file: the name of the sum file in errors
+func ParseSum(file string, data []byte) (Sum, error) {
+	sum := Sum{}
+	for i, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 || !validName(fields[0]) || !strings.HasPrefix(fields[1], HASH_PREFIX) {
+			return nil, fmt.Errorf("%s:%d: malformed line, want <name> %s<hash>", file, i+1, HASH_PREFIX)
+		}
+		if _, ok := sum[fields[0]]; ok {
+			return nil, fmt.Errorf("%s:%d: %s listed twice", file, i+1, fields[0])
+		}
+		sum[fields[0]] = fields[1]
+	}
+	return sum, nil
+}
+
+// Return the canonical content of the sum file, with its dependencies sorted by name
+func (s Sum) Format() []byte {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&out, "%s %s\n", name, s[name])
+	}
+	return out.Bytes()
+}
+
+// Return the hash of the content of a filesystem, such as a dependency, as "h1:" followed by
+// the base64 encoding of a SHA-256 hash
+//   - The hash covers the path and content of each regular file, so that it is the same for a
+//     directory and for an archive of it
+//   - Directories and other files, such as symbolic links, are not covered
+func Hash(fsys fs.FS) (string, error) {
+	summary := sha256.New()
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(summary, "%x  %s\n", sha256.Sum256(data), name)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return HASH_PREFIX + base64.StdEncoding.EncodeToString(summary.Sum(nil)), nil
+}
@@ -0,0 +1,56 @@
+package project
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseSum(t *testing.T) {
+	sum, err := ParseSum(SUM_FILE, []byte("text h1:b=\n\ngeometry h1:a=\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(sum, Sum{"geometry": "h1:a=", "text": "h1:b="}) {
+		t.Errorf("wrong sum. got=%v", sum)
+	}
+	if got := string(sum.Format()); got != "geometry h1:a=\ntext h1:b=\n" {
+		t.Errorf("wrong format. got=%q", got)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"geometry", "bolt.sum:1: malformed line, want <name> h1:<hash>"},
+		{"geometry sha:a=", "bolt.sum:1: malformed line, want <name> h1:<hash>"},
+		{"a h1:a=\na h1:b=", "bolt.sum:2: a listed twice"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseSum(SUM_FILE, []byte(tt.input)); err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	a := fstest.MapFS{"x.bolt": {Data: []byte("1")}, "dir/y.bolt": {Data: []byte("2")}}
+	b := fstest.MapFS{"dir/y.bolt": {Data: []byte("2")}, "x.bolt": {Data: []byte("1")}, "dir": {Mode: os.ModeDir}}
+	changed := fstest.MapFS{"x.bolt": {Data: []byte("1")}, "dir/y.bolt": {Data: []byte("3")}}
+	renamed := fstest.MapFS{"z.bolt": {Data: []byte("1")}, "dir/y.bolt": {Data: []byte("2")}}
+
+	hashA, err := Hash(a)
+	if err != nil || !strings.HasPrefix(hashA, HASH_PREFIX) {
+		t.Fatalf("wrong hash. got=%q (%v)", hashA, err)
+	}
+	if hashB, _ := Hash(b); hashB != hashA {
+		t.Errorf("expected the same files to have the same hash. got=%s and %s", hashA, hashB)
+	}
+	for _, fsys := range []fstest.MapFS{changed, renamed} {
+		if hash, _ := Hash(fsys); hash == hashA {
+			t.Errorf("expected different files to have a different hash. got=%s", hash)
+		}
+	}
+}
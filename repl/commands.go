@@ -0,0 +1,98 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// The prefix that marks a line of input as a REPL command rather than Bolt code
+const COMMAND_PREFIX = ":"
+
+// Usage and description of each REPL command, in the order they are listed by :help
+var commandHelp = []struct {
+	usage       string
+	description string
+}{
+	{":tokens", "show the tokens of each input"},
+	{":ast", "show the parsed program of each input"},
+	{":json", "show the parsed program of each input as JSON"},
+	{":eval", "evaluate each input and show the result (default)"},
+	{":bytecode", "unavailable: Bolt is interpreted and has no compiler yet"},
+	{":env", "list the bindings in the session"},
+	{":reset", "clear all bindings in the session"},
+	{":load <file>", "evaluate a Bolt source file in the session"},
+	{":help", "show this help"},
+	{":quit", "exit the REPL"},
+}
+
+// Determine whether a line of input is a REPL command
+func isCommand(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), COMMAND_PREFIX)
+}
+
+// Run a REPL command, writing its output to out
+//   - Return true if the REPL should exit
+func (s *Session) Command(line string, out io.Writer) bool {
+	fields := strings.Fields(strings.TrimSpace(line))
+	if len(fields) == 0 {
+		return false
+	}
+	name, args := fields[0], fields[1:]
+
+	switch name {
+	case ":tokens":
+		s.setMode(TOKENS_MODE, out)
+	case ":ast":
+		s.setMode(AST_MODE, out)
+	case ":json":
+		s.setMode(JSON_MODE, out)
+	case ":eval":
+		s.setMode(EVAL_MODE, out)
+	case ":bytecode":
+		fmt.Fprintln(out, "bytecode mode is unavailable: Bolt is interpreted and has no compiler yet")
+	case ":env":
+		for _, name := range s.env.Names() {
+			val, _ := s.env.Get(name)
+			fmt.Fprintf(out, "%s = %s\n", name, s.theme.Highlight(val.Inspect()))
+		}
+	case ":reset":
+		s.env = newEnvironment()
+		fmt.Fprintln(out, "session reset")
+	case ":load":
+		if len(args) != 1 {
+			fmt.Fprintln(out, "usage: :load <file>")
+			return false
+		}
+		s.load(args[0], out)
+	case ":help":
+		for _, c := range commandHelp {
+			fmt.Fprintf(out, "  %-14s %s\n", c.usage, c.description)
+		}
+	case ":quit", ":q":
+		return true
+	default:
+		fmt.Fprintf(out, "unknown command %s, type :help for a list of commands\n", name)
+	}
+
+	return false
+}
+
+// Switch the session to a new mode and confirm the switch
+func (s *Session) setMode(mode Mode, out io.Writer) {
+	s.mode = mode
+	fmt.Fprintf(out, "mode: %s\n", mode)
+}
+
+// Evaluate a Bolt source file in the session, whatever the current mode
+func (s *Session) load(file string, out io.Writer) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintln(out, s.theme.color("error", "ERROR: "+err.Error()))
+		return
+	}
+
+	evaluated, errors := s.Eval(string(src))
+	s.printResult(out, evaluated, errors)
+}
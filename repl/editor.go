@@ -0,0 +1,416 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupt is returned by ReadLine when the user abandons a line with Ctrl-C
+var ErrInterrupt = errors.New("interrupted")
+
+// LineReader reads a line of input after showing a prompt
+//   - Return io.EOF once there is no more input
+type LineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// scannerReader is the plain LineReader used when the input is not a terminal
+type scannerReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (r *scannerReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
+
+// Control keys understood by the Editor
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyCtrlJ     = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// Keys produced by terminal escape sequences, outside the range of valid runes
+const (
+	keyUp rune = -(iota + 1)
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDelete
+	keyUnknown
+)
+
+// Editor is a LineReader for terminals, supporting cursor movement, history and completion
+//   - Emacs-style keys: Ctrl-A/E to the start/end, Ctrl-B/F and arrows to move, Ctrl-K/U/W to delete
+//   - Up/Down (or Ctrl-P/N) browse the history, and Ctrl-R searches it in reverse
+//   - Tab completes the word before the cursor using Complete
+//   - The line is drawn through Highlight, if set
+//   - The terminal must already be in raw mode, or RawMode must be set to switch it for each line
+type Editor struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	History   *History
+	Complete  func(prefix string) []string // return the candidates for completing prefix
+	Highlight func(line string) string     // decorate the line for display without changing its width
+	RawMode   func() (restore func(), err error)
+
+	prompt string
+	buf    []rune // the line being edited
+	pos    int    // the cursor position in buf
+}
+
+// Create, initialize and return a new Editor reading keys from in and drawing to out
+func NewEditor(in io.Reader, out io.Writer) *Editor {
+	return &Editor{in: bufio.NewReader(in), out: out, History: &History{}}
+}
+
+// Read and edit a line of input
+//   - Enter submits the line, which is added to the history
+//   - Ctrl-C abandons the line, returning ErrInterrupt
+//   - Ctrl-D on an empty line returns io.EOF
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if e.RawMode != nil {
+		restore, err := e.RawMode()
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+
+	e.prompt, e.buf, e.pos = prompt, nil, 0
+	e.refresh()
+
+	history := e.History.Lines()
+	historyPos := len(history)
+	var saved []rune // the new line being edited while browsing the history
+	lastWasTab := false
+
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			if err == io.EOF && len(e.buf) > 0 {
+				return e.submit(), nil
+			}
+			return "", err
+		}
+
+		tab := key == keyTab
+		switch key {
+		case keyEnter, keyCtrlJ:
+			return e.submit(), nil
+
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupt
+
+		case keyCtrlD:
+			if len(e.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.deleteAt(e.pos)
+
+		case keyDelete:
+			e.deleteAt(e.pos)
+
+		case keyBackspace, keyCtrlH:
+			if e.pos > 0 {
+				e.pos--
+				e.deleteAt(e.pos)
+			}
+
+		case keyCtrlA, keyHome:
+			e.pos = 0
+		case keyCtrlE, keyEnd:
+			e.pos = len(e.buf)
+		case keyCtrlB, keyLeft:
+			if e.pos > 0 {
+				e.pos--
+			}
+		case keyCtrlF, keyRight:
+			if e.pos < len(e.buf) {
+				e.pos++
+			}
+
+		case keyCtrlK:
+			e.buf = e.buf[:e.pos]
+		case keyCtrlU:
+			e.buf = append([]rune{}, e.buf[e.pos:]...)
+			e.pos = 0
+		case keyCtrlW:
+			start := e.pos
+			for start > 0 && e.buf[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && e.buf[start-1] != ' ' {
+				start--
+			}
+			e.buf = append(e.buf[:start], e.buf[e.pos:]...)
+			e.pos = start
+
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+
+		case keyUp, keyCtrlP:
+			if historyPos > 0 {
+				if historyPos == len(history) {
+					saved = e.buf
+				}
+				historyPos--
+				e.setLine(history[historyPos])
+			}
+		case keyDown, keyCtrlN:
+			if historyPos < len(history) {
+				historyPos++
+				if historyPos == len(history) {
+					e.buf, e.pos = saved, len(saved)
+				} else {
+					e.setLine(history[historyPos])
+				}
+			}
+
+		case keyCtrlR:
+			if e.reverseSearch(history) {
+				return e.submit(), nil
+			}
+
+		case keyTab:
+			e.complete(lastWasTab)
+
+		default:
+			if key >= ' ' {
+				e.buf = append(e.buf[:e.pos], append([]rune{key}, e.buf[e.pos:]...)...)
+				e.pos++
+			}
+		}
+
+		lastWasTab = tab
+		e.refresh()
+	}
+}
+
+// Finish editing the line: move to the next line on screen and remember the line in the history
+func (e *Editor) submit() string {
+	fmt.Fprint(e.out, "\r\n")
+	line := string(e.buf)
+	e.History.Add(line)
+	return line
+}
+
+// Redraw the prompt and line, leaving the terminal cursor at the editing position
+func (e *Editor) refresh() {
+	line := string(e.buf)
+	if e.Highlight != nil {
+		line = e.Highlight(line)
+	}
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, line)
+	if n := len(e.buf) - e.pos; n > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", n)
+	}
+}
+
+// Replace the line being edited, placing the cursor at its end
+func (e *Editor) setLine(line string) {
+	e.buf = []rune(line)
+	e.pos = len(e.buf)
+}
+
+// Delete the rune at index i of the line, if there is one
+func (e *Editor) deleteAt(i int) {
+	if i < len(e.buf) {
+		e.buf = append(e.buf[:i], e.buf[i+1:]...)
+	}
+}
+
+// Read a key, decoding terminal escape sequences for the arrow, home, end and delete keys
+func (e *Editor) readKey() (rune, error) {
+	r, _, err := e.in.ReadRune()
+	if err != nil || r != keyEscape {
+		return r, err
+	}
+
+	next, _, err := e.in.ReadRune()
+	if err != nil {
+		return keyUnknown, err
+	}
+	if next != '[' && next != 'O' {
+		return keyUnknown, nil
+	}
+
+	// read the parameters and final byte of a control sequence, e.g. "3~" or "A"
+	var params strings.Builder
+	for {
+		b, _, err := e.in.ReadRune()
+		if err != nil {
+			return keyUnknown, err
+		}
+		if b >= 0x40 && b <= 0x7e {
+			return escapeKey(params.String(), b), nil
+		}
+		params.WriteRune(b)
+	}
+}
+
+// Map the parameters and final byte of a control sequence to a key
+func escapeKey(params string, final rune) rune {
+	switch final {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case 'C':
+		return keyRight
+	case 'D':
+		return keyLeft
+	case 'H':
+		return keyHome
+	case 'F':
+		return keyEnd
+	case '~':
+		switch params {
+		case "1", "7":
+			return keyHome
+		case "4", "8":
+			return keyEnd
+		case "3":
+			return keyDelete
+		}
+	}
+	return keyUnknown
+}
+
+// Search the history backwards for lines containing a query typed by the user
+//   - Typing extends the query, Backspace shortens it, and Ctrl-R finds the next older match
+//   - Enter submits the match, returning true
+//   - Ctrl-G or Escape cancels the search, restoring the line
+//   - Any other key keeps the match in the line for editing
+func (e *Editor) reverseSearch(history []string) bool {
+	original, originalPos := e.buf, e.pos
+	var query []rune
+	match := len(history)
+
+	// find the newest match at or before index from
+	search := func(from int) {
+		for i := from; i >= 0; i-- {
+			if i < len(history) && strings.Contains(history[i], string(query)) {
+				match = i
+				e.setLine(history[i])
+				return
+			}
+		}
+	}
+
+	for {
+		fmt.Fprintf(e.out, "\r(reverse-i-search)`%s': %s\x1b[K", string(query), string(e.buf))
+
+		key, err := e.readKey()
+		if err != nil {
+			e.buf, e.pos = original, originalPos
+			return false
+		}
+
+		switch key {
+		case keyEnter, keyCtrlJ:
+			return true
+		case keyCtrlG, keyCtrlC, keyEscape, keyUnknown:
+			e.buf, e.pos = original, originalPos
+			return false
+		case keyCtrlR:
+			search(match - 1)
+		case keyBackspace, keyCtrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				search(len(history) - 1)
+			}
+		default:
+			if key < ' ' {
+				return false
+			}
+			query = append(query, key)
+			search(match)
+		}
+	}
+}
+
+// Complete the word before the cursor
+//   - A single candidate is inserted in full
+//   - Several candidates are completed up to their common prefix, and listed if Tab is pressed twice
+func (e *Editor) complete(listCandidates bool) {
+	if e.Complete == nil {
+		return
+	}
+
+	start := e.pos
+	for start > 0 && isWordRune(e.buf[start-1]) {
+		start--
+	}
+	prefix := string(e.buf[start:e.pos])
+
+	candidates := e.Complete(prefix)
+	switch len(candidates) {
+	case 0:
+		fmt.Fprint(e.out, "\a")
+		return
+	case 1:
+		e.insert([]rune(candidates[0])[len([]rune(prefix)):])
+		return
+	}
+
+	common := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	if len(common) > len(prefix) {
+		e.insert([]rune(common)[len([]rune(prefix)):])
+		return
+	}
+
+	if listCandidates {
+		fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	}
+}
+
+// Insert runes at the cursor
+func (e *Editor) insert(runes []rune) {
+	e.buf = append(e.buf[:e.pos], append(runes, e.buf[e.pos:]...)...)
+	e.pos += len(runes)
+}
+
+// Determine whether a rune can be part of a completable word: identifiers, keywords and REPL commands
+func isWordRune(r rune) bool {
+	return r == '_' || r == ':' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
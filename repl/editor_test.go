@@ -0,0 +1,195 @@
+package repl
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditorReadLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{"plain", "let x = 5;\r", "let x = 5;"},
+		{"backspace", "leet\x7f\x7ft\r", "let"},
+		{"move and insert", "ac\x1b[Db\r", "abc"},
+		{"home and end", "bc\x01a\x05d\r", "abcd"},
+		{"home and end sequences", "bc\x1b[Ha\x1b[Fd\r", "abcd"},
+		{"delete", "abc\x01\x1b[3~\r", "bc"},
+		{"kill to end", "abcdef\x02\x02\x02\x0b\r", "abc"},
+		{"kill to start", "abcdef\x02\x02\x15\r", "ef"},
+		{"delete word", "let foo bar\x17\r", "let foo "},
+		{"ctrl-d deletes", "abc\x01\x04\r", "bc"},
+		{"unknown escape ignored", "a\x1b[5~b\r", "ab"},
+		{"end of input submits", "abc", "abc"},
+	}
+
+	for _, tt := range tests {
+		var out strings.Builder
+		e := NewEditor(strings.NewReader(tt.keys), &out)
+
+		line, err := e.ReadLine(PROMPT)
+		if err != nil {
+			t.Fatalf("%s: ReadLine returned error: %s", tt.name, err)
+		}
+		if line != tt.expected {
+			t.Errorf("%s: wrong line. expected=%q, got=%q", tt.name, tt.expected, line)
+		}
+	}
+}
+
+func TestEditorInterruptAndEOF(t *testing.T) {
+	var out strings.Builder
+	e := NewEditor(strings.NewReader("abc\x03\x04"), &out)
+
+	if _, err := e.ReadLine(PROMPT); err != ErrInterrupt {
+		t.Errorf("expected ErrInterrupt, got=%v", err)
+	}
+	if _, err := e.ReadLine(PROMPT); err != io.EOF {
+		t.Errorf("expected io.EOF, got=%v", err)
+	}
+}
+
+func TestEditorHistory(t *testing.T) {
+	var out strings.Builder
+	keys := "first\r" +
+		"second\r" +
+		"\x1b[A\x1b[A!\r" + // up twice recalls "first"
+		"draft\x1b[A\x1b[B\r" + // up then down restores the draft
+		"\x10\x10\x10\x0e\r" // ctrl-p three times then ctrl-n recalls "first!"
+	e := NewEditor(strings.NewReader(keys), &out)
+
+	expected := []string{"first", "second", "first!", "draft", "first!"}
+	for i, want := range expected {
+		line, err := e.ReadLine(PROMPT)
+		if err != nil {
+			t.Fatalf("line %d: ReadLine returned error: %s", i, err)
+		}
+		if line != want {
+			t.Errorf("line %d wrong. expected=%q, got=%q", i, want, line)
+		}
+	}
+}
+
+func TestEditorReverseSearch(t *testing.T) {
+	history := &History{}
+	for _, line := range []string{"let alpha = 1;", "let beta = 2;", "alpha + beta", "gamma"} {
+		history.Add(line)
+	}
+
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"\x12alp\r", "alpha + beta"},
+		{"\x12alp\x12\r", "let alpha = 1;"},
+		{"\x12bet\x7f\x7f\x7fgam\r", "gamma"},
+		{"x\x12beta\x07y\r", "xy"},
+		{"\x12beta\x1b[C;\r", "alpha + beta;"},
+	}
+
+	for _, tt := range tests {
+		var out strings.Builder
+		e := NewEditor(strings.NewReader(tt.keys), &out)
+		e.History = &History{lines: append([]string{}, history.Lines()...)}
+
+		line, err := e.ReadLine(PROMPT)
+		if err != nil {
+			t.Fatalf("%q: ReadLine returned error: %s", tt.keys, err)
+		}
+		if line != tt.expected {
+			t.Errorf("%q: wrong line. expected=%q, got=%q", tt.keys, tt.expected, line)
+		}
+	}
+}
+
+func TestEditorCompletion(t *testing.T) {
+	session := NewSession()
+	session.Eval("let total = 1; let totem = 2; let value = 3;")
+
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"re\t 1\r", "return 1"},
+		{"va\t\r", "value"},
+		{"tr\t\r", "true"},
+		{"tot\t\r", "tot"},
+		{"to\t\ta\t\r", "total"},
+		{"zz\t\r", "zz"},
+		{":he\t\r", ":help"},
+		{"1 + va\t\r", "1 + value"},
+	}
+
+	for _, tt := range tests {
+		var out strings.Builder
+		e := NewEditor(strings.NewReader(tt.keys), &out)
+		e.Complete = session.Complete
+
+		line, err := e.ReadLine(PROMPT)
+		if err != nil {
+			t.Fatalf("%q: ReadLine returned error: %s", tt.keys, err)
+		}
+		if line != tt.expected {
+			t.Errorf("%q: wrong line. expected=%q, got=%q", tt.keys, tt.expected, line)
+		}
+	}
+}
+
+func TestSessionComplete(t *testing.T) {
+	session := NewSession()
+	session.Eval("let foo = 1; let fab = 2;")
+
+	tests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{"f", []string{"fab", "false", "fn", "foo"}},
+		{"le", []string{"let"}},
+		{":r", []string{":reset"}},
+		{"q", nil},
+	}
+
+	for _, tt := range tests {
+		got := session.Complete(tt.prefix)
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Complete(%q) wrong. expected=%v, got=%v", tt.prefix, tt.expected, got)
+		}
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), HISTORY_FILE)
+
+	history, err := LoadHistory(file)
+	if err != nil {
+		t.Fatalf("LoadHistory returned error for a missing file: %s", err)
+	}
+	for _, line := range []string{"let a = 1;", "", "a", "a", "a + 1"} {
+		if err := history.Add(line); err != nil {
+			t.Fatalf("Add returned error: %s", err)
+		}
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("could not read history file: %s", err)
+	}
+	if string(data) != "let a = 1;\na\na + 1\n" {
+		t.Errorf("wrong history file contents. got=%q", data)
+	}
+
+	reloaded, err := LoadHistory(file)
+	if err != nil {
+		t.Fatalf("LoadHistory returned error: %s", err)
+	}
+	expected := []string{"let a = 1;", "a", "a + 1"}
+	if !reflect.DeepEqual(reloaded.Lines(), expected) {
+		t.Errorf("wrong reloaded history. expected=%v, got=%v", expected, reloaded.Lines())
+	}
+}
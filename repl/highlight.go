@@ -0,0 +1,159 @@
+package repl
+
+import (
+	"bolt/lexer"
+	"bolt/token"
+	"io"
+	"os"
+	"strings"
+)
+
+// The environment variable used to customize the colors of the REPL, e.g. BOLT_THEME="keyword=1;34:number=32"
+const THEME_ENV = "BOLT_THEME"
+
+// Theme maps each highlighting category to the ANSI SGR parameters used to color it
+//   - keyword, number, string, operator, illegal and comment color the tokens of Bolt code
+//   - error colors runtime error messages printed by the REPL
+type Theme map[string]string
+
+// DefaultTheme is the theme used when THEME_ENV does not override a category
+var DefaultTheme = Theme{
+	"keyword":  "1;35",
+	"number":   "36",
+	"string":   "32",
+	"operator": "33",
+	"illegal":  "1;31",
+	"comment":  "2",
+	"error":    "31",
+}
+
+// The highlighting category of each token type; token types not listed are left uncolored
+var tokenCategories = map[token.TokenType]string{
+	token.FUNCTION: "keyword",
+	token.LET:      "keyword",
+	token.IF:       "keyword",
+	token.ELSE:     "keyword",
+	token.RETURN:   "keyword",
+	token.TRUE:     "keyword",
+	token.FALSE:    "keyword",
+	token.IMPORT:   "keyword",
+	token.EXPORT:   "keyword",
+	token.AS:       "keyword",
+	token.INT:      "number",
+	token.STRING:   "string",
+	token.ASSIGN:   "operator",
+	token.PLUS:     "operator",
+	token.MINUS:    "operator",
+	token.BANG:     "operator",
+	token.ASTERISK: "operator",
+	token.SLASH:    "operator",
+	token.LT:       "operator",
+	token.GT:       "operator",
+	token.EQ:       "operator",
+	token.NOT_EQ:   "operator",
+	token.ILLEGAL:  "illegal",
+	token.COMMENT:  "comment",
+}
+
+// Build the theme from the default theme and the overrides in THEME_ENV
+//   - Overrides are colon-separated category=parameters pairs
+//   - Malformed pairs are ignored, and an empty parameter list turns a category's color off
+func ThemeFromEnv() Theme {
+	theme := Theme{}
+	for category, color := range DefaultTheme {
+		theme[category] = color
+	}
+
+	for _, pair := range strings.Split(os.Getenv(THEME_ENV), ":") {
+		category, color, ok := strings.Cut(pair, "=")
+		if !ok || strings.Trim(color, "0123456789;") != "" {
+			continue
+		}
+		theme[category] = color
+	}
+	return theme
+}
+
+// Determine whether colored output should be written to out
+//   - Color is disabled when NO_COLOR is set, see https://no-color.org
+//   - Color is disabled when out is not a terminal
+func colorEnabled(out io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	file, ok := out.(*os.File)
+	return ok && isTerminal(file.Fd())
+}
+
+// Wrap text in the color of a category, or return it unchanged if the category has no color
+func (t Theme) color(category, text string) string {
+	if code := t[category]; code != "" && text != "" {
+		return "\x1b[" + code + "m" + text + "\x1b[0m"
+	}
+	return text
+}
+
+// span is a colored range of the source, from start up to (but not including) end
+type span struct {
+	start, end int
+	category   string
+}
+
+// Highlight Bolt source by coloring each token according to the theme
+//   - The text of the source is unchanged, so cursor positions still line up
+//   - Runs of adjacent illegal tokens are colored as one, so that multi-byte characters are not split
+func (t Theme) Highlight(src string) string {
+	lineStarts := []int{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	offset := func(tok token.Token) int {
+		return lineStarts[tok.Line-1] + tok.Column - 1
+	}
+
+	var spans []span
+	add := func(tok token.Token) {
+		category, ok := tokenCategories[tok.Type]
+		if !ok {
+			return
+		}
+		start := offset(tok)
+		end := start + len(tok.Literal)
+		if n := len(spans); n > 0 && category == "illegal" && spans[n-1].category == category && spans[n-1].end == start {
+			spans[n-1].end = end
+			return
+		}
+		spans = append(spans, span{start: start, end: end, category: category})
+	}
+
+	l := lexer.New(src)
+	var tokens []token.Token
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		tokens = append(tokens, tok)
+	}
+
+	// merge the comments, which the lexer reports separately, into the tokens in source order
+	comments := l.Comments()
+	for len(tokens) > 0 || len(comments) > 0 {
+		if len(comments) == 0 || (len(tokens) > 0 && offset(tokens[0]) < offset(comments[0])) {
+			add(tokens[0])
+			tokens = tokens[1:]
+		} else {
+			add(comments[0])
+			comments = comments[1:]
+		}
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, s := range spans {
+		out.WriteString(src[last:s.start])
+		out.WriteString(t.color(s.category, src[s.start:s.end]))
+		last = s.end
+	}
+	out.WriteString(src[last:])
+
+	return out.String()
+}
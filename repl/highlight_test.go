@@ -0,0 +1,102 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	theme := Theme{"keyword": "K", "number": "N", "operator": "O", "illegal": "I", "comment": "C", "string": "S"}
+	color := func(code, text string) string { return "\x1b[" + code + "m" + text + "\x1b[0m" }
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"foo", "foo"},
+		{"let x = 5;", color("K", "let") + " x " + color("O", "=") + " " + color("N", "5") + ";"},
+		{"!true != false", color("O", "!") + color("K", "true") + " " + color("O", "!=") + " " + color("K", "false")},
+		{"a @ b", "a " + color("I", "@") + " b"},
+		{`import "m" as m`, color("K", "import") + " " + color("S", `"m"`) + " " + color("K", "as") + " m"},
+		{"é", color("I", "é")},
+		{"1 // one\n// two\n2", color("N", "1") + " " + color("C", "// one") + "\n" + color("C", "// two") + "\n" + color("N", "2")},
+	}
+
+	for _, tt := range tests {
+		if got := theme.Highlight(tt.input); got != tt.expected {
+			t.Errorf("Highlight(%q) wrong.\nexpected=%q\ngot=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	var plain Theme
+	if got := plain.Highlight("let x = 5;"); got != "let x = 5;" {
+		t.Errorf("nil theme changed the input. got=%q", got)
+	}
+}
+
+func TestThemeFromEnv(t *testing.T) {
+	t.Setenv(THEME_ENV, "keyword=1;34:number=:string=32:bogus:operator=red")
+
+	theme := ThemeFromEnv()
+
+	tests := []struct {
+		category string
+		expected string
+	}{
+		{"keyword", "1;34"},
+		{"number", ""},
+		{"string", "32"},
+		{"operator", DefaultTheme["operator"]},
+		{"illegal", DefaultTheme["illegal"]},
+	}
+
+	for _, tt := range tests {
+		if theme[tt.category] != tt.expected {
+			t.Errorf("theme[%q] wrong. expected=%q, got=%q", tt.category, tt.expected, theme[tt.category])
+		}
+	}
+}
+
+func TestColorDisabled(t *testing.T) {
+	var out bytes.Buffer
+	if colorEnabled(&out) {
+		t.Errorf("color enabled for a non-terminal writer")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabled(&out) {
+		t.Errorf("color enabled with NO_COLOR set")
+	}
+}
+
+func TestHighlightedOutput(t *testing.T) {
+	session := NewSession()
+	session.theme = Theme{"number": "N", "error": "E"}
+
+	var out bytes.Buffer
+	session.Handle("1 + 2", &out)
+	session.Handle("x", &out)
+	session.Handle("let = 1", &out)
+
+	expected := "\x1b[Nm3\x1b[0m\n" +
+		"\x1b[EmERROR: identifier not found: x\x1b[0m\n" +
+		"\t\x1b[Emexpected next token to be IDENT, got = instead\x1b[0m\n"
+	if !strings.HasPrefix(out.String(), expected) {
+		t.Errorf("wrong output.\nexpected prefix=%q\ngot=%q", expected, out.String())
+	}
+}
+
+func TestEditorHighlight(t *testing.T) {
+	var out strings.Builder
+	e := NewEditor(strings.NewReader("let\r"), &out)
+	e.Highlight = Theme{"keyword": "K"}.Highlight
+
+	if _, err := e.ReadLine(PROMPT); err != nil {
+		t.Fatalf("ReadLine returned error: %s", err)
+	}
+	if !strings.Contains(out.String(), PROMPT+"\x1b[Km"+"let\x1b[0m") {
+		t.Errorf("line was not highlighted. got=%q", out.String())
+	}
+}
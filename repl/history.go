@@ -0,0 +1,86 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// The name of the history file, kept in the user's home directory
+const HISTORY_FILE = ".bolt_history"
+
+// The maximum number of lines kept in memory and loaded from the history file
+const HISTORY_LIMIT = 1000
+
+// History holds the lines entered in the REPL, oldest first
+//   - lines: the remembered lines
+//   - file: the file each new line is appended to, or "" to keep history in memory only
+type History struct {
+	lines []string
+	file  string
+}
+
+// Create a History backed by a file, loading the lines already in it
+//   - A missing file is not an error; it is created when the first line is added
+func LoadHistory(file string) (*History, error) {
+	h := &History{file: file}
+
+	f, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		h.append(scanner.Text())
+	}
+	return h, scanner.Err()
+}
+
+// Return the path of the default history file, ~/.bolt_history, or "" if there is no home directory
+func DefaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, HISTORY_FILE)
+}
+
+// Remember a line, appending it to the history file
+//   - Empty lines and repeats of the previous line are ignored
+func (h *History) Add(line string) error {
+	if line == "" || (len(h.lines) > 0 && h.lines[len(h.lines)-1] == line) {
+		return nil
+	}
+	h.append(line)
+
+	if h.file == "" {
+		return nil
+	}
+	f, err := os.OpenFile(h.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(line + "\n")
+	return err
+}
+
+// Return the remembered lines, oldest first
+func (h *History) Lines() []string {
+	return h.lines
+}
+
+// Append a line in memory, dropping the oldest line once the limit is reached
+func (h *History) append(line string) {
+	h.lines = append(h.lines, line)
+	if len(h.lines) > HISTORY_LIMIT {
+		h.lines = h.lines[len(h.lines)-HISTORY_LIMIT:]
+	}
+}
@@ -4,32 +4,143 @@ import (
 	"bolt/lexer"
 	"bolt/token"
 	"bufio"
-	"fmt"
 	"io"
+	"os"
+	"strings"
 )
 
 // The REPL prompt is prepended to each input line and is used to indicate that the REPL is ready to accept input
 const PROMPT = "⚡️> "
 
+// The continuation prompt is prepended to each line of input that continues an incomplete statement
+const CONTINUATION_PROMPT = "... "
+
 // Start the Bolt REPL
-//   - Read input from the user
-//   - Tokenize the input
-//   - Print the tokens
+//   - Read input from the user, continuing over several lines until the input is complete
+//   - Run lines starting with COMMAND_PREFIX as REPL commands, see :help
+//   - Otherwise handle the input in a session that persists for as long as the REPL runs
+//   - Input and output are highlighted with the theme from THEME_ENV when writing to a terminal
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
+	session := NewSession()
+	if colorEnabled(out) {
+		session.theme = ThemeFromEnv()
+	}
+	reader := newLineReader(in, out, session)
 
 	for {
-		fmt.Fprint(out, PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
+		input, ok := readInput(reader)
+		if !ok {
 			return
 		}
 
-		line := scanner.Text()
-		l := lexer.New(line)
+		if isCommand(input) {
+			if session.Command(input, out) {
+				return
+			}
+			continue
+		}
+
+		session.Handle(input, out)
+	}
+}
+
+// Choose how to read lines of input
+//   - If both in and out are terminals, use an Editor with the history file, and completion and
+//     highlighting from the session
+//   - Otherwise read plain lines, e.g. when input is piped in
+func newLineReader(in io.Reader, out io.Writer, session *Session) LineReader {
+	inFile, inOk := in.(*os.File)
+	outFile, outOk := out.(*os.File)
+	if !inOk || !outOk || !isTerminal(inFile.Fd()) || !isTerminal(outFile.Fd()) {
+		return &scannerReader{scanner: bufio.NewScanner(in), out: out}
+	}
 
-		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
-			fmt.Fprintf(out, "%+v\n", tok)
+	editor := NewEditor(in, out)
+	editor.RawMode = func() (func(), error) { return makeRaw(inFile.Fd()) }
+	editor.Complete = session.Complete
+	editor.Highlight = session.theme.Highlight
+	if file := DefaultHistoryFile(); file != "" {
+		if history, err := LoadHistory(file); err == nil {
+			editor.History = history
 		}
 	}
+	return editor
+}
+
+// Read a complete piece of input, which may span several lines
+//   - Show the prompt, then the continuation prompt for as long as the input is incomplete
+//   - An empty continuation line submits the input as it is, so that mistakes can be abandoned
+//   - REPL commands are always a single line
+//   - Ctrl-C abandons the input and starts again
+//   - Return false if the input ends before anything was read
+func readInput(reader LineReader) (string, bool) {
+	var lines []string
+	prompt := PROMPT
+
+	for {
+		line, err := reader.ReadLine(prompt)
+		if err == ErrInterrupt {
+			lines, prompt = nil, PROMPT
+			continue
+		}
+		if err != nil {
+			return strings.Join(lines, "\n"), len(lines) > 0
+		}
+
+		if len(lines) > 0 && strings.TrimSpace(line) == "" {
+			return strings.Join(lines, "\n"), true
+		}
+		lines = append(lines, line)
+
+		if len(lines) == 1 && isCommand(line) {
+			return line, true
+		}
+
+		input := strings.Join(lines, "\n")
+		if !incomplete(input) {
+			return input, true
+		}
+		prompt = CONTINUATION_PROMPT
+	}
+}
+
+// Tokens that cannot end a statement because they expect an operand or member to follow
+var continuationTokens = map[token.TokenType]bool{
+	token.ASSIGN:   true,
+	token.PLUS:     true,
+	token.MINUS:    true,
+	token.BANG:     true,
+	token.ASTERISK: true,
+	token.SLASH:    true,
+	token.LT:       true,
+	token.GT:       true,
+	token.EQ:       true,
+	token.NOT_EQ:   true,
+	token.COMMA:    true,
+	token.COLON:    true,
+	token.ARROW:    true,
+	token.DOT:      true,
+}
+
+// Determine whether input is an incomplete statement that continues on the next line
+//   - An opening parenthesis or brace has not been closed
+//   - The last token is an operator or comma still waiting for its right-hand side
+//   - The last token is a string that has not been closed
+func incomplete(input string) bool {
+	l := lexer.New(input)
+	depth := 0
+	last := token.Token{Type: token.EOF}
+
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACE:
+			depth--
+		}
+		last = tok
+	}
+
+	unterminated := last.Type == token.ILLEGAL && strings.HasPrefix(last.Literal, `"`)
+	return depth > 0 || continuationTokens[last.Type] || unterminated
 }
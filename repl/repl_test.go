@@ -0,0 +1,156 @@
+package repl
+
+import (
+	"bolt/evaluator"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"let x = 5;", false},
+		{"x", false},
+		{"", false},
+		{"let add = fn(x, y) {", true},
+		{"let add = fn(x, y) {\n  x + y;\n};", false},
+		{"add(1,", true},
+		{"(1 + (2", true},
+		{"(1 + 2))", false},
+		{"let x =", true},
+		{"1 +", true},
+		{"1 + // more to come", true},
+		{"a ==", true},
+		{"!", true},
+		{"let f = fn(x: int) ->", true},
+		{`let s = "abc`, true},
+		{`let s = "a\"bc`, true},
+		{`let s = "abc";`, false},
+		{"@", false},
+		{"math.", true},
+		{"math.\npi", false},
+	}
+
+	for _, tt := range tests {
+		if got := incomplete(tt.input); got != tt.expected {
+			t.Errorf("incomplete(%q) wrong. expected=%t, got=%t", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestStartMultiLine(t *testing.T) {
+	input := "1 +\n2;\n(3\n\nx\n"
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	expected := PROMPT + CONTINUATION_PROMPT + "3\n" +
+		PROMPT + CONTINUATION_PROMPT + "\texpected next token to be ), got EOF instead\n" +
+		PROMPT + "ERROR: identifier not found: x\n" +
+		PROMPT
+
+	if out.String() != expected {
+		t.Errorf("wrong output.\nexpected=%q\ngot=%q", expected, out.String())
+	}
+}
+
+func TestStartCallDepthLimit(t *testing.T) {
+	input := "let f = fn() { f() };\nf()\n1\n"
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	expected := PROMPT + PROMPT + fmt.Sprintf("ERROR: call depth limit of %d exceeded\n", evaluator.DEFAULT_MAX_DEPTH) +
+		PROMPT + "1\n" + PROMPT
+	if out.String() != expected {
+		t.Errorf("wrong output.\nexpected=%q\ngot=%q", expected, out.String())
+	}
+}
+
+func TestStartKeepsBindings(t *testing.T) {
+	input := "let x = 5;\nlet y = x * 2;\nx + y\nlet x = 1;\nx + y\n"
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	expected := PROMPT + PROMPT + PROMPT + "15\n" + PROMPT + PROMPT + "11\n" + PROMPT
+
+	if out.String() != expected {
+		t.Errorf("wrong output.\nexpected=%q\ngot=%q", expected, out.String())
+	}
+}
+
+func TestSessionEval(t *testing.T) {
+	session := NewSession()
+
+	if _, errors := session.Eval("let a = 2;"); len(errors) != 0 {
+		t.Fatalf("unexpected parser errors: %v", errors)
+	}
+	if _, errors := session.Eval("let = 3;"); len(errors) == 0 {
+		t.Fatalf("expected parser errors")
+	}
+
+	evaluated, errors := session.Eval("a * 21")
+	if len(errors) != 0 {
+		t.Fatalf("unexpected parser errors: %v", errors)
+	}
+	if evaluated == nil || evaluated.Inspect() != "42" {
+		t.Errorf("wrong result. expected=42, got=%v", evaluated)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "lib.bolt")
+	if err := os.WriteFile(file, []byte("let z = 7;\nz * 6"), 0o644); err != nil {
+		t.Fatalf("could not write file: %s", err)
+	}
+
+	input := strings.Join([]string{
+		"let y = 2;",
+		":tokens",
+		"y;",
+		":ast",
+		"1 + 2 * 3",
+		":json",
+		"x",
+		":eval",
+		":load " + file,
+		":env",
+		":bytecode",
+		":reset",
+		":env",
+		":nope",
+		":quit",
+		"1",
+	}, "\n")
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	expected := PROMPT +
+		PROMPT + "mode: tokens\n" +
+		PROMPT + "{Type:IDENT Literal:y Line:1 Column:1}\n{Type:; Literal:; Line:1 Column:2}\n" +
+		PROMPT + "mode: ast\n" +
+		PROMPT + "(1 + (2 * 3))\n" +
+		PROMPT + "mode: json\n" +
+		PROMPT + `{"kind":"Program","statements":[{"kind":"ExpressionStatement","token":{"type":"IDENT","literal":"x","line":1,"column":1},` +
+		`"expression":{"kind":"Identifier","token":{"type":"IDENT","literal":"x","line":1,"column":1},"value":"x"}}]}` + "\n" +
+		PROMPT + "mode: eval\n" +
+		PROMPT + "42\n" +
+		PROMPT + "y = 2\nz = 7\n" +
+		PROMPT + "bytecode mode is unavailable: Bolt is interpreted and has no compiler yet\n" +
+		PROMPT + "session reset\n" +
+		PROMPT +
+		PROMPT + "unknown command :nope, type :help for a list of commands\n" +
+		PROMPT
+
+	if out.String() != expected {
+		t.Errorf("wrong output.\nexpected=%q\ngot=%q", expected, out.String())
+	}
+}
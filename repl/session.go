@@ -0,0 +1,139 @@
+package repl
+
+import (
+	"bolt/ast"
+	"bolt/evaluator"
+	"bolt/lexer"
+	"bolt/object"
+	"bolt/parser"
+	"bolt/token"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Mode selects what the REPL shows for each piece of input
+type Mode string
+
+const (
+	TOKENS_MODE Mode = "tokens" // print the tokens produced by the lexer
+	AST_MODE    Mode = "ast"    // print the parsed program
+	JSON_MODE   Mode = "json"   // print the parsed program as JSON
+	EVAL_MODE   Mode = "eval"   // evaluate the input and print the result
+)
+
+// Session holds the state that lives for the whole of a REPL session
+//   - env: the environment that every input is evaluated in, so bindings persist between inputs
+//   - mode: what the REPL shows for each input
+//   - theme: the colors used for output, or nil for plain output
+type Session struct {
+	env   *object.Environment
+	mode  Mode
+	theme Theme
+}
+
+// Create, initialize and return a new Session with an empty environment, evaluating input
+func NewSession() *Session {
+	return &Session{env: newEnvironment(), mode: EVAL_MODE}
+}
+
+// Create the environment of a session, limiting the depth of calls so that runaway recursion
+// is an error rather than a Go stack overflow
+func newEnvironment() *object.Environment {
+	env := object.NewEnvironment()
+	env.SetLimits(&object.Limits{MaxDepth: evaluator.DEFAULT_MAX_DEPTH})
+	return env
+}
+
+// Evaluate a piece of input in the session's environment
+//   - If the input cannot be parsed, return the parser errors and leave the environment untouched
+//   - Otherwise return the result of evaluating the input, which is nil for inputs such as let statements
+func (s *Session) Eval(input string) (object.Object, []string) {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, p.Errors()
+	}
+
+	return evaluator.Eval(program, s.env), nil
+}
+
+// Handle a piece of input according to the session's mode, writing the output to out
+//   - Only the eval mode evaluates the input; the other modes only show how it is lexed or parsed
+func (s *Session) Handle(input string, out io.Writer) {
+	switch s.mode {
+	case TOKENS_MODE:
+		l := lexer.New(input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+			fmt.Fprintf(out, "%+v\n", tok)
+		}
+
+	case AST_MODE, JSON_MODE:
+		p := parser.New(lexer.New(input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			s.printParserErrors(out, p.Errors())
+			return
+		}
+		if s.mode == AST_MODE {
+			fmt.Fprintln(out, s.theme.Highlight(program.String()))
+			return
+		}
+		data, err := ast.MarshalJSON(program)
+		if err != nil {
+			fmt.Fprintln(out, s.theme.color("error", "ERROR: "+err.Error()))
+			return
+		}
+		fmt.Fprintln(out, string(data))
+
+	default:
+		evaluated, errors := s.Eval(input)
+		s.printResult(out, evaluated, errors)
+	}
+}
+
+// Print the result of evaluating input, or the parser errors if it could not be parsed
+//   - Errors are printed in the theme's error color, and other values are highlighted like code
+func (s *Session) printResult(out io.Writer, evaluated object.Object, errors []string) {
+	if len(errors) != 0 {
+		s.printParserErrors(out, errors)
+		return
+	}
+
+	switch evaluated := evaluated.(type) {
+	case nil:
+	case *object.Error:
+		fmt.Fprintln(out, s.theme.color("error", evaluated.Inspect()))
+	default:
+		fmt.Fprintln(out, s.theme.Highlight(evaluated.Inspect()))
+	}
+}
+
+// Return the keywords, bound identifiers and REPL commands that start with prefix, for tab completion
+func (s *Session) Complete(prefix string) []string {
+	var words []string
+	if strings.HasPrefix(prefix, COMMAND_PREFIX) {
+		for _, c := range commandHelp {
+			words = append(words, strings.Fields(c.usage)[0])
+		}
+	} else {
+		words = append(token.Keywords(), s.env.Names()...)
+	}
+
+	var candidates []string
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) {
+			candidates = append(candidates, word)
+		}
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+// Print each parser error on its own line
+func (s *Session) printParserErrors(out io.Writer, errors []string) {
+	for _, msg := range errors {
+		fmt.Fprintf(out, "\t%s\n", s.theme.color("error", msg))
+	}
+}
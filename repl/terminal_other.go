@@ -0,0 +1,14 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package repl
+
+import "errors"
+
+// Terminals are not supported on this platform, so the REPL always reads plain lines
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func makeRaw(fd uintptr) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
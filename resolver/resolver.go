@@ -0,0 +1,274 @@
+package resolver
+
+import (
+	"bolt/ast"
+	"bolt/token"
+	"fmt"
+	"sort"
+)
+
+// Declaration is a name declared in a scope
+//   - Ident: the identifier of the let statement, import or parameter declaring the name, or nil for a predeclared name
+//   - Node: the *ast.LetStatement, the *ast.ImportStatement or, for a parameter, the *ast.FunctionLiteral
+//     declaring the name, or nil for a predeclared name
+//   - Scope: the scope the name is declared in
+type Declaration struct {
+	Name  string
+	Ident *ast.Identifier
+	Node  ast.Node
+	Scope *Scope
+}
+
+// Scope maps names to their declarations, falling back to the enclosing scope for names it does not declare
+type Scope struct {
+	parent       *Scope
+	declarations map[string]*Declaration
+}
+
+// Create, initialize and return a new, empty Scope nested in parent, which may be nil
+func NewScope(parent *Scope) *Scope {
+	return &Scope{parent: parent, declarations: map[string]*Declaration{}}
+}
+
+// Create a scope holding predeclared names, such as those bound by the host before a program runs
+func Predeclared(names ...string) *Scope {
+	s := NewScope(nil)
+	for _, name := range names {
+		s.declarations[name] = &Declaration{Name: name, Scope: s}
+	}
+	return s
+}
+
+// Return the enclosing scope, or nil for the outermost scope
+func (s *Scope) Parent() *Scope {
+	return s.parent
+}
+
+// Return the declaration of a name in this scope or the nearest enclosing scope declaring it, or nil if there is none
+func (s *Scope) Lookup(name string) *Declaration {
+	for ; s != nil; s = s.parent {
+		if d, ok := s.declarations[name]; ok {
+			return d
+		}
+	}
+	return nil
+}
+
+// Return the names declared in this scope, not counting enclosing scopes, in sorted order
+func (s *Scope) Names() []string {
+	names := make([]string, 0, len(s.declarations))
+	for name := range s.declarations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Error describes a name that cannot be resolved or declared
+//   - Token: the identifier at which the problem was detected, giving its position
+//   - Message: a description of the problem
+type Error struct {
+	Token   token.Token
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Token.Line, e.Token.Column, e.Message)
+}
+
+// Info is the result of resolving a program
+//   - Uses: the declaration each identifier in an expression refers to; unresolved identifiers are absent
+//   - Declarations: the declarations made by the program, in source order
+//   - Scope: the scope of the program, nested in the scope given to Resolve
+//   - Errors: the problems found, in source order
+type Info struct {
+	Uses         map[*ast.Identifier]*Declaration
+	Declarations []*Declaration
+	Scope        *Scope
+	Errors       []*Error
+}
+
+// Resolve the names of a program, linking each identifier to its declaration
+//   - The program is a scope nested in outer, which holds names declared outside of it and may be nil
+//   - Each function literal is a scope holding its parameters and the names declared in its body,
+//     and each block of an if expression is a scope of its own
+//   - The value of a let statement is resolved before its name is declared, so it cannot refer to itself;
+//     the body of a function only runs once it is called, so it is resolved at the end of the scope the
+//     function is created in, and may refer to names declared after it there, including its own
+//   - An import declares the name of its module; the members selected from a module, as in mod.name,
+//     are not resolved, since they name its exports rather than declarations in scope
+//   - Report identifiers that are not declared, identifiers used before a later declaration in scope,
+//     and names declared twice in the same scope; a redeclared name refers to its latest declaration from then on
+//   - Report imports and exported let statements that are not at the top level of the program
+func Resolve(program *ast.Program, outer *Scope) *Info {
+	r := &resolver{info: &Info{Uses: map[*ast.Identifier]*Declaration{}}}
+	r.scope = NewScope(outer)
+	r.info.Scope = r.scope
+
+	r.statements(program.Statements)
+
+	// the bodies of functions are resolved after the statements around them
+	sort.SliceStable(r.info.Declarations, func(i, j int) bool {
+		return before(r.info.Declarations[i].Ident.Token, r.info.Declarations[j].Ident.Token)
+	})
+	sort.SliceStable(r.info.Errors, func(i, j int) bool {
+		return before(r.info.Errors[i].Token, r.info.Errors[j].Token)
+	})
+	return r.info
+}
+
+// resolver holds the state of Resolve
+//   - pending: for the current scope and each enclosing one, innermost last, the first declaration
+//     of each name still to be declared in it
+//   - functions: the function literals created in the current scope, whose bodies are still to be resolved
+type resolver struct {
+	info      *Info
+	scope     *Scope
+	pending   []map[string]*ast.Identifier
+	functions []*ast.FunctionLiteral
+}
+
+// Resolve the statements of the current scope, followed by the bodies of the functions created in it
+func (r *resolver) statements(stmts []ast.Statement) {
+	// the names declared later in the scope, to tell a use before its declaration from an undefined name
+	pending := map[string]*ast.Identifier{}
+	for i := len(stmts) - 1; i >= 0; i-- {
+		switch stmt := stmts[i].(type) {
+		case *ast.LetStatement:
+			if stmt.Name != nil {
+				pending[stmt.Name.Value] = stmt.Name
+			}
+		case *ast.ImportStatement:
+			if stmt.Name != nil {
+				pending[stmt.Name.Value] = stmt.Name
+			}
+		}
+	}
+	r.pending = append(r.pending, pending)
+	functions := r.functions
+	r.functions = nil
+
+	for _, stmt := range stmts {
+		r.statement(stmt)
+	}
+	for i := 0; i < len(r.functions); i++ {
+		r.function(r.functions[i])
+	}
+
+	r.pending = r.pending[:len(r.pending)-1]
+	r.functions = functions
+}
+
+func (r *resolver) statement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		if stmt.Exported() && !r.topLevel() {
+			r.errorf(stmt.Export, "export is only allowed at the top level of a program")
+		}
+		if stmt.Value != nil {
+			r.uses(stmt.Value)
+		}
+		if stmt.Name != nil {
+			r.declare(stmt.Name, stmt)
+		}
+
+	case *ast.ImportStatement:
+		if !r.topLevel() {
+			r.errorf(stmt.Token, "import is only allowed at the top level of a program")
+		}
+		if stmt.Name != nil {
+			r.declare(stmt.Name, stmt)
+		}
+
+	default:
+		r.uses(stmt)
+	}
+}
+
+// Determine whether the statements being resolved are those of the program, rather than of a block or function
+func (r *resolver) topLevel() bool {
+	return len(r.pending) == 1
+}
+
+// Resolve the body of a function in a new scope holding its parameters
+func (r *resolver) function(fn *ast.FunctionLiteral) {
+	outer := r.scope
+	r.scope = NewScope(outer)
+	defer func() { r.scope = outer }()
+
+	for _, param := range fn.Parameters {
+		r.declare(param.Name, fn)
+	}
+	if fn.Body != nil {
+		r.statements(fn.Body.Statements)
+	}
+}
+
+// Resolve the statements of a block in a new scope
+func (r *resolver) block(block *ast.BlockStatement) {
+	outer := r.scope
+	r.scope = NewScope(outer)
+	defer func() { r.scope = outer }()
+
+	r.statements(block.Statements)
+}
+
+// Resolve the identifiers used in a node, leaving the bodies of functions until the end of the scope
+func (r *resolver) uses(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionLiteral:
+			r.functions = append(r.functions, n)
+			return false
+		case *ast.BlockStatement:
+			r.block(n)
+			return false
+		case *ast.MemberExpression:
+			if n.Object != nil {
+				r.uses(n.Object)
+			}
+			return false
+		case *ast.Identifier:
+			r.use(n)
+		}
+		return true
+	})
+}
+
+// Resolve an identifier used in an expression
+func (r *resolver) use(ident *ast.Identifier) {
+	if d := r.scope.Lookup(ident.Value); d != nil {
+		r.info.Uses[ident] = d
+		return
+	}
+	for i := len(r.pending) - 1; i >= 0; i-- {
+		if later := r.pending[i][ident.Value]; later != nil {
+			r.errorf(ident.Token, "%s used before its declaration at %d:%d", ident.Value, later.Token.Line, later.Token.Column)
+			return
+		}
+	}
+	r.errorf(ident.Token, "identifier not found: %s", ident.Value)
+}
+
+// Declare a name in the current scope
+//   - node: the let statement, import or function literal declaring the name
+func (r *resolver) declare(ident *ast.Identifier, node ast.Node) {
+	if previous, ok := r.scope.declarations[ident.Value]; ok && previous.Ident != nil {
+		r.errorf(ident.Token, "%s redeclared in this scope, previous declaration at %d:%d",
+			ident.Value, previous.Ident.Token.Line, previous.Ident.Token.Column)
+	}
+
+	d := &Declaration{Name: ident.Value, Ident: ident, Node: node, Scope: r.scope}
+	r.scope.declarations[ident.Value] = d
+	r.info.Declarations = append(r.info.Declarations, d)
+	delete(r.pending[len(r.pending)-1], ident.Value)
+}
+
+func (r *resolver) errorf(tok token.Token, format string, a ...interface{}) {
+	r.info.Errors = append(r.info.Errors, &Error{Token: tok, Message: fmt.Sprintf(format, a...)})
+}
+
+// Determine whether token a comes before token b in the source
+func before(a, b token.Token) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+}
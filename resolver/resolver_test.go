@@ -0,0 +1,155 @@
+package resolver
+
+import (
+	"bolt/ast"
+	"bolt/lexer"
+	"bolt/parser"
+	"reflect"
+	"testing"
+)
+
+func resolve(t *testing.T, input string, outer *Scope) (*ast.Program, *Info) {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program, Resolve(program, outer)
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; x + 1;", []string{}},
+		{"ARGS;", []string{}},
+		{"y;", []string{"1:1: identifier not found: y"}},
+		{"let x = 1; x + y * z;", []string{"1:16: identifier not found: y", "1:20: identifier not found: z"}},
+		{"x;\nlet x = 1;", []string{"1:1: x used before its declaration at 2:5"}},
+		{"let x = x;", []string{"1:9: x used before its declaration at 1:5"}},
+		{"let x = 1;\nlet x = x + 1;", []string{"2:5: x redeclared in this scope, previous declaration at 1:5"}},
+		{"let ARGS = 1; ARGS;", []string{}},
+		{"let f = fn(a, b) { let c = a + b; c }; f(1, 2);", []string{}},
+		{"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } };", []string{}},
+		{"let even = fn(n) { odd(n) };\nlet odd = fn(n) { even(n) };", []string{}},
+		{"let f = fn(a) { a };\na;", []string{"2:1: identifier not found: a"}},
+		{"if (true) { let z = 1; };\nz;", []string{"2:1: identifier not found: z"}},
+		{"let x = 1; if (x) { let x = 2; x };", []string{}},
+		{"let f = fn(a, a) { let a = 1; b };", []string{
+			"1:15: a redeclared in this scope, previous declaration at 1:12",
+			"1:24: a redeclared in this scope, previous declaration at 1:15",
+			"1:31: identifier not found: b",
+		}},
+		{"if (true) { g() };\nlet g = fn() { 1 };", []string{"1:13: g used before its declaration at 2:5"}},
+		{"let f = fn() { y; let y = 1; };", []string{"1:16: y used before its declaration at 1:23"}},
+		{`import "lib/math"; math.max(math.min);`, []string{}},
+		{"m.x;\nimport \"lib/m\" as m;", []string{"1:1: m used before its declaration at 2:19"}},
+		{`import "a/m"; import "b/m";`, []string{"1:22: m redeclared in this scope, previous declaration at 1:8"}},
+		{"export let x = 1; x.y.z;", []string{}},
+		{"let f = fn() { export let x = 1; import \"m\"; m };", []string{
+			"1:16: export is only allowed at the top level of a program",
+			"1:34: import is only allowed at the top level of a program",
+		}},
+	}
+
+	for _, tt := range tests {
+		_, info := resolve(t, tt.input, Predeclared("ARGS"))
+
+		errors := []string{}
+		for _, err := range info.Errors {
+			errors = append(errors, err.Error())
+		}
+		if !reflect.DeepEqual(errors, tt.expected) {
+			t.Errorf("wrong errors for %q.\nexpected=%q\ngot=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestResolveUses(t *testing.T) {
+	program, info := resolve(t, "let a = N;\nlet b = a + a;\nlet a = b;\na;", Predeclared("N"))
+
+	if len(info.Declarations) != 3 {
+		t.Fatalf("wrong number of declarations. expected=3, got=%d", len(info.Declarations))
+	}
+	firstA, b, secondA := info.Declarations[0], info.Declarations[1], info.Declarations[2]
+
+	// the identifiers used in expressions, in source order
+	var uses []*ast.Identifier
+	for _, stmt := range program.Statements {
+		node := ast.Node(stmt)
+		if let, ok := stmt.(*ast.LetStatement); ok {
+			node = let.Value
+		}
+		ast.Inspect(node, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Identifier); ok {
+				uses = append(uses, ident)
+			}
+			return true
+		})
+	}
+
+	expected := []*Declaration{nil, firstA, firstA, b, secondA}
+	if len(uses) != len(expected) {
+		t.Fatalf("wrong number of uses. expected=%d, got=%d", len(expected), len(uses))
+	}
+	for i, use := range uses {
+		got := info.Uses[use]
+		if i == 0 {
+			if got == nil || got.Ident != nil || got.Name != "N" {
+				t.Errorf("uses[0] must refer to the predeclared N. got=%+v", got)
+			}
+			continue
+		}
+		if got != expected[i] {
+			t.Errorf("uses[%d] (%s at %d:%d) refers to the wrong declaration", i, use.Value, use.Token.Line, use.Token.Column)
+		}
+	}
+
+	if names := info.Scope.Names(); !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Errorf("wrong names in the program scope. got=%q", names)
+	}
+	if info.Scope.Parent().Lookup("a") != nil {
+		t.Errorf("declarations of the program leaked into the outer scope")
+	}
+}
+
+func TestResolveFunctions(t *testing.T) {
+	program, info := resolve(t, "let x = 1;\nlet f = fn(x) { let y = x; fn() { x + y + f } };", nil)
+
+	if len(info.Declarations) != 4 {
+		t.Fatalf("wrong number of declarations. expected=4, got=%d", len(info.Declarations))
+	}
+	names := []string{}
+	for _, d := range info.Declarations {
+		names = append(names, d.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"x", "f", "x", "y"}) {
+		t.Errorf("declarations are not in source order. got=%q", names)
+	}
+	outerX, f, param, y := info.Declarations[0], info.Declarations[1], info.Declarations[2], info.Declarations[3]
+
+	fn := program.Statements[1].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	if param.Node != fn || f.Node != program.Statements[1] {
+		t.Errorf("declarations have the wrong nodes. param=%T, f=%T", param.Node, f.Node)
+	}
+	if param.Scope == info.Scope || param.Scope.Parent() != info.Scope || y.Scope != param.Scope {
+		t.Errorf("the parameter and body of a function must share a scope nested in the program scope")
+	}
+
+	expected := map[string]*Declaration{"x": param, "y": y, "f": f}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Identifier); ok && ident != y.Ident {
+			if info.Uses[ident] != expected[ident.Value] {
+				t.Errorf("%s at %d:%d refers to the wrong declaration", ident.Value, ident.Token.Line, ident.Token.Column)
+			}
+		}
+		return true
+	})
+	for _, d := range info.Uses {
+		if d == outerX {
+			t.Errorf("the parameter x must shadow the outer x")
+		}
+	}
+}
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bolt/ast"
+	"bolt/evaluator"
+	"bolt/lexer"
+	"bolt/lsp"
+	"bolt/module"
+	"bolt/object"
+	"bolt/parser"
+	"bolt/project"
+	"bolt/repl"
+	"bolt/resolver"
+	"bolt/token"
+	"bolt/types"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// Parse the source of a program, printing any errors with their positions
+//   - Return nil if the source could not be read or parsed, along with the exit code to use
+func parseSource(name string, std *streams) (*ast.Program, int) {
+	src, err := readSource(name, std)
+	if err != nil {
+		fmt.Fprintf(std.err, "bolt: %s\n", err)
+		return nil, EXIT_FAILURE
+	}
+
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if len(p.ParseErrors()) != 0 {
+		for _, err := range p.ParseErrors() {
+			fmt.Fprintf(std.err, "%s:%s\n", displayName(name), err)
+		}
+		return nil, EXIT_PARSE_ERROR
+	}
+	return program, EXIT_SUCCESS
+}
+
+// Resolve the names of a parsed program, printing any errors with their positions
+//   - The names bound by scriptEnvironment are predeclared
+//   - Return EXIT_CHECK_ERROR if any name cannot be resolved
+func resolveProgram(program *ast.Program, name string, std *streams) int {
+	universe := resolver.Predeclared(scriptEnvironment(name, nil, nil, io.Discard).Names()...)
+	info := resolver.Resolve(program, universe)
+	for _, err := range info.Errors {
+		fmt.Fprintf(std.err, "%s:%s\n", displayName(name), err)
+	}
+	if len(info.Errors) != 0 {
+		return EXIT_CHECK_ERROR
+	}
+	return EXIT_SUCCESS
+}
+
+// Check the types of a program whose names resolve, printing any errors with their positions
+//   - The names bound by scriptEnvironment are predeclared with the types of their values
+//   - Return EXIT_CHECK_ERROR if there are type errors
+func typeCheckProgram(program *ast.Program, name string, std *streams) int {
+	info := types.Check(program, predeclaredTypes(name))
+	for _, err := range info.Errors {
+		fmt.Fprintf(std.err, "%s:%s\n", displayName(name), err)
+	}
+	if len(info.Errors) != 0 {
+		return EXIT_CHECK_ERROR
+	}
+	return EXIT_SUCCESS
+}
+
+// Infer the types of a program, printing the type scheme of each of its top-level let bindings
+// as "name:line:col: x: type" to standard output, and its type errors to standard error
+//   - Return EXIT_CHECK_ERROR if there are type errors
+func inferProgram(program *ast.Program, name string, std *streams) int {
+	info := types.Infer(program, predeclaredTypes(name))
+	for _, stmt := range program.Statements {
+		if let, ok := stmt.(*ast.LetStatement); ok && let.Name != nil {
+			pos := let.Name.Token
+			fmt.Fprintf(std.out, "%s:%d:%d: %s: %s\n", displayName(name), pos.Line, pos.Column, let.Name.Value, info.Schemes[let.Name])
+		}
+	}
+
+	for _, err := range info.Errors {
+		fmt.Fprintf(std.err, "%s:%s\n", displayName(name), err)
+	}
+	if len(info.Errors) != 0 {
+		return EXIT_CHECK_ERROR
+	}
+	return EXIT_SUCCESS
+}
+
+// Return the static types of the names a script is run with
+func predeclaredTypes(name string) map[string]types.Type {
+	env := scriptEnvironment(name, nil, nil, io.Discard)
+	predeclared := map[string]types.Type{}
+	for _, n := range env.Names() {
+		obj, _ := env.Get(n)
+		predeclared[n] = types.Of(obj)
+	}
+	return predeclared
+}
+
+// Run a Bolt program
+//   - Exit with EXIT_PARSE_ERROR if the program does not parse, EXIT_CHECK_ERROR if its names do not resolve,
+//     and EXIT_FAILURE if it fails at runtime
+//   - The arguments following the file name are passed to the program, see scriptEnvironment
+//   - A top-level return of an integer sets the exit code, see runScript
+//   - Calls nest at most evaluator.DEFAULT_MAX_DEPTH deep, in the program and the modules it imports
+//   - -path: the directories searched for imported modules, separated as in PATH; defaults to $BOLTPATH.
+//     Imports starting with "./" or "../" are relative to the directory of the importing file
+//   - In a project, the modules are loaded from the project and its dependencies instead, see scriptImporter
+func runRun(args []string, std *streams) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(std.err)
+	searchPath := flags.String("path", os.Getenv(BOLTPATH_ENV), "the directories searched for imported modules, separated by "+string(filepath.ListSeparator))
+	if err := flags.Parse(args); err != nil {
+		return EXIT_USAGE
+	}
+
+	name := sourceName(flags.Args())
+	program, code := parseSource(name, std)
+	if program == nil {
+		return code
+	}
+	if code := resolveProgram(program, name, std); code != EXIT_SUCCESS {
+		return code
+	}
+
+	var scriptArgs []string
+	if flags.NArg() > 1 {
+		scriptArgs = flags.Args()[1:]
+	}
+	env := scriptEnvironment(name, scriptArgs, os.Environ(), std.out)
+	env.SetLimits(&object.Limits{MaxDepth: evaluator.DEFAULT_MAX_DEPTH})
+	importer, err := scriptImporter(name, *searchPath, func() *object.Environment {
+		moduleEnv := object.NewEnvironment()
+		moduleEnv.SetLimits(env.Limits())
+		return moduleEnv
+	})
+	if err != nil {
+		fmt.Fprintf(std.err, "bolt: %s\n", err)
+		return EXIT_FAILURE
+	}
+	env.SetImporter(importer)
+	return runScript(program, env, name, std)
+}
+
+// Return the importer of the modules imported by a script
+//   - In a project, the directory of a bolt.mod holding the script, or the current directory for a script
+//     read from standard input, modules are loaded from the project with its dependencies verified
+//     against bolt.sum, see project.Project.Open; imports that are not relative are looked for from the
+//     root of the project, and searchPath is not used
+//   - Otherwise modules are loaded from the directories of searchPath, see scriptLoader
+//   - Each module is evaluated in an environment created by environment
+func scriptImporter(name, searchPath string, environment func() *object.Environment) (object.Importer, error) {
+	dir := scriptDir(name)
+	p, err := project.Find(dir)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		loader := scriptLoader(searchPath)
+		loader.Environment = environment
+		return loader.Importer(dir), nil
+	}
+
+	fsys, err := p.Open()
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(p.Dir, abs)
+	if err != nil {
+		return nil, err
+	}
+	loader := module.NewFSLoader(fsys, ".")
+	loader.Environment = environment
+	return loader.Importer(filepath.ToSlash(rel)), nil
+}
+
+// The environment variable giving the default search path for imported modules
+const BOLTPATH_ENV = "BOLTPATH"
+
+// Create the loader of the modules imported by a script, searching the directories of a list
+// separated as in PATH; empty elements are ignored
+func scriptLoader(searchPath string) *module.Loader {
+	var dirs []string
+	for _, dir := range filepath.SplitList(searchPath) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return module.NewLoader(dirs...)
+}
+
+// Return the directory that relative imports of a script are resolved against,
+// the current directory for a script read from standard input
+func scriptDir(name string) string {
+	if name == "-" {
+		return "."
+	}
+	return filepath.Dir(name)
+}
+
+// Create the environment of a script, exposing how it was invoked to the program
+//   - SCRIPT: the path of the script, or "-" if it is read from standard input
+//   - ARGS: the arguments following the path of the script, as an array of strings
+//   - ENV: the environment variables, given as "key=value" pairs, as a hash of strings
+//   - The builtins of scriptBuiltins, with puts writing to out
+func scriptEnvironment(path string, args []string, environ []string, out io.Writer) *object.Environment {
+	elements := make([]object.Object, len(args))
+	for i, arg := range args {
+		elements[i] = &object.String{Value: arg}
+	}
+
+	pairs := make(map[string]object.Object, len(environ))
+	for _, kv := range environ {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			pairs[key] = &object.String{Value: value}
+		}
+	}
+
+	env := object.NewEnvironment()
+	env.Set("SCRIPT", &object.String{Value: path})
+	env.Set("ARGS", &object.Array{Elements: elements})
+	env.Set("ENV", &object.Hash{Pairs: pairs})
+	for _, builtin := range scriptBuiltins(out) {
+		env.Set(builtin.Name, builtin)
+	}
+	return env
+}
+
+// Return the builtins of a script, for using ARGS and ENV and writing output
+//   - len(x): the number of bytes of a string, elements of an array or pairs of a hash
+//   - get(x, key): the element of an array at an integer index, or the value of a hash at a string key;
+//     null if there is none
+//   - puts(args...): write the arguments to out separated by spaces and followed by a newline,
+//     strings as they are and other values as they are inspected
+func scriptBuiltins(out io.Writer) []*object.Builtin {
+	return []*object.Builtin{
+		{Name: "len", Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return argumentCountError("len", 1, len(args))
+			}
+			switch arg := args[0].(type) {
+			case *object.String:
+				return &object.Integer{Value: int64(len(arg.Value))}
+			case *object.Array:
+				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(len(arg.Pairs))}
+			}
+			return &object.Error{Message: fmt.Sprintf("len: unsupported argument of type %s", args[0].Type())}
+		}},
+		{Name: "get", Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return argumentCountError("get", 2, len(args))
+			}
+			switch x := args[0].(type) {
+			case *object.Array:
+				if index, ok := args[1].(*object.Integer); ok {
+					if index.Value < 0 || index.Value >= int64(len(x.Elements)) {
+						return nil
+					}
+					return x.Elements[index.Value]
+				}
+			case *object.Hash:
+				if key, ok := args[1].(*object.String); ok {
+					return x.Pairs[key.Value]
+				}
+			}
+			return &object.Error{Message: fmt.Sprintf("get: cannot index %s with %s", args[0].Type(), args[1].Type())}
+		}},
+		{Name: "puts", Fn: func(args ...object.Object) object.Object {
+			parts := make([]string, len(args))
+			for i, arg := range args {
+				if str, ok := arg.(*object.String); ok {
+					parts[i] = str.Value
+				} else {
+					parts[i] = arg.Inspect()
+				}
+			}
+			fmt.Fprintln(out, strings.Join(parts, " "))
+			return nil
+		}},
+	}
+}
+
+// Return the error of a builtin called with the wrong number of arguments
+func argumentCountError(name string, want, got int) *object.Error {
+	return &object.Error{Message: fmt.Sprintf("wrong number of arguments to %s: want=%d, got=%d", name, want, got)}
+}
+
+// Evaluate the top-level statements of a script in order, returning the exit code of the process
+//   - A return statement stops the script, and exits with its value if it is an integer from 0 to 255
+//   - A runtime error is printed and exits with EXIT_FAILURE
+func runScript(program *ast.Program, env *object.Environment, name string, std *streams) int {
+	for _, statement := range program.Statements {
+		switch result := evaluator.Eval(statement, env).(type) {
+		case *object.Error:
+			fmt.Fprintf(std.err, "%s: %s\n", displayName(name), result.Inspect())
+			return EXIT_FAILURE
+
+		case *object.ReturnValue:
+			code, ok := result.Value.(*object.Integer)
+			if !ok {
+				return EXIT_SUCCESS
+			}
+			if code.Value < 0 || code.Value > 255 {
+				fmt.Fprintf(std.err, "%s: exit code %d is out of range 0-255\n", displayName(name), code.Value)
+				return EXIT_FAILURE
+			}
+			return int(code.Value)
+		}
+	}
+	return EXIT_SUCCESS
+}
+
+// Check that Bolt programs parse, that their names resolve and that they are well typed, without running them
+//   - Every file is checked, and the highest exit code of the files is returned
+//   - With -infer, types are inferred rather than only checked against annotations, and the type of
+//     each top-level let binding is printed
+func runCheck(args []string, std *streams) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(std.err)
+	infer := flags.Bool("infer", false, "infer the types of unannotated code and print the type of each top-level let binding")
+	if err := flags.Parse(args); err != nil {
+		return EXIT_USAGE
+	}
+
+	names := flags.Args()
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+
+	status := EXIT_SUCCESS
+	for _, name := range names {
+		program, code := parseSource(name, std)
+		if program != nil {
+			code = resolveProgram(program, name, std)
+		}
+		switch {
+		case code != EXIT_SUCCESS:
+		case *infer:
+			code = inferProgram(program, name, std)
+		default:
+			code = typeCheckProgram(program, name, std)
+		}
+		if code > status {
+			status = code
+		}
+	}
+	return status
+}
+
+// Start the REPL, greeting the user by name if it can be found
+func runRepl(args []string, std *streams) int {
+	if len(args) != 0 {
+		fmt.Fprintln(std.err, "usage: bolt repl")
+		return EXIT_USAGE
+	}
+
+	if u, err := user.Current(); err == nil {
+		fmt.Fprintf(std.out, "Hello %s! Welcome to Bolt ⚡️\n", u.Username)
+	} else {
+		fmt.Fprintln(std.out, "Hello! Welcome to Bolt ⚡️")
+	}
+	fmt.Fprintf(std.out, "Type a command and press Enter to execute it, or :help for REPL commands.\n")
+	repl.Start(std.in, std.out)
+	return EXIT_SUCCESS
+}
+
+// Start the language server, speaking the Language Server Protocol over standard input and output
+func runLsp(args []string, std *streams) int {
+	if len(args) != 0 {
+		fmt.Fprintln(std.err, "usage: bolt lsp")
+		return EXIT_USAGE
+	}
+
+	server := lsp.NewServer(std.in, std.out)
+	server.Version = version
+	server.Predeclared = scriptEnvironment("-", nil, nil, io.Discard).Names()
+	if err := server.Serve(); err != nil {
+		fmt.Fprintf(std.err, "bolt lsp: %s\n", err)
+		return EXIT_FAILURE
+	}
+	return EXIT_SUCCESS
+}
+
+// Print the tokens of a Bolt program, one per line
+func runLex(args []string, std *streams) int {
+	flags := flag.NewFlagSet("lex", flag.ContinueOnError)
+	flags.SetOutput(std.err)
+	if err := flags.Parse(args); err != nil {
+		return EXIT_USAGE
+	}
+
+	src, err := readSource(sourceName(flags.Args()), std)
+	if err != nil {
+		fmt.Fprintf(std.err, "bolt: %s\n", err)
+		return EXIT_FAILURE
+	}
+
+	l := lexer.New(string(src))
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		fmt.Fprintf(std.out, "%d:%d\t%s\t%q\n", tok.Line, tok.Column, tok.Type, tok.Literal)
+	}
+	return EXIT_SUCCESS
+}
+
+// Parse a Bolt program and print its syntax tree
+//   - --json: print the tree as JSON rather than as a string; each node has a "kind" and a "token", the
+//     literal value of a leaf node is under "value" and its children are keyed by their role, see ast.MarshalJSON
+func runParse(args []string, std *streams) int {
+	flags := flag.NewFlagSet("parse", flag.ContinueOnError)
+	flags.SetOutput(std.err)
+	asJSON := flags.Bool("json", false, "print the AST as JSON")
+	if err := flags.Parse(args); err != nil {
+		return EXIT_USAGE
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintln(std.err, "usage: bolt parse [--json] [file.bolt]")
+		return EXIT_USAGE
+	}
+
+	program, code := parseSource(sourceName(flags.Args()), std)
+	if program == nil {
+		return code
+	}
+
+	if !*asJSON {
+		fmt.Fprintln(std.out, program.String())
+		return EXIT_SUCCESS
+	}
+
+	out, err := ast.MarshalJSON(program)
+	if err != nil {
+		fmt.Fprintf(std.err, "bolt: %s\n", err)
+		return EXIT_FAILURE
+	}
+	fmt.Fprintln(std.out, string(out))
+	return EXIT_SUCCESS
+}
@@ -1,23 +1,31 @@
 package token
 
+import "sort"
+
 type TokenType string
 
 // Token represents a token in the input
 //   - Type: the type of the token
 //   - Literal: the literal value of the token (e.g. the identifier or character)
+//   - Line: the line of the first character of the token, starting at 1
+//   - Column: the column of the first character of the token, starting at 1
 type Token struct {
 	Type    TokenType
 	Literal string
+	Line    int
+	Column  int
 }
 
 const (
 	// Special tokens
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	COMMENT = "COMMENT" // line comments, e.g. // note
 
 	// Identifiers + literals
-	IDENT = "IDENT" // variable identifiers, e.g add, foobar, x, y, ...
-	INT   = "INT"   // integer literals, e.g 123456
+	IDENT  = "IDENT"  // variable identifiers, e.g add, foobar, x, y, ...
+	INT    = "INT"    // integer literals, e.g 123456
+	STRING = "STRING" // string literals, including their quotes, e.g "hello\n"
 
 	// Operators
 	ASSIGN   = "="
@@ -34,6 +42,9 @@ const (
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"  // introduces a type annotation, e.g. let x: int = 5
+	ARROW     = "->" // introduces the result type of a function, e.g. fn(x: int) -> int
+	DOT       = "."  // selects a member of a module, e.g. math.max
 
 	// Parentheses
 	LPAREN = "("
@@ -49,6 +60,9 @@ const (
 	RETURN   = "RETURN"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
+	IMPORT   = "IMPORT"
+	EXPORT   = "EXPORT"
+	AS       = "AS"
 )
 
 var keywords = map[string]TokenType{
@@ -59,6 +73,19 @@ var keywords = map[string]TokenType{
 	"return": RETURN,
 	"true":   TRUE,
 	"false":  FALSE,
+	"import": IMPORT,
+	"export": EXPORT,
+	"as":     AS,
+}
+
+// Return the source spelling of every keyword, in sorted order
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
 }
 
 // Lookup known keywords and return a token identifier if found
@@ -0,0 +1,324 @@
+package types
+
+import (
+	"bolt/ast"
+	"bolt/resolver"
+	"bolt/token"
+	"fmt"
+	"sort"
+)
+
+// Error describes a type error
+//   - Token: the token at which the error was detected, giving its position
+//   - Message: a description of the error
+type Error struct {
+	Token   token.Token
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Token.Line, e.Token.Column, e.Message)
+}
+
+// Info is the result of checking a program
+//   - Types: the type of each expression
+//   - Defs: the type of each name declared by the program
+//   - Errors: the type errors found, in source order
+type Info struct {
+	Types  map[ast.Expression]Type
+	Defs   map[*ast.Identifier]Type
+	Errors []*Error
+}
+
+// Check the types of a program, inferring the type of each expression
+//   - predeclared: the types of the names bound by the host before the program runs
+//   - A let statement with a type annotation declares its name with that type, and its value must have it;
+//     without an annotation, the name has the type of its value
+//   - A function has the annotated types of its parameters and result, and Any for those without one;
+//     the values it returns must have its annotated result type, and the arguments of a call its parameter types
+//   - Operators are checked as the evaluator applies them: arithmetic and ordering on integers,
+//     concatenation on strings, and equality on operands of the same type
+//   - An if expression has the type of its blocks if both have the same type, and Any otherwise
+//   - Names that cannot be resolved are left to the resolver, and have type Any
+//   - The types of modules are not known until they are loaded, so imported names and the members
+//     selected from them have type Any
+//   - An expression with an error has type Any, so that one mistake is reported once
+func Check(program *ast.Program, predeclared map[string]Type) *Info {
+	names := make([]string, 0, len(predeclared))
+	for name := range predeclared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	c := &checker{
+		info: &Info{
+			Types: map[ast.Expression]Type{},
+			Defs:  map[*ast.Identifier]Type{},
+		},
+		predeclared: predeclared,
+		resolved:    resolver.Resolve(program, resolver.Predeclared(names...)),
+	}
+
+	for _, stmt := range program.Statements {
+		c.statement(stmt)
+	}
+
+	// the errors of an operator are found after those of its operands, which may follow it
+	sort.SliceStable(c.info.Errors, func(i, j int) bool {
+		a, b := c.info.Errors[i].Token, c.info.Errors[j].Token
+		return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+	})
+	return c.info
+}
+
+// checker holds the state of Check
+//   - result: the annotated result type of the function being checked, or nil outside a function or without one
+type checker struct {
+	info        *Info
+	predeclared map[string]Type
+	resolved    *resolver.Info
+	result      Type
+}
+
+// Check a statement and return the type of its value, which is Null for statements without one
+func (c *checker) statement(stmt ast.Statement) Type {
+	switch s := stmt.(type) {
+	case *ast.LetStatement:
+		valueType := c.expression(s.Value)
+		declared := valueType
+
+		if s.Type != nil {
+			if declared = c.annotation(s.Type); !assignable(valueType, declared) {
+				c.errorf(ast.FirstToken(s.Value), "cannot use %s value as %s in let %s", valueType, declared, s.Name.Value)
+			}
+		}
+		if s.Name != nil {
+			c.info.Defs[s.Name] = declared
+		}
+
+	case *ast.ImportStatement:
+		if s.Name != nil {
+			c.info.Defs[s.Name] = Any
+		}
+
+	case *ast.ReturnStatement:
+		t := c.expression(s.ReturnValue)
+		if s.ReturnValue == nil {
+			t = Null
+		}
+		if c.result != nil && !assignable(t, c.result) {
+			c.errorf(s.Token, "cannot use %s value as %s in return", t, c.result)
+		}
+		// a return does not complete, so it has no value of its own that the enclosing block could take
+		return Any
+
+	case *ast.ExpressionStatement:
+		return c.expression(s.Expression)
+	}
+	return Null
+}
+
+// Check the statements of a block and return the type of its value, that of its last statement
+//   - Statements after a return are still checked, but the value of the block is that of the return
+func (c *checker) block(block *ast.BlockStatement) Type {
+	t := Type(Null)
+	if block == nil {
+		return t
+	}
+	returned := false
+	for _, stmt := range block.Statements {
+		value := c.statement(stmt)
+		if !returned {
+			t = value
+		}
+		if _, ok := stmt.(*ast.ReturnStatement); ok {
+			returned = true
+		}
+	}
+	return t
+}
+
+// Return the type named by a type annotation, reporting an unknown type and returning Any for it
+func (c *checker) annotation(name *ast.TypeName) Type {
+	t := Lookup(name.Value)
+	if t == nil {
+		c.errorf(name.Token, "unknown type: %s", name.Value)
+		return Any
+	}
+	return t
+}
+
+// Determine whether a value of type from can be used where a value of type to is expected
+func assignable(from, to Type) bool {
+	return Identical(from, to) || from == Any || to == Any
+}
+
+// Return the type of an expression, recording it and reporting any error in it
+func (c *checker) expression(exp ast.Expression) Type {
+	if exp == nil {
+		return Any
+	}
+
+	var t Type
+	switch e := exp.(type) {
+	case *ast.IntegerLiteral:
+		t = Int
+	case *ast.StringLiteral:
+		t = String
+	case *ast.Boolean:
+		t = Bool
+	case *ast.Identifier:
+		t = c.identifier(e)
+	case *ast.MemberExpression:
+		c.expression(e.Object)
+		t = Any
+	case *ast.PrefixExpression:
+		t = c.prefix(e, c.expression(e.Right))
+	case *ast.InfixExpression:
+		left := c.expression(e.Left)
+		right := c.expression(e.Right)
+		t = c.infix(e, left, right)
+	case *ast.IfExpression:
+		c.expression(e.Condition)
+		consequence := c.block(e.Consequence)
+		alternative := Type(Null)
+		if e.Alternative != nil {
+			alternative = c.block(e.Alternative)
+		}
+		t = consequence
+		if !Identical(consequence, alternative) {
+			t = Any
+		}
+	case *ast.FunctionLiteral:
+		t = c.function(e)
+	case *ast.CallExpression:
+		t = c.call(e)
+	default:
+		t = Any
+	}
+
+	c.info.Types[exp] = t
+	return t
+}
+
+// Return the type of the declaration an identifier refers to
+func (c *checker) identifier(ident *ast.Identifier) Type {
+	decl := c.resolved.Uses[ident]
+	switch {
+	case decl == nil:
+		return Any
+	case decl.Ident == nil:
+		return c.predeclared[decl.Name]
+	}
+	// the body of a function may refer to names declared after it, whose types are not known yet
+	if t, ok := c.info.Defs[decl.Ident]; ok {
+		return t
+	}
+	return Any
+}
+
+// Return the type of a function literal, checking its body against its annotated result type
+//   - The value of the body is returned like that of a return statement
+func (c *checker) function(fn *ast.FunctionLiteral) Type {
+	t := &Func{Result: Any}
+	for _, param := range fn.Parameters {
+		paramType := Type(Any)
+		if param.Type != nil {
+			paramType = c.annotation(param.Type)
+		}
+		c.info.Defs[param.Name] = paramType
+		t.Params = append(t.Params, paramType)
+	}
+
+	outer := c.result
+	defer func() { c.result = outer }()
+	c.result = nil
+	if fn.ReturnType != nil {
+		t.Result = c.annotation(fn.ReturnType)
+		c.result = t.Result
+	}
+
+	value := c.block(fn.Body)
+	if c.result != nil && fn.Body != nil && len(fn.Body.Statements) > 0 && !assignable(value, c.result) {
+		last := fn.Body.Statements[len(fn.Body.Statements)-1]
+		c.errorf(ast.FirstToken(last), "cannot use %s value as %s in return", value, c.result)
+	}
+	return t
+}
+
+// Return the type of the result of a call, checking its arguments against the parameters of the function
+func (c *checker) call(call *ast.CallExpression) Type {
+	callee := c.expression(call.Function)
+	args := make([]Type, len(call.Arguments))
+	for i, arg := range call.Arguments {
+		args[i] = c.expression(arg)
+	}
+
+	fn, ok := callee.(*Func)
+	switch {
+	case callee == Any:
+		return Any
+	case !ok:
+		c.errorf(ast.FirstToken(call), "not a function: %s", callee)
+		return Any
+	case len(args) != len(fn.Params):
+		c.errorf(ast.FirstToken(call), "wrong number of arguments: want=%d, got=%d", len(fn.Params), len(args))
+		return fn.Result
+	}
+
+	for i, arg := range args {
+		if !assignable(arg, fn.Params[i]) {
+			c.errorf(ast.FirstToken(call.Arguments[i]), "cannot use %s value as %s in argument %d of call", arg, fn.Params[i], i+1)
+		}
+	}
+	return fn.Result
+}
+
+func (c *checker) prefix(e *ast.PrefixExpression, right Type) Type {
+	switch {
+	case e.Operator == "!":
+		return Bool
+	case e.Operator == "-" && (right == Int || right == Any):
+		return Int
+	default:
+		c.errorf(e.Token, "unknown operator: %s%s", e.Operator, right)
+		return Any
+	}
+}
+
+func (c *checker) infix(e *ast.InfixExpression, left, right Type) Type {
+	op := e.Operator
+	comparison := op == "==" || op == "!=" || op == "<" || op == ">"
+
+	switch {
+	case left == Any || right == Any:
+		if comparison {
+			return Bool
+		}
+		return Any
+
+	case !Identical(left, right):
+		c.errorf(e.Token, "type mismatch: %s %s %s", left, op, right)
+		return Any
+
+	case left == Int:
+		if comparison {
+			return Bool
+		}
+		return Int
+
+	case left == String && op == "+":
+		return String
+
+	case op == "==" || op == "!=":
+		return Bool
+
+	default:
+		c.errorf(e.Token, "unknown operator: %s %s %s", left, op, right)
+		return Any
+	}
+}
+
+func (c *checker) errorf(tok token.Token, format string, a ...interface{}) {
+	c.info.Errors = append(c.info.Errors, &Error{Token: tok, Message: fmt.Sprintf(format, a...)})
+}
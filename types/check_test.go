@@ -0,0 +1,130 @@
+package types
+
+import (
+	"bolt/ast"
+	"bolt/lexer"
+	"bolt/object"
+	"bolt/parser"
+	"reflect"
+	"testing"
+)
+
+func check(t *testing.T, input string) (*ast.Program, *Info) {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program, Check(program, map[string]Type{"NAME": String, "ARGS": Any})
+}
+
+func TestCheckErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"5 + 5 * 2;", []string{}},
+		{"5 + true;", []string{"1:3: type mismatch: int + bool"}},
+		{"true + false;", []string{"1:6: unknown operator: bool + bool"}},
+		{"-true;", []string{"1:1: unknown operator: -bool"}},
+		{"!5 == false;", []string{}},
+		{"1 < 2 == true;", []string{}},
+		{"5 == true;", []string{"1:3: type mismatch: int == bool"}},
+		{"(5 + true) + (1 + false);", []string{"1:4: type mismatch: int + bool", "1:17: type mismatch: int + bool"}},
+		{"let x = true; x * 2;", []string{"1:17: type mismatch: bool * int"}},
+		{"let x: int = 5; x + 1;", []string{}},
+		{"let x: bool = 5;", []string{"1:15: cannot use int value as bool in let x"}},
+		{"let x: number = 5;", []string{"1:8: unknown type: number"}},
+		{"let x: any = 5; x + true;", []string{}},
+		{"NAME + NAME == NAME;", []string{}},
+		{"NAME - NAME;", []string{"1:6: unknown operator: string - string"}},
+		{"NAME + 1;", []string{"1:6: type mismatch: string + int"}},
+		{"ARGS + 1; ARGS == 1;", []string{}},
+		{"let s: string = ARGS; s * 2;", []string{"1:25: type mismatch: string * int"}},
+		{"undefined + 1;", []string{}},
+		{"return 1 + true;", []string{"1:10: type mismatch: int + bool"}},
+		{"let add = fn(a: int, b: int) -> int { a + b }; add(1, 2) + 1;", []string{}},
+		{"let add = fn(a: int, b: int) -> int { a + b }; add(1, true);", []string{"1:55: cannot use bool value as int in argument 2 of call"}},
+		{"let add = fn(a: int, b: int) -> int { a + b }; add(1);", []string{"1:48: wrong number of arguments: want=2, got=1"}},
+		{"let add = fn(a: int, b: int) -> int { a + b }; add(1, 2) + true;", []string{"1:58: type mismatch: int + bool"}},
+		{"let f = fn(a: int) -> bool { a };", []string{"1:30: cannot use int value as bool in return"}},
+		{"let f = fn(a: int) -> bool { return a; };", []string{"1:30: cannot use int value as bool in return"}},
+		{"let f = fn(a: int) -> bool { if (a > 1) { return true; } false };", []string{}},
+		{"let f = fn(x: bool) -> int { if (x) { return 1; } else { return 2; } };", []string{}},
+		{"let f = fn(x: bool) -> int { if (x) { return 1; } else { return true; } };", []string{"1:58: cannot use bool value as int in return"}},
+		{"let f = fn() -> int { return 1; true };", []string{}},
+		{"let f = fn(a: number) { a };", []string{"1:15: unknown type: number"}},
+		{"let f = fn(a) { a * 2 }; f(true);", []string{}},
+		{"5(1);", []string{"1:1: not a function: int"}},
+		{"let f = fn() { g() + 1 }; let g = fn() -> bool { true };", []string{}},
+		{"let x = if (true) { 1 } else { 2 }; x + true;", []string{"1:39: type mismatch: int + bool"}},
+		{"let x = if (true) { 1 }; x + true;", []string{}},
+		{`let s: string = "a" + NAME; s - 1;`, []string{"1:31: type mismatch: string - int"}},
+		{`import "lib/m"; m.f(1) + m.x; let x: int = m.y; m(true);`, []string{}},
+		{`import "lib/m"; (m.x + true).y;`, []string{}},
+	}
+
+	for _, tt := range tests {
+		_, info := check(t, tt.input)
+
+		errors := []string{}
+		for _, err := range info.Errors {
+			errors = append(errors, err.Error())
+		}
+		if !reflect.DeepEqual(errors, tt.expected) {
+			t.Errorf("wrong errors for %q.\nexpected=%q\ngot=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestCheckTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Type
+	}{
+		{"5", Int},
+		{"true", Bool},
+		{"!5", Bool},
+		{"-5 * 2", Int},
+		{"1 < 2", Bool},
+		{"NAME + NAME", String},
+		{"ARGS", Any},
+		{"ARGS + 1", Any},
+		{"let x = 1 == 1; x", Bool},
+		{"let x: any = 1; x", Any},
+		{"fn(a: int, b) -> bool { true }", &Func{Params: []Type{Int, Any}, Result: Bool}},
+		{"fn() { 1 }()", Any},
+		{"fn() -> string { NAME }()", String},
+		{"if (ARGS) { 1 } else { 2 }", Int},
+		{"if (ARGS) { 1 } else { true }", Any},
+	}
+
+	for _, tt := range tests {
+		program, info := check(t, tt.input)
+
+		last := program.Statements[len(program.Statements)-1].(*ast.ExpressionStatement)
+		if got := info.Types[last.Expression]; !Identical(got, tt.expected) {
+			t.Errorf("wrong type for %q. expected=%s, got=%v", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestOf(t *testing.T) {
+	tests := []struct {
+		obj      object.Object
+		expected Type
+	}{
+		{&object.Integer{Value: 1}, Int},
+		{&object.Boolean{Value: true}, Bool},
+		{&object.String{Value: "a"}, String},
+		{&object.Null{}, Null},
+		{&object.Array{}, Any},
+	}
+
+	for _, tt := range tests {
+		if got := Of(tt.obj); got != tt.expected {
+			t.Errorf("Of(%s) wrong. expected=%s, got=%s", tt.obj.Inspect(), tt.expected, got)
+		}
+	}
+}
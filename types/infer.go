@@ -0,0 +1,686 @@
+package types
+
+import (
+	"bolt/ast"
+	"bolt/resolver"
+	"bolt/token"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Var is a type variable, standing for a type that inference has not determined yet
+//   - ID: a number distinguishing the variable from the others of an inference
+//   - Class: the types the variable may stand for, such as int and string for the operands of +,
+//     or nil if it may stand for any type
+//   - instance: the type the variable has been unified with, or nil
+//   - level: the depth of let statements at which the variable was created, so that generalizing
+//     the value of a let statement quantifies only the variables created in it
+type Var struct {
+	ID       int
+	Class    []Type
+	instance Type
+	level    int
+}
+
+func (v *Var) String() string {
+	if v.instance != nil {
+		return v.instance.String()
+	}
+	return fmt.Sprintf("t%d", v.ID)
+}
+
+// Scheme is the type of a name bound by a let statement, generalized over the type variables
+// that its value leaves undetermined, so that each use of the name may instantiate them differently
+//   - Vars: the quantified type variables, in order of their appearance in Type
+//   - Type: the type, with the quantified variables standing for any type of their class
+type Scheme struct {
+	Vars []*Var
+	Type Type
+}
+
+// Return the scheme in the form forall a b. fn(a, b) -> a, naming its variables in order of appearance
+//   - A variable with a class is followed by it, e.g. where a: int | string
+func (s *Scheme) String() string {
+	names := map[*Var]string{}
+	body := typeString(s.Type, names)
+	if len(s.Vars) == 0 {
+		return body
+	}
+
+	quantified := make([]string, len(s.Vars))
+	var classes []string
+	for i, v := range s.Vars {
+		quantified[i] = typeString(v, names)
+		if v.Class != nil {
+			classes = append(classes, quantified[i]+": "+classString(v.Class))
+		}
+	}
+
+	out := "forall " + strings.Join(quantified, " ") + ". " + body
+	if len(classes) > 0 {
+		out += " where " + strings.Join(classes, ", ")
+	}
+	return out
+}
+
+// Format a type, naming its type variables a, b, c and so on in order of appearance
+func typeString(t Type, names map[*Var]string) string {
+	switch t := prune(t).(type) {
+	case *Var:
+		if _, ok := names[t]; !ok {
+			names[t] = varName(len(names))
+		}
+		return names[t]
+	case *Func:
+		params := make([]string, len(t.Params))
+		for i, p := range t.Params {
+			params[i] = typeString(p, names)
+		}
+		return "fn(" + strings.Join(params, ", ") + ") -> " + typeString(t.Result, names)
+	default:
+		return t.String()
+	}
+}
+
+// Return the name of the nth type variable: a to z, then a1 to z1 and so on
+func varName(n int) string {
+	name := string(rune('a' + n%26))
+	if n >= 26 {
+		name += fmt.Sprint(n / 26)
+	}
+	return name
+}
+
+func classString(class []Type) string {
+	names := make([]string, len(class))
+	for i, t := range class {
+		names[i] = t.String()
+	}
+	return strings.Join(names, " | ")
+}
+
+// Inference is the result of inferring the types of a program
+//   - Types: the type of each expression, in which type variables stand for types left undetermined
+//   - Schemes: the type scheme of each name declared by a let statement
+//   - Errors: the type errors found, in source order
+type Inference struct {
+	Types   map[ast.Expression]Type
+	Schemes map[*ast.Identifier]*Scheme
+	Errors  []*Error
+}
+
+// Infer the principal types of a program with Hindley-Milner type inference, without needing annotations
+//   - predeclared: the types of the names bound by the host before the program runs; each use of a name
+//     of type Any has a type variable of its own
+//   - The value of a let statement is generalized, so that a function bound by let may be used at
+//     several types; parameters and the name being declared are monomorphic within the value
+//   - Annotations constrain the types they annotate, and any stands for a fresh type variable
+//   - Operators constrain their operands as the evaluator applies them: + to two integers or two strings,
+//     the other arithmetic and ordering operators to integers, and equality to two operands of the same type
+//   - The condition of an if expression may have any type; its blocks must have the same type,
+//     and without an alternative its value is null
+//   - A name used before its declaration, as a function may do with one declared after it, is not checked
+//   - The types of modules are not known until they are loaded, so each use of an imported name, and
+//     each member selected from it, has a type variable of its own
+//   - Report the unification failures of an infix expression at its operator, and those of a call
+//     at its first token
+func Infer(program *ast.Program, predeclared map[string]Type) *Inference {
+	names := make([]string, 0, len(predeclared))
+	for name := range predeclared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	in := &inferer{
+		info: &Inference{
+			Types:   map[ast.Expression]Type{},
+			Schemes: map[*ast.Identifier]*Scheme{},
+		},
+		predeclared: predeclared,
+		resolved:    resolver.Resolve(program, resolver.Predeclared(names...)),
+		env:         map[*resolver.Declaration]*Scheme{},
+		decls:       map[*ast.Identifier]*resolver.Declaration{},
+	}
+	for _, d := range in.resolved.Declarations {
+		in.decls[d.Ident] = d
+	}
+
+	for _, stmt := range program.Statements {
+		in.statement(stmt)
+	}
+
+	// replace the variables determined by the end of inference with their types
+	for exp, t := range in.info.Types {
+		in.info.Types[exp] = resolve(t)
+	}
+	for _, s := range in.info.Schemes {
+		s.Type = resolve(s.Type)
+	}
+
+	sort.SliceStable(in.info.Errors, func(i, j int) bool {
+		a, b := in.info.Errors[i].Token, in.info.Errors[j].Token
+		return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+	})
+	return in.info
+}
+
+// inferer holds the state of Infer
+//   - env: the type scheme of each declaration inferred so far
+//   - decls: the declaration made by each identifier declaring a name
+//   - level: the depth of let statements being inferred
+//   - result: the type of the result of the function being inferred, or nil outside a function
+type inferer struct {
+	info        *Inference
+	predeclared map[string]Type
+	resolved    *resolver.Info
+	env         map[*resolver.Declaration]*Scheme
+	decls       map[*ast.Identifier]*resolver.Declaration
+	nextID      int
+	level       int
+	result      Type
+}
+
+// Create a type variable at the current level
+func (in *inferer) newVar(class ...Type) *Var {
+	in.nextID++
+	v := &Var{ID: in.nextID, level: in.level}
+	if len(class) > 0 {
+		v.Class = class
+	}
+	return v
+}
+
+// Infer the type of a statement and return the type of its value, which is null for statements without one
+//   - A return statement does not complete, so the value of a block ending with it may have any type
+func (in *inferer) statement(stmt ast.Statement) Type {
+	switch s := stmt.(type) {
+	case *ast.LetStatement:
+		in.let(s)
+
+	case *ast.ReturnStatement:
+		t := Type(Null)
+		if s.ReturnValue != nil {
+			t = in.expression(s.ReturnValue)
+		}
+		if in.result != nil {
+			if err := in.unify(t, in.result); err != nil {
+				in.errorf(s.Token, "%s in return", err)
+			}
+		}
+		return in.newVar()
+
+	case *ast.ExpressionStatement:
+		return in.expression(s.Expression)
+	}
+	return Null
+}
+
+// Infer the type of the value of a let statement and bind its name to the generalization of it
+//   - A function may call itself, so the name is bound to a monomorphic type variable within the value
+func (in *inferer) let(s *ast.LetStatement) {
+	decl := in.decls[s.Name]
+
+	in.level++
+	var self *Var
+	if _, ok := s.Value.(*ast.FunctionLiteral); ok && decl != nil {
+		self = in.newVar()
+		in.env[decl] = &Scheme{Type: self}
+	}
+
+	t := in.expression(s.Value)
+	if self != nil {
+		if err := in.unify(self, t); err != nil {
+			in.errorf(ast.FirstToken(s.Value), "%s in let %s", err, s.Name.Value)
+		}
+	}
+	if s.Type != nil {
+		annotated := in.annotation(s.Type)
+		if err := in.unify(t, annotated); err != nil {
+			in.errorf(ast.FirstToken(s.Value), "cannot use %s value as %s in let %s", in.describe(t), in.describe(annotated), s.Name.Value)
+		}
+	}
+	in.level--
+
+	scheme := in.generalize(t)
+	if decl != nil {
+		in.env[decl] = scheme
+	}
+	if s.Name != nil {
+		in.info.Schemes[s.Name] = scheme
+	}
+}
+
+// Infer the type of the statements of a block, returning the type of its value, that of its last statement
+//   - The statements after a return statement are never run, so they do not give the block its value
+func (in *inferer) block(block *ast.BlockStatement) Type {
+	t := Type(Null)
+	if block == nil {
+		return t
+	}
+	returned := false
+	for _, stmt := range block.Statements {
+		value := in.statement(stmt)
+		if !returned {
+			t = value
+		}
+		if _, ok := stmt.(*ast.ReturnStatement); ok {
+			returned = true
+		}
+	}
+	return t
+}
+
+// Return the type named by a type annotation, reporting an unknown type; any is a fresh type variable
+func (in *inferer) annotation(name *ast.TypeName) Type {
+	t := Lookup(name.Value)
+	if t == nil {
+		in.errorf(name.Token, "unknown type: %s", name.Value)
+		return in.newVar()
+	}
+	if t == Any {
+		return in.newVar()
+	}
+	return t
+}
+
+// Infer the type of an expression, recording it
+func (in *inferer) expression(exp ast.Expression) Type {
+	if exp == nil {
+		return in.newVar()
+	}
+
+	var t Type
+	switch e := exp.(type) {
+	case *ast.IntegerLiteral:
+		t = Int
+	case *ast.StringLiteral:
+		t = String
+	case *ast.Boolean:
+		t = Bool
+	case *ast.Identifier:
+		t = in.identifier(e)
+	case *ast.MemberExpression:
+		in.expression(e.Object)
+		t = in.newVar()
+	case *ast.PrefixExpression:
+		t = in.prefix(e)
+	case *ast.InfixExpression:
+		t = in.infix(e)
+	case *ast.IfExpression:
+		t = in.ifExpression(e)
+	case *ast.FunctionLiteral:
+		t = in.function(e)
+	case *ast.CallExpression:
+		t = in.call(e)
+	default:
+		t = in.newVar()
+	}
+
+	in.info.Types[exp] = t
+	return t
+}
+
+// Return an instance of the type scheme of the declaration an identifier refers to
+func (in *inferer) identifier(ident *ast.Identifier) Type {
+	decl := in.resolved.Uses[ident]
+	switch {
+	case decl == nil:
+		// left to the resolver to report
+		return in.newVar()
+	case decl.Ident == nil:
+		if t := in.predeclared[decl.Name]; t != nil && t != Any {
+			return t
+		}
+		return in.newVar()
+	}
+
+	scheme, ok := in.env[decl]
+	if !ok {
+		return in.newVar()
+	}
+	return in.instantiate(scheme)
+}
+
+func (in *inferer) prefix(e *ast.PrefixExpression) Type {
+	right := in.expression(e.Right)
+	if e.Operator != "-" {
+		return Bool
+	}
+	if err := in.unify(right, Int); err != nil {
+		in.errorf(e.Token, "%s in %s", err, e.String())
+	}
+	return Int
+}
+
+func (in *inferer) infix(e *ast.InfixExpression) Type {
+	left := in.expression(e.Left)
+	right := in.expression(e.Right)
+
+	var operand, result Type
+	switch e.Operator {
+	case "+":
+		operand = in.newVar(Int, String)
+		result = operand
+	case "==", "!=":
+		operand = in.newVar()
+		result = Bool
+	case "<", ">":
+		operand, result = Int, Bool
+	default:
+		operand, result = Int, Int
+	}
+
+	for _, t := range []Type{left, right} {
+		if err := in.unify(t, operand); err != nil {
+			in.errorf(e.Token, "%s in %s", err, e.String())
+			break
+		}
+	}
+	return result
+}
+
+func (in *inferer) ifExpression(e *ast.IfExpression) Type {
+	in.expression(e.Condition)
+	consequence := in.block(e.Consequence)
+	if e.Alternative == nil {
+		return Null
+	}
+
+	alternative := in.block(e.Alternative)
+	if err := in.unify(consequence, alternative); err != nil {
+		in.errorf(e.Token, "%s in the blocks of if", err)
+	}
+	return consequence
+}
+
+// Infer the type of a function literal, binding its parameters to monomorphic types within its body
+func (in *inferer) function(fn *ast.FunctionLiteral) Type {
+	t := &Func{Params: []Type{}}
+	for _, param := range fn.Parameters {
+		paramType := Type(in.newVar())
+		if param.Type != nil {
+			paramType = in.annotation(param.Type)
+		}
+		if decl := in.decls[param.Name]; decl != nil {
+			in.env[decl] = &Scheme{Type: paramType}
+		}
+		t.Params = append(t.Params, paramType)
+	}
+
+	t.Result = in.newVar()
+	if fn.ReturnType != nil {
+		t.Result = in.annotation(fn.ReturnType)
+	}
+
+	outer := in.result
+	in.result = t.Result
+	defer func() { in.result = outer }()
+
+	value := in.block(fn.Body)
+	if err := in.unify(value, t.Result); err != nil {
+		tok := fn.Token
+		if fn.Body != nil && len(fn.Body.Statements) > 0 {
+			tok = ast.FirstToken(fn.Body.Statements[len(fn.Body.Statements)-1])
+		}
+		in.errorf(tok, "%s in return", err)
+	}
+	return t
+}
+
+// Infer the type of the result of a call, unifying the arguments with the parameters of the function
+func (in *inferer) call(call *ast.CallExpression) Type {
+	callee := in.expression(call.Function)
+	args := make([]Type, len(call.Arguments))
+	for i, arg := range call.Arguments {
+		args[i] = in.expression(arg)
+	}
+	at := ast.FirstToken(call)
+
+	switch fn := prune(callee).(type) {
+	case *Func:
+		if len(fn.Params) != len(args) {
+			in.errorf(at, "wrong number of arguments: want=%d, got=%d in %s", len(fn.Params), len(args), call.String())
+			return fn.Result
+		}
+		for i, arg := range args {
+			if err := in.unify(arg, fn.Params[i]); err != nil {
+				in.errorf(at, "%s in argument %d of %s", err, i+1, call.String())
+				break
+			}
+		}
+		return fn.Result
+
+	case *Var:
+		result := in.newVar()
+		if err := in.unify(fn, &Func{Params: args, Result: result}); err != nil {
+			in.errorf(at, "%s in %s", err, call.String())
+		}
+		return result
+
+	default:
+		in.errorf(at, "not a function: %s in %s", in.describe(fn), call.String())
+		return in.newVar()
+	}
+}
+
+// Generalize a type over its type variables created within the let statement being inferred
+func (in *inferer) generalize(t Type) *Scheme {
+	s := &Scheme{Type: t}
+	seen := map[*Var]bool{}
+	var collect func(Type)
+	collect = func(t Type) {
+		switch t := prune(t).(type) {
+		case *Var:
+			if t.level > in.level && !seen[t] {
+				seen[t] = true
+				s.Vars = append(s.Vars, t)
+			}
+		case *Func:
+			for _, p := range t.Params {
+				collect(p)
+			}
+			collect(t.Result)
+		}
+	}
+	collect(t)
+	return s
+}
+
+// Return a copy of the type of a scheme with fresh type variables in place of its quantified ones
+func (in *inferer) instantiate(s *Scheme) Type {
+	if len(s.Vars) == 0 {
+		return s.Type
+	}
+
+	fresh := map[*Var]Type{}
+	for _, v := range s.Vars {
+		fresh[v] = in.newVar(v.Class...)
+	}
+	var substitute func(Type) Type
+	substitute = func(t Type) Type {
+		switch t := prune(t).(type) {
+		case *Var:
+			if f, ok := fresh[t]; ok {
+				return f
+			}
+			return t
+		case *Func:
+			out := &Func{Params: make([]Type, len(t.Params)), Result: substitute(t.Result)}
+			for i, p := range t.Params {
+				out.Params[i] = substitute(p)
+			}
+			return out
+		default:
+			return t
+		}
+	}
+	return substitute(s.Type)
+}
+
+// unifyError describes two types that cannot be unified
+//   - infinite: whether b contains the variable a, which would make an infinite type
+type unifyError struct {
+	a, b     Type
+	infinite bool
+}
+
+func (e *unifyError) Error() string {
+	names := map[*Var]string{}
+	a, b := describe(e.a, names), describe(e.b, names)
+	if e.infinite {
+		return fmt.Sprintf("infinite type: %s occurs in %s", a, b)
+	}
+	return fmt.Sprintf("cannot unify %s with %s", a, b)
+}
+
+// Make two types equal by determining the type variables in them
+func (in *inferer) unify(a, b Type) error {
+	a, b = prune(a), prune(b)
+	if a == b {
+		return nil
+	}
+
+	if v, ok := a.(*Var); ok {
+		return in.bind(v, b)
+	}
+	if v, ok := b.(*Var); ok {
+		return in.bind(v, a)
+	}
+
+	fa, okA := a.(*Func)
+	fb, okB := b.(*Func)
+	if !okA || !okB || len(fa.Params) != len(fb.Params) {
+		return &unifyError{a: a, b: b}
+	}
+	for i := range fa.Params {
+		if err := in.unify(fa.Params[i], fb.Params[i]); err != nil {
+			return err
+		}
+	}
+	return in.unify(fa.Result, fb.Result)
+}
+
+// Determine a type variable as a type, keeping it within its class
+func (in *inferer) bind(v *Var, t Type) error {
+	if w, ok := t.(*Var); ok {
+		class := w.Class
+		if v.Class != nil {
+			class = v.Class
+			if w.Class != nil {
+				class = intersect(v.Class, w.Class)
+			}
+		}
+		if v.Class != nil && w.Class != nil && len(class) == 0 {
+			return &unifyError{a: v, b: w}
+		}
+		w.Class = class
+		if v.level < w.level {
+			w.level = v.level
+		}
+		v.instance = w
+		return nil
+	}
+
+	if occurs(v, t) {
+		return &unifyError{a: v, b: t, infinite: true}
+	}
+	if v.Class != nil && !inClass(t, v.Class) {
+		return &unifyError{a: t, b: v}
+	}
+	lowerLevels(t, v.level)
+	v.instance = t
+	return nil
+}
+
+// Determine whether a type variable occurs in a type
+func occurs(v *Var, t Type) bool {
+	switch t := prune(t).(type) {
+	case *Var:
+		return t == v
+	case *Func:
+		for _, p := range t.Params {
+			if occurs(v, p) {
+				return true
+			}
+		}
+		return occurs(v, t.Result)
+	}
+	return false
+}
+
+// Lower the levels of the type variables in a type to at most level, as it is now part of a type at that level
+func lowerLevels(t Type, level int) {
+	switch t := prune(t).(type) {
+	case *Var:
+		if t.level > level {
+			t.level = level
+		}
+	case *Func:
+		for _, p := range t.Params {
+			lowerLevels(p, level)
+		}
+		lowerLevels(t.Result, level)
+	}
+}
+
+func inClass(t Type, class []Type) bool {
+	for _, c := range class {
+		if c == t {
+			return true
+		}
+	}
+	return false
+}
+
+func intersect(a, b []Type) []Type {
+	out := []Type{}
+	for _, t := range a {
+		if inClass(t, b) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Return the type a type variable has been determined as, following chains of variables,
+// or the type itself if it is not a determined variable
+func prune(t Type) Type {
+	for {
+		v, ok := t.(*Var)
+		if !ok || v.instance == nil {
+			return t
+		}
+		t = v.instance
+	}
+}
+
+// Return a type with each determined type variable in it replaced by its type
+func resolve(t Type) Type {
+	switch t := prune(t).(type) {
+	case *Func:
+		out := &Func{Params: make([]Type, len(t.Params)), Result: resolve(t.Result)}
+		for i, p := range t.Params {
+			out.Params[i] = resolve(p)
+		}
+		return out
+	default:
+		return t
+	}
+}
+
+// Format a type for an error message, describing an undetermined variable with a class by its class
+func describe(t Type, names map[*Var]string) string {
+	if v, ok := prune(t).(*Var); ok && v.Class != nil {
+		return classString(v.Class)
+	}
+	return typeString(t, names)
+}
+
+func (in *inferer) describe(t Type) string {
+	return describe(t, map[*Var]string{})
+}
+
+func (in *inferer) errorf(tok token.Token, format string, a ...interface{}) {
+	in.info.Errors = append(in.info.Errors, &Error{Token: tok, Message: fmt.Sprintf(format, a...)})
+}
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"bolt/ast"
+	"bolt/lexer"
+	"bolt/parser"
+	"reflect"
+	"testing"
+)
+
+func infer(t *testing.T, input string) (*ast.Program, *Inference) {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program, Infer(program, map[string]Type{"NAME": String, "ARGS": Any})
+}
+
+func TestInferSchemes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 5;", "int"},
+		{"let x = 1 < 2;", "bool"},
+		{"let x = NAME + NAME;", "string"},
+		{"let x = ARGS;", "forall a. a"},
+		{"let id = fn(x) { x };", "forall a. fn(a) -> a"},
+		{"let const = fn(x, y) { x };", "forall a b. fn(a, b) -> a"},
+		{"let inc = fn(x) { x + 1 };", "fn(int) -> int"},
+		{"let add = fn(a, b) { a + b };", "forall a. fn(a, a) -> a where a: int | string"},
+		{"let eq = fn(a, b) { a == b };", "forall a. fn(a, a) -> bool"},
+		{"let apply = fn(f, x) { f(x) };", "forall a b. fn(fn(a) -> b, a) -> b"},
+		{"let compose = fn(f, g) { fn(x) { f(g(x)) } };", "forall a b c. fn(fn(a) -> b, fn(c) -> a) -> fn(c) -> b"},
+		{"let fact = fn(n) { if (n < 2) { return 1; } n * fact(n - 1) };", "fn(int) -> int"},
+		{"let pick = fn(c, a, b) { if (c) { a } else { b } };", "forall a b. fn(a, b, b) -> b"},
+		{"let f = fn(x: int) -> bool { x > 0 };", "fn(int) -> bool"},
+		{"let f = fn(x: any) { x };", "forall a. fn(a) -> a"},
+		{"let f = fn() { };", "fn() -> null"},
+		{"let f = fn(x) { return x; 1 };", "forall a. fn(a) -> a"},
+		{"let id = fn(x) { x }; let pair = fn(a, b) { a }; let x = pair(id(1), id(true));", "int"},
+		{`let x = "a" + "b";`, "string"},
+		{`import "lib/m"; let f = fn(x) { m.g(x) + m.n };`, "forall a b. fn(a) -> b where b: int | string"},
+	}
+
+	for _, tt := range tests {
+		program, info := infer(t, tt.input)
+		if len(info.Errors) != 0 {
+			t.Errorf("unexpected errors for %q: %v", tt.input, info.Errors)
+			continue
+		}
+
+		last := program.Statements[len(program.Statements)-1].(*ast.LetStatement)
+		if got := info.Schemes[last.Name].String(); got != tt.expected {
+			t.Errorf("wrong scheme for %q. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestInferErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"5 + true;", []string{"1:3: cannot unify bool with int in (5 + true)"}},
+		{"true + false;", []string{"1:6: cannot unify bool with int | string in (true + false)"}},
+		{"NAME - 1;", []string{"1:6: cannot unify string with int in (NAME - 1)"}},
+		{"-true;", []string{"1:1: cannot unify bool with int in (-true)"}},
+		{"1 == true;", []string{"1:3: cannot unify bool with int in (1 == true)"}},
+		{"let f = fn(x) { x + 1 }; f(true);", []string{"1:26: cannot unify bool with int in argument 1 of f(true)"}},
+		{"let f = fn(x) { x }; f(1, 2);", []string{"1:22: wrong number of arguments: want=1, got=2 in f(1, 2)"}},
+		{"5(1);", []string{"1:1: not a function: int in 5(1)"}},
+		{"let f = fn(x) { x(x) };", []string{"1:17: infinite type: a occurs in fn(a) -> b in x(x)"}},
+		{"let f = fn(g) { g(1) + g(true) };", []string{"1:24: cannot unify bool with int in argument 1 of g(true)"}},
+		{"let f = fn(x) { if (x) { 1 } else { true } };", []string{"1:17: cannot unify int with bool in the blocks of if"}},
+		{"let x: bool = 5;", []string{"1:15: cannot use int value as bool in let x"}},
+		{"let f = fn(x) -> int { return true; };", []string{"1:24: cannot unify bool with int in return"}},
+		{"let f = fn(x) -> int { true };", []string{"1:24: cannot unify bool with int in return"}},
+		{"let x: number = 1;", []string{"1:8: unknown type: number"}},
+		{"let id = fn(x) { x }; id(1) + id(true);", []string{"1:29: cannot unify bool with int in (id(1) + id(true))"}},
+		{"ARGS + 1; ARGS == true; undefined + 1;", []string{}},
+		{"ARGS + true;", []string{"1:6: cannot unify bool with int | string in (ARGS + true)"}},
+		{"let x = if (true) { 1 }; x + 1;", []string{"1:28: cannot unify null with int | string in (x + 1)"}},
+		{`import "lib/m"; m.x + 1; m.y + true; "a" + 1;`, []string{"1:30: cannot unify bool with int | string in (m.y + true)", "1:42: cannot unify int with string in (\"a\" + 1)"}},
+	}
+
+	for _, tt := range tests {
+		_, info := infer(t, tt.input)
+
+		errors := []string{}
+		for _, err := range info.Errors {
+			errors = append(errors, err.Error())
+		}
+		if !reflect.DeepEqual(errors, tt.expected) {
+			t.Errorf("wrong errors for %q.\nexpected=%q\ngot=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestInferTypes(t *testing.T) {
+	program, info := infer(t, "let id = fn(x) { x }; id(1); id(NAME)")
+
+	expected := []Type{Int, String}
+	for i, stmt := range program.Statements[1:] {
+		exp := stmt.(*ast.ExpressionStatement).Expression
+		if got := info.Types[exp]; got != expected[i] {
+			t.Errorf("wrong type for %s. expected=%s, got=%v", exp.String(), expected[i], got)
+		}
+	}
+}
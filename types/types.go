@@ -0,0 +1,92 @@
+package types
+
+import (
+	"bolt/object"
+	"strings"
+)
+
+// Type is the static type of a Bolt value
+type Type interface {
+	String() string
+}
+
+// Basic is a type built into the language, named by type annotations
+type Basic struct {
+	Name string
+}
+
+func (b *Basic) String() string { return b.Name }
+
+// Func is the type of a function
+//   - Params: the types of the parameters, in order
+//   - Result: the type of the value a call returns
+type Func struct {
+	Params []Type
+	Result Type
+}
+
+func (f *Func) String() string {
+	params := make([]string, len(f.Params))
+	for i, p := range f.Params {
+		params[i] = p.String()
+	}
+	return "fn(" + strings.Join(params, ", ") + ") -> " + f.Result.String()
+}
+
+// The built-in types
+//   - Any is the type of values whose type is not known statically, such as names bound by the host;
+//     operations on Any values are not checked
+var (
+	Int    = &Basic{Name: "int"}
+	Bool   = &Basic{Name: "bool"}
+	String = &Basic{Name: "string"}
+	Null   = &Basic{Name: "null"}
+	Any    = &Basic{Name: "any"}
+)
+
+// The types that can be named in a type annotation, by name
+var annotations = map[string]Type{
+	Int.Name:    Int,
+	Bool.Name:   Bool,
+	String.Name: String,
+	Any.Name:    Any,
+}
+
+// Determine whether two types are the same: function types are compared by structure, other types by identity
+func Identical(a, b Type) bool {
+	fa, ok := a.(*Func)
+	if !ok {
+		return a == b
+	}
+	fb, ok := b.(*Func)
+	if !ok || len(fa.Params) != len(fb.Params) || !Identical(fa.Result, fb.Result) {
+		return false
+	}
+	for i := range fa.Params {
+		if !Identical(fa.Params[i], fb.Params[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Return the type named by a type annotation, or nil if there is no such type
+func Lookup(name string) Type {
+	return annotations[name]
+}
+
+// Return the static type of a runtime value, or Any if it has no static type of its own
+func Of(obj object.Object) Type {
+	switch obj.(type) {
+	case *object.Integer:
+		return Int
+	case *object.Boolean:
+		return Bool
+	case *object.String:
+		return String
+	case *object.Null:
+		return Null
+	default:
+		return Any
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bolt/lexer"
+	"bolt/lint"
+	"bolt/parser"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+)
+
+// vetFinding is a lint finding in a file, as printed by `bolt vet -json`
+type vetFinding struct {
+	File string `json:"file"`
+	lint.Finding
+}
+
+// Report suspicious code in Bolt source files
+//   - With no paths, check standard input
+//   - Directories are walked recursively for .bolt files
+//   - -config: the configuration enabling and disabling rules, by default lint.CONFIG_FILE if it exists
+//   - -json: print the findings as a JSON array instead of one per line
+//   - -rules: list the rules and exit
+//   - Exit with EXIT_FAILURE if there are findings, and EXIT_PARSE_ERROR if a file does not parse
+func runVet(args []string, std *streams) int {
+	flags := flag.NewFlagSet("vet", flag.ContinueOnError)
+	flags.SetOutput(std.err)
+	configPath := flags.String("config", "", "read the rule configuration from `file`")
+	asJSON := flags.Bool("json", false, "print findings as JSON")
+	listRules := flags.Bool("rules", false, "list the rules and exit")
+	if err := flags.Parse(args); err != nil {
+		return EXIT_USAGE
+	}
+
+	if *listRules {
+		for _, rule := range lint.Rules() {
+			fmt.Fprintf(std.out, "%-20s %s\n", rule.Name, rule.Description)
+		}
+		return EXIT_SUCCESS
+	}
+
+	config, err := loadVetConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(std.err, "bolt: %s\n", err)
+		return EXIT_USAGE
+	}
+
+	findings := []vetFinding{}
+	vetFile := func(name string) int {
+		src, err := readSource(name, std)
+		if err != nil {
+			fmt.Fprintf(std.err, "bolt: %s\n", err)
+			return EXIT_FAILURE
+		}
+		p := parser.New(lexer.New(string(src)))
+		program := p.ParseProgram()
+		if len(p.ParseErrors()) != 0 {
+			for _, err := range p.ParseErrors() {
+				fmt.Fprintf(std.err, "%s:%s\n", displayName(name), err)
+			}
+			return EXIT_PARSE_ERROR
+		}
+
+		for _, f := range lint.Run(program, config) {
+			findings = append(findings, vetFinding{File: displayName(name), Finding: f})
+		}
+		return EXIT_SUCCESS
+	}
+
+	var status int
+	if flags.NArg() == 0 {
+		status = vetFile("-")
+	} else {
+		status = walkSources(flags.Args(), std, vetFile)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(std.out)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		enc.Encode(findings)
+	} else {
+		for _, f := range findings {
+			fmt.Fprintf(std.out, "%s:%s\n", f.File, f.Finding)
+		}
+	}
+
+	if len(findings) > 0 && status < EXIT_FAILURE {
+		status = EXIT_FAILURE
+	}
+	return status
+}
+
+// Load the configuration of `bolt vet` from a file, or from lint.CONFIG_FILE if no file is given
+//   - Return a nil config, enabling every rule, if no file is given and lint.CONFIG_FILE does not exist
+func loadVetConfig(path string) (*lint.Config, error) {
+	if path != "" {
+		return lint.LoadConfig(path)
+	}
+	config, err := lint.LoadConfig(lint.CONFIG_FILE)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return config, err
+}